@@ -0,0 +1,191 @@
+// Package aggregate downsamples readings into time buckets with the
+// minimum, maximum and average of each field.
+package aggregate
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"backend/database"
+
+	"gorm.io/gorm"
+)
+
+// Intervals are the supported bucket sizes by name.
+var Intervals = map[string]time.Duration{
+	"1m": time.Minute,
+	"5m": 5 * time.Minute,
+	"1h": time.Hour,
+	"1d": 24 * time.Hour,
+}
+
+// IntervalNames lists the keys of Intervals, shortest first.
+var IntervalNames = []string{"1m", "5m", "1h", "1d"}
+
+// MaxBuckets bounds the size of one response.
+const MaxBuckets = 10000
+
+// ErrTooManyBuckets is returned by Add when the readings span more than MaxBuckets intervals.
+var ErrTooManyBuckets = fmt.Errorf("more than %d buckets, use a longer interval or a shorter time range", MaxBuckets)
+
+// NumericFields returns the JSON names of the float64 fields of a model
+// struct, the fields that can be aggregated.
+func NumericFields(model any) []string {
+	var fields []string
+	t := reflect.TypeOf(model)
+	for i := range t.NumField() {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if f.Type.Kind() == reflect.Float64 && name != "" && name != "-" {
+			fields = append(fields, name)
+		}
+	}
+	return fields
+}
+
+// Stats summarise one field over a bucket.
+type Stats struct {
+	// Count is the number of values of the field, which may be less than
+	// the readings of the bucket when some are missing.
+	Count int     `json:"count"`
+	Min   float64 `json:"min"`
+	Max   float64 `json:"max"`
+	Avg   float64 `json:"avg"`
+}
+
+// Bucket holds the readings of one interval, starting at Start in local
+// time. Fields without any value in the interval are left out.
+type Bucket struct {
+	Start  time.Time        `json:"start"`
+	Count  int              `json:"count"`
+	Fields map[string]Stats `json:"fields"`
+}
+
+// Aggregator collects readings into buckets.
+type Aggregator struct {
+	interval time.Duration
+	fields   []string
+	buckets  map[time.Time]*bucket
+}
+
+type bucket struct {
+	count int
+	// Per field: the number of values, as some may be missing.
+	n             []int
+	min, max, sum []float64
+}
+
+// New returns an Aggregator for buckets of the given interval, which must
+// divide a day. Values passed to Add are in the order of fields.
+func New(interval time.Duration, fields []string) *Aggregator {
+	return &Aggregator{interval: interval, fields: fields, buckets: map[time.Time]*bucket{}}
+}
+
+// Start returns the start of the bucket containing t. Buckets are aligned
+// to local midnight, so a 1d bucket is a calendar day.
+func (a *Aggregator) Start(t time.Time) time.Time {
+	t = t.In(time.Local)
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.Local)
+	return midnight.Add(t.Sub(midnight).Truncate(a.interval))
+}
+
+// Add counts a reading taken at t. A NaN value stands for a missing one.
+func (a *Aggregator) Add(t time.Time, values []float64) error {
+	start := a.Start(t)
+	b, ok := a.buckets[start]
+	if !ok {
+		if len(a.buckets) >= MaxBuckets {
+			return ErrTooManyBuckets
+		}
+		n := len(a.fields)
+		b = &bucket{n: make([]int, n), min: make([]float64, n), max: make([]float64, n), sum: make([]float64, n)}
+		for i := range n {
+			b.min[i], b.max[i] = math.Inf(1), math.Inf(-1)
+		}
+		a.buckets[start] = b
+	}
+	b.count++
+	for i, v := range values {
+		if math.IsNaN(v) {
+			continue
+		}
+		b.n[i]++
+		b.min[i] = math.Min(b.min[i], v)
+		b.max[i] = math.Max(b.max[i], v)
+		b.sum[i] += v
+	}
+	return nil
+}
+
+// Buckets returns the non-empty buckets, oldest first.
+func (a *Aggregator) Buckets() []Bucket {
+	starts := make([]time.Time, 0, len(a.buckets))
+	for start := range a.buckets {
+		starts = append(starts, start)
+	}
+	slices.SortFunc(starts, time.Time.Compare)
+
+	out := make([]Bucket, 0, len(starts))
+	for _, start := range starts {
+		b := a.buckets[start]
+		stats := make(map[string]Stats, len(a.fields))
+		for i, f := range a.fields {
+			if b.n[i] > 0 {
+				stats[f] = Stats{Count: b.n[i], Min: b.min[i], Max: b.max[i], Avg: round(b.sum[i] / float64(b.n[i]))}
+			}
+		}
+		out = append(out, Bucket{Start: start, Count: b.count, Fields: stats})
+	}
+	return out
+}
+
+// round keeps averages readable; readings have at most two decimals.
+func round(v float64) float64 {
+	return math.Round(v*1000) / 1000
+}
+
+// Query aggregates the rows of db, which selects the table and filters, by
+// streaming their timestamp and fields. Rows without a timestamp are
+// skipped.
+func Query(db *gorm.DB, interval time.Duration, fields []string) ([]Bucket, error) {
+	rows, err := db.Select(append([]string{"timestamp"}, fields...)).Order("timestamp").Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	a := New(interval, fields)
+	var ts database.Time
+	nulls := make([]sql.NullFloat64, len(fields))
+	dest := []any{&ts}
+	for i := range nulls {
+		dest = append(dest, &nulls[i])
+	}
+	values := make([]float64, len(fields))
+	for rows.Next() {
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+		if ts.IsZero() {
+			continue
+		}
+		for i, v := range nulls {
+			values[i] = math.NaN()
+			if v.Valid {
+				values[i] = v.Float64
+			}
+		}
+		if err := a.Add(ts.Time, values); err != nil {
+			return nil, err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return a.Buckets(), nil
+}
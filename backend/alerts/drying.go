@@ -0,0 +1,50 @@
+package alerts
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"backend/models"
+	"backend/notify"
+)
+
+// DryingComplete notifies and records an alert when a drying session ends
+// because the laundry was detected dry. Its SessionEnded method is meant to
+// be used as the sessions.Manager OnEnd hook.
+type DryingComplete struct {
+	Notifier notify.Notifier
+	Store    Store
+	Now      func() time.Time
+}
+
+// SessionEnded sends the drying-complete alert for s. Sessions that ended
+// for any other reason are ignored.
+func (d *DryingComplete) SessionEnded(s models.DryingSession) {
+	if s.EndReason != models.EndReasonDryDetected {
+		return
+	}
+	msg := DryingCompleteMessage(s)
+	if err := d.Notifier.Notify(context.Background(), msg); err != nil {
+		log.Printf("Drying complete alert for session %d: %v", s.TestID, err)
+	}
+
+	now := time.Now
+	if d.Now != nil {
+		now = d.Now
+	}
+	alert := &models.Alert{Kind: msg.Kind, Message: msg.Body, SentAt: now()}
+	if err := d.Store.Record(alert); err != nil {
+		log.Printf("Failed to record drying complete alert for session %d: %v", s.TestID, err)
+	}
+}
+
+// DryingCompleteMessage builds the notification for a session that ended dry.
+func DryingCompleteMessage(s models.DryingSession) notify.Message {
+	body := fmt.Sprintf("👕 Your laundry is dry! Drying session #%d is complete", s.TestID)
+	if s.EndedAt != nil && !s.StartedAt.IsZero() {
+		body += fmt.Sprintf(" after %d minutes", int(s.EndedAt.Sub(s.StartedAt).Minutes()))
+	}
+	return notify.Message{Kind: models.AlertDryingComplete, Title: "Laundry is dry", Body: body + "."}
+}
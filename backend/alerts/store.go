@@ -0,0 +1,101 @@
+package alerts
+
+import (
+	"errors"
+	"time"
+
+	"backend/database"
+	"backend/events"
+	"backend/models"
+
+	"gorm.io/gorm"
+)
+
+// ErrNotFound is returned by Acknowledge for an unknown alert.
+var ErrNotFound = errors.New("alert not found")
+
+// Store records sent alerts.
+type Store interface {
+	// Last returns the most recently sent alert of any of the given kinds, or nil if none was sent.
+	Last(kinds ...string) (*models.Alert, error)
+	Record(a *models.Alert) error
+	// Acknowledge marks an alert as seen at the given timestamp and returns it.
+	// An alert that was already acknowledged keeps its first timestamp.
+	Acknowledge(id uint, at time.Time) (*models.Alert, error)
+}
+
+// Default is the Store used by the rain watch, drying-complete alerts and
+// the HTTP and WebSocket handlers.
+var Default Store = Publishing{Store: GormStore{}, Bus: events.Default}
+
+// GormStore keeps alerts in the alerts table of database.DB.
+type GormStore struct{}
+
+func (GormStore) Last(kinds ...string) (*models.Alert, error) {
+	var a models.Alert
+	err := database.DB.Where("kind IN ?", kinds).Order("id desc").First(&a).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+func (GormStore) Record(a *models.Alert) error {
+	return database.DB.Create(a).Error
+}
+
+func (GormStore) Acknowledge(id uint, at time.Time) (*models.Alert, error) {
+	var a models.Alert
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&a, id).Error; err != nil {
+			return err
+		}
+		if a.AcknowledgedAt != nil {
+			return nil
+		}
+		at = models.StoredTime(at)
+		a.AcknowledgedAt = &at
+		return tx.Model(&a).Update("acknowledged_at", at).Error
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+// Recent returns up to limit alerts, newest first.
+func Recent(limit int) ([]models.Alert, error) {
+	list := []models.Alert{}
+	err := database.DB.Order("id desc").Limit(limit).Find(&list).Error
+	return list, err
+}
+
+// Publishing is a Store that also publishes every recorded and acknowledged
+// alert as an alert event.
+type Publishing struct {
+	Store
+	Bus *events.Bus
+}
+
+func (p Publishing) Record(a *models.Alert) error {
+	if err := p.Store.Record(a); err != nil {
+		return err
+	}
+	p.Bus.Publish(events.TypeAlert, "", 0, *a)
+	return nil
+}
+
+func (p Publishing) Acknowledge(id uint, at time.Time) (*models.Alert, error) {
+	a, err := p.Store.Acknowledge(id, at)
+	if err != nil {
+		return nil, err
+	}
+	p.Bus.Publish(events.TypeAlert, "", 0, *a)
+	return a, nil
+}
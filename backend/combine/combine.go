@@ -0,0 +1,234 @@
+// Package combine joins time_to_dry readings with the nearest tmd weather
+// record into combined_data. Each run only handles the readings stored since
+// the previous one; readings left unmatched are joined again by Rejoin once
+// weather for their time is imported.
+package combine
+
+import (
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"backend/models"
+)
+
+// DefaultBatchSize is how many readings are joined and saved together.
+const DefaultBatchSize = 1000
+
+// Result summarises a run.
+type Result struct {
+	// Processed counts the readings handled, matched or not.
+	Processed int `json:"processed"`
+	// Inserted counts the combined rows stored; readings already combined are not stored twice.
+	Inserted int `json:"inserted"`
+	// Unmatched counts readings without weather within the maximum gap, or
+	// without observations on both sides when interpolating. They are joined
+	// by Rejoin when weather of their time is imported.
+	Unmatched int `json:"unmatched"`
+	// Invalid counts readings whose timestamp cannot be parsed.
+	Invalid int `json:"invalid"`
+	// Pending counts readings left for a later run, as weather near them may still arrive.
+	Pending int64 `json:"pending"`
+	// Watermark is the id of the last reading handled.
+	Watermark uint `json:"watermark"`
+}
+
+// Joiner combines readings with weather.
+type Joiner struct {
+	Store     Store
+	Config    Config
+	BatchSize int
+	Now       func() time.Time
+
+	// mu keeps two runs from joining the same readings.
+	mu sync.Mutex
+}
+
+// New returns a Joiner on the database with DefaultConfig.
+func New() *Joiner {
+	return &Joiner{Store: GormStore{}, Config: DefaultConfig, BatchSize: DefaultBatchSize, Now: time.Now}
+}
+
+// NewFromEnv is New configured from the environment, which must be loaded first.
+func NewFromEnv() *Joiner {
+	j := New()
+	j.Config = ConfigFromEnv()
+	return j
+}
+
+// Default is the Joiner used by the populate endpoint. main replaces it
+// with NewFromEnv once the environment is loaded.
+var Default = New()
+
+// Run joins the readings stored after the watermark, in id order, and
+// advances the watermark past them. It stops at the first reading that may
+// still get nearer weather: one newer than the latest weather record and
+// less than the maximum gap old.
+func (j *Joiner) Run() (*Result, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	watermark, err := j.Store.Watermark()
+	if err != nil {
+		return nil, err
+	}
+	latestWeather, err := j.Store.LatestWeather()
+	if err != nil {
+		return nil, err
+	}
+	batchSize := j.BatchSize
+	if batchSize <= 0 {
+		batchSize = DefaultBatchSize
+	}
+
+	res := &Result{Watermark: watermark}
+	for {
+		readings, err := j.Store.Readings(watermark, batchSize)
+		if err != nil {
+			return res, err
+		}
+		if len(readings) == 0 {
+			break
+		}
+		rows, lastID, stopped, err := j.join(readings, watermark, latestWeather, true, res)
+		if err != nil {
+			return res, err
+		}
+		if lastID == watermark {
+			break
+		}
+		inserted, err := j.Store.Save(rows, lastID)
+		if err != nil {
+			return res, err
+		}
+		res.Inserted += inserted
+		res.Watermark, watermark = lastID, lastID
+		if stopped || len(readings) < batchSize {
+			break
+		}
+	}
+
+	if res.Pending, err = j.Store.CountReadings(watermark); err != nil {
+		return res, err
+	}
+	return res, nil
+}
+
+// Rejoin joins the readings up to the watermark with a timestamp within the
+// maximum gap of from to to and no combined row, such as readings counted
+// as unmatched before the weather of their time was imported. The
+// watermark does not move.
+func (j *Joiner) Rejoin(from, to time.Time) (*Result, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	watermark, err := j.Store.Watermark()
+	if err != nil {
+		return nil, err
+	}
+	batchSize := j.BatchSize
+	if batchSize <= 0 {
+		batchSize = DefaultBatchSize
+	}
+
+	res := &Result{Watermark: watermark}
+	from, to = from.Add(-j.Config.MaxGap), to.Add(j.Config.MaxGap)
+	var afterID uint
+	for {
+		readings, err := j.Store.Unjoined(afterID, watermark, from, to, batchSize)
+		if err != nil {
+			return res, err
+		}
+		if len(readings) == 0 {
+			break
+		}
+		rows, lastID, _, err := j.join(readings, afterID, time.Time{}, false, res)
+		if err != nil {
+			return res, err
+		}
+		inserted, err := j.Store.Save(rows, watermark)
+		if err != nil {
+			return res, err
+		}
+		res.Inserted += inserted
+		afterID = lastID
+		if len(readings) < batchSize {
+			break
+		}
+	}
+	return res, nil
+}
+
+// join matches a batch of readings following the one with id lastID. It
+// returns the combined rows, the id of the last reading handled and whether
+// it stopped at a reading that must wait, which only happens when wait is
+// set.
+func (j *Joiner) join(readings []models.TimeToDry, lastID uint, latestWeather time.Time, wait bool, res *Result) ([]models.CombinedData, uint, bool, error) {
+	var first, last time.Time
+	for _, r := range readings {
+		t := r.Timestamp
+		if t.IsZero() {
+			continue
+		}
+		if first.IsZero() || t.Before(first) {
+			first = t
+		}
+		if t.After(last) {
+			last = t
+		}
+	}
+
+	var weather []weatherPoint
+	if !first.IsZero() {
+		records, err := j.Store.Weather(first.Add(-j.Config.MaxGap), last.Add(j.Config.MaxGap))
+		if err != nil {
+			return nil, 0, false, err
+		}
+		weather = make([]weatherPoint, 0, len(records))
+		for _, rec := range records {
+			if rec.Timestamp.IsZero() {
+				log.Printf("Skipping TMD record %d without a timestamp", rec.ID)
+				continue
+			}
+			weather = append(weather, weatherPoint{rec.Timestamp, rec})
+		}
+		sort.SliceStable(weather, func(a, b int) bool { return weather[a].at.Before(weather[b].at) })
+	}
+
+	now := j.Now()
+	var rows []models.CombinedData
+	for _, r := range readings {
+		t := r.Timestamp
+		if t.IsZero() {
+			log.Printf("Skipping TimeToDry reading %d without a timestamp", r.ID)
+			res.Invalid++
+		} else if wait && latestWeather.Before(t) && now.Sub(t) < j.Config.MaxGap {
+			return rows, lastID, true, nil
+		} else if m, ok := j.Config.find(weather, t); ok {
+			rows = append(rows, models.CombinedData{
+				Timestamp:         t,
+				Lat:               r.Lat,
+				Lon:               r.Lon,
+				TempIn:            r.TempIn,
+				TempOut:           r.TempOut,
+				HumIn:             r.HumIn,
+				HumOut:            r.HumOut,
+				DiffTemp:          r.DiffTemp,
+				DiffHum:           r.DiffHum,
+				TestID:            r.TestID,
+				APITemp:           m.temperature,
+				APIHumidity:       m.humidity,
+				Rainfall:          m.rainfall,
+				CreatedAt:         now,
+				WeatherTimestamp:  &m.timestamp,
+				WeatherGapSeconds: int64(m.gap / time.Second),
+			})
+		} else {
+			res.Unmatched++
+		}
+		res.Processed++
+		lastID = r.ID
+	}
+	return rows, lastID, false, nil
+}
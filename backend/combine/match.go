@@ -0,0 +1,135 @@
+package combine
+
+import (
+	"log"
+	"slices"
+	"sort"
+	"time"
+
+	"backend/config"
+	"backend/models"
+)
+
+// Strategies for deriving the weather at the time of a reading.
+const (
+	// Nearest takes the observation closest in time, the earlier one on a tie.
+	Nearest = "nearest"
+	// Interpolate interpolates linearly between the observations just before
+	// and just after the reading. Both must be within the maximum gap.
+	Interpolate = "interpolate"
+	// CarryForward takes the last observation at or before the reading.
+	CarryForward = "carry_forward"
+)
+
+// Strategies lists the valid strategies.
+var Strategies = []string{Nearest, Interpolate, CarryForward}
+
+// Config chooses how readings are matched with weather.
+type Config struct {
+	Strategy string
+	// MaxGap is how far from a reading an observation may be to be used.
+	MaxGap time.Duration
+}
+
+// DefaultConfig is used when no COMBINE_* variables are set.
+var DefaultConfig = Config{Strategy: Nearest, MaxGap: 3 * time.Hour}
+
+// ConfigFromEnv reads COMBINE_STRATEGY and COMBINE_MAX_GAP, falling back to
+// DefaultConfig on empty or invalid values.
+func ConfigFromEnv() Config {
+	cfg := Config{
+		Strategy: config.GetEnv("COMBINE_STRATEGY", DefaultConfig.Strategy),
+		MaxGap:   config.GetEnvDuration("COMBINE_MAX_GAP", DefaultConfig.MaxGap),
+	}
+	if !slices.Contains(Strategies, cfg.Strategy) {
+		log.Printf("Invalid COMBINE_STRATEGY %q, using %s", cfg.Strategy, DefaultConfig.Strategy)
+		cfg.Strategy = DefaultConfig.Strategy
+	}
+	if cfg.MaxGap <= 0 {
+		log.Printf("Invalid COMBINE_MAX_GAP %v, using %v", cfg.MaxGap, DefaultConfig.MaxGap)
+		cfg.MaxGap = DefaultConfig.MaxGap
+	}
+	return cfg
+}
+
+// weatherPoint is a tmd record with its time.
+type weatherPoint struct {
+	at     time.Time
+	record models.TMD
+}
+
+// match is the weather derived for a reading.
+type match struct {
+	temperature, humidity, rainfall float64
+	// timestamp is the observation used, the nearer one when interpolating.
+	timestamp time.Time
+	// gap is the distance to the farthest observation used.
+	gap time.Duration
+}
+
+func matchOf(p weatherPoint, t time.Time) match {
+	return match{
+		temperature: p.record.Temperature,
+		humidity:    p.record.Humidity,
+		rainfall:    p.record.Rainfall,
+		timestamp:   p.record.Timestamp,
+		gap:         abs(t.Sub(p.at)),
+	}
+}
+
+// find derives the weather at t from weather, sorted by time.
+func (c Config) find(weather []weatherPoint, t time.Time) (match, bool) {
+	// after is the first observation at or after t.
+	after := sort.Search(len(weather), func(i int) bool { return !weather[i].at.Before(t) })
+	exact := after < len(weather) && weather[after].at.Equal(t)
+	var prev, next *weatherPoint
+	if exact {
+		prev = &weather[after]
+	} else if after > 0 && t.Sub(weather[after-1].at) < c.MaxGap {
+		prev = &weather[after-1]
+	}
+	if after < len(weather) && weather[after].at.Sub(t) < c.MaxGap {
+		next = &weather[after]
+	}
+
+	switch c.Strategy {
+	case CarryForward:
+		if prev == nil {
+			return match{}, false
+		}
+		return matchOf(*prev, t), true
+	case Interpolate:
+		if exact {
+			return matchOf(*prev, t), true
+		}
+		if prev == nil || next == nil {
+			return match{}, false
+		}
+		f := float64(t.Sub(prev.at)) / float64(next.at.Sub(prev.at))
+		lerp := func(a, b float64) float64 { return a + (b-a)*f }
+		m := matchOf(*prev, t)
+		if f > 0.5 {
+			m = matchOf(*next, t)
+		}
+		m.temperature = lerp(prev.record.Temperature, next.record.Temperature)
+		m.humidity = lerp(prev.record.Humidity, next.record.Humidity)
+		m.rainfall = lerp(prev.record.Rainfall, next.record.Rainfall)
+		m.gap = max(t.Sub(prev.at), next.at.Sub(t))
+		return m, true
+	default:
+		switch {
+		case prev != nil && (next == nil || t.Sub(prev.at) <= next.at.Sub(t)):
+			return matchOf(*prev, t), true
+		case next != nil:
+			return matchOf(*next, t), true
+		}
+		return match{}, false
+	}
+}
+
+func abs(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
@@ -0,0 +1,100 @@
+package combine
+
+import (
+	"errors"
+	"time"
+
+	"backend/database"
+	"backend/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// WatermarkName names the watermark of the combined_data join.
+const WatermarkName = "combined_data"
+
+// Store reads readings and weather and saves combined rows.
+type Store interface {
+	// Watermark returns the id of the last reading joined, 0 before the first run.
+	Watermark() (uint, error)
+	// Readings returns up to limit readings with an id above afterID, by id.
+	Readings(afterID uint, limit int) ([]models.TimeToDry, error)
+	// Unjoined returns up to limit readings with an id above afterID and up
+	// to throughID, a timestamp from from to to, both included, and no
+	// combined row, by id.
+	Unjoined(afterID, throughID uint, from, to time.Time, limit int) ([]models.TimeToDry, error)
+	// CountReadings counts the readings with an id above afterID.
+	CountReadings(afterID uint) (int64, error)
+	// Weather returns the tmd records with a timestamp from from to to, both included.
+	Weather(from, to time.Time) ([]models.TMD, error)
+	// LatestWeather returns the newest tmd timestamp, or the zero time when there is none.
+	LatestWeather() (time.Time, error)
+	// Save inserts the rows not stored yet and moves the watermark to lastID,
+	// atomically. It returns how many rows were inserted.
+	Save(rows []models.CombinedData, lastID uint) (int, error)
+}
+
+// GormStore is the Store backed by database.DB.
+type GormStore struct{}
+
+func (GormStore) Watermark() (uint, error) {
+	var w models.Watermark
+	err := database.DB.Where("name = ?", WatermarkName).First(&w).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, nil
+	}
+	return w.LastID, err
+}
+
+func (GormStore) Readings(afterID uint, limit int) ([]models.TimeToDry, error) {
+	var readings []models.TimeToDry
+	err := database.DB.Where("id > ?", afterID).Order("id").Limit(limit).Find(&readings).Error
+	return readings, err
+}
+
+func (GormStore) Unjoined(afterID, throughID uint, from, to time.Time, limit int) ([]models.TimeToDry, error) {
+	var readings []models.TimeToDry
+	err := database.DB.
+		Where("id > ? AND id <= ? AND timestamp >= ? AND timestamp <= ?", afterID, throughID, from.UTC(), to.UTC()).
+		Where("NOT EXISTS (SELECT 1 FROM combined_data WHERE combined_data.timestamp = time_to_dry.timestamp AND combined_data.test_id = time_to_dry.test_id)").
+		Order("id").Limit(limit).Find(&readings).Error
+	return readings, err
+}
+
+func (GormStore) CountReadings(afterID uint) (int64, error) {
+	var n int64
+	err := database.DB.Model(&models.TimeToDry{}).Where("id > ?", afterID).Count(&n).Error
+	return n, err
+}
+
+func (GormStore) Weather(from, to time.Time) ([]models.TMD, error) {
+	var records []models.TMD
+	err := database.DB.Where("timestamp >= ? AND timestamp <= ?", from.UTC(), to.UTC()).Order("timestamp").Find(&records).Error
+	return records, err
+}
+
+func (GormStore) LatestWeather() (time.Time, error) {
+	var latest database.Time
+	err := database.DB.Model(&models.TMD{}).Select("MAX(timestamp)").Row().Scan(&latest)
+	return latest.Time, err
+}
+
+func (GormStore) Save(rows []models.CombinedData, lastID uint) (int, error) {
+	var inserted int
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if len(rows) > 0 {
+			result := tx.Clauses(clause.OnConflict{DoNothing: true}).CreateInBatches(rows, 500)
+			if result.Error != nil {
+				return result.Error
+			}
+			inserted = int(result.RowsAffected)
+		}
+		return tx.Clauses(clause.OnConflict{UpdateAll: true}).Create(&models.Watermark{
+			Name:      WatermarkName,
+			LastID:    lastID,
+			UpdatedAt: time.Now(),
+		}).Error
+	})
+	return inserted, err
+}
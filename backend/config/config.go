@@ -2,6 +2,11 @@ package config
 
 import (
 	"log"
+	"os"
+	"strconv"
+	"time"
+	// Zone names for DISPLAY_TIMEZONE on hosts without a zoneinfo database.
+	_ "time/tzdata"
 
 	"github.com/joho/godotenv"
 )
@@ -12,3 +17,70 @@ func LoadEnvVariables() {
 		log.Println("No .env file found or error loading .env")
 	}
 }
+
+// GetEnv returns the value of an environment variable or fallback when it is unset.
+func GetEnv(key, fallback string) string {
+	if value, ok := os.LookupEnv(key); ok && value != "" {
+		return value
+	}
+	return fallback
+}
+
+// GetEnvDuration parses a duration such as "30s" or "5m", falling back on empty or invalid input.
+func GetEnvDuration(key string, fallback time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		log.Printf("Invalid duration for %s: %q, using %v", key, value, fallback)
+		return fallback
+	}
+	return d
+}
+
+// GetEnvBool parses a boolean such as "true" or "1", falling back on empty or invalid input.
+func GetEnvBool(key string, fallback bool) bool {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		log.Printf("Invalid boolean for %s: %q, using %v", key, value, fallback)
+		return fallback
+	}
+	return b
+}
+
+// GetEnvFloat parses a number such as "2.5", falling back on empty or invalid input.
+func GetEnvFloat(key string, fallback float64) float64 {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		log.Printf("Invalid number for %s: %q, using %v", key, value, fallback)
+		return fallback
+	}
+	return f
+}
+
+// LoadDisplayZone sets the local time zone from DISPLAY_TIMEZONE, such as
+// Asia/Bangkok, keeping the server's zone when it is unset or unknown. Times
+// are stored in UTC and shown, and read when they carry no zone, in the
+// local zone.
+func LoadDisplayZone() {
+	name := os.Getenv("DISPLAY_TIMEZONE")
+	if name == "" {
+		return
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		log.Printf("Invalid time zone for DISPLAY_TIMEZONE: %q, using %v", name, time.Local)
+		return
+	}
+	time.Local = loc
+}
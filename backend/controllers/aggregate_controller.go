@@ -0,0 +1,112 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+
+	"backend/aggregate"
+	"backend/database"
+	"backend/listing"
+	"backend/models"
+)
+
+// AggregateResponse is the body of the aggregate endpoints.
+type AggregateResponse struct {
+	Interval string             `json:"interval"`
+	Fields   []string           `json:"fields"`
+	Buckets  []aggregate.Bucket `json:"buckets"`
+}
+
+// GetTimeToDryAggregate godoc
+// @Summary Aggregate time_to_dry records
+// @Description Returns time_to_dry readings downsampled into buckets of interval with the min, max and average of each field. Buckets are aligned to local midnight and empty buckets are left out.
+// @Tags TimeToDry
+// @Produce json
+// @Param interval query string true "Bucket size: 1m, 5m, 1h or 1d"
+// @Param fields query string false "Comma-separated numeric fields (default all)"
+// @Param from query string false "Only rows at or after this time (2006-01-02, 2006-01-02 15:04:05 or RFC 3339)"
+// @Param to query string false "Only rows before this time"
+// @Param test_id query int false "Only rows of this drying session"
+// @Success 200 {object} controllers.AggregateResponse
+// @Failure 400 {object} controllers.ErrorResponse "Invalid query parameters"
+// @Router /api/timetodry/aggregate [get]
+func GetTimeToDryAggregate(w http.ResponseWriter, r *http.Request) {
+	aggregateTable[models.TimeToDry](w, r, true)
+}
+
+// GetTMDAggregate godoc
+// @Summary Aggregate tmd records
+// @Description Returns tmd weather records downsampled into buckets of interval with the min, max and average of each field. Buckets are aligned to local midnight and empty buckets are left out.
+// @Tags TMD
+// @Produce json
+// @Param interval query string true "Bucket size: 1m, 5m, 1h or 1d"
+// @Param fields query string false "Comma-separated numeric fields (default all)"
+// @Param from query string false "Only rows at or after this time (2006-01-02, 2006-01-02 15:04:05 or RFC 3339)"
+// @Param to query string false "Only rows before this time"
+// @Success 200 {object} controllers.AggregateResponse
+// @Failure 400 {object} controllers.ErrorResponse "Invalid query parameters"
+// @Router /api/tmd/aggregate [get]
+func GetTMDAggregate(w http.ResponseWriter, r *http.Request) {
+	aggregateTable[models.TMD](w, r, false)
+}
+
+// GetCombinedAggregate godoc
+// @Summary Aggregate combined records
+// @Description Returns combined_data records downsampled into buckets of interval with the min, max and average of each field. Buckets are aligned to local midnight and empty buckets are left out.
+// @Tags CombinedData
+// @Produce json
+// @Param interval query string true "Bucket size: 1m, 5m, 1h or 1d"
+// @Param fields query string false "Comma-separated numeric fields (default all)"
+// @Param from query string false "Only rows at or after this time (2006-01-02, 2006-01-02 15:04:05 or RFC 3339)"
+// @Param to query string false "Only rows before this time"
+// @Param test_id query int false "Only rows of this drying session"
+// @Success 200 {object} controllers.AggregateResponse
+// @Failure 400 {object} controllers.ErrorResponse "Invalid query parameters"
+// @Router /api/combined/aggregate [get]
+func GetCombinedAggregate(w http.ResponseWriter, r *http.Request) {
+	aggregateTable[models.CombinedData](w, r, true)
+}
+
+// aggregateTable answers an aggregate request for the table of T.
+func aggregateTable[T any](w http.ResponseWriter, r *http.Request, hasTestID bool) {
+	var model T
+	opts := listing.Options{Fields: aggregate.NumericFields(model), TestID: hasTestID}
+	p, errs := listing.ParseFilter(r.URL.Query(), opts)
+	if errs == nil {
+		errs = listing.Errors{}
+	}
+
+	name := r.URL.Query().Get("interval")
+	interval, ok := aggregate.Intervals[name]
+	switch {
+	case name == "":
+		errs["interval"] = "is required, one of " + strings.Join(aggregate.IntervalNames, ", ")
+	case !ok:
+		errs["interval"] = "must be one of " + strings.Join(aggregate.IntervalNames, ", ")
+	case !p.From.IsZero() && !p.To.IsZero():
+		if p.To.Sub(p.From)/interval > aggregate.MaxBuckets {
+			errs["interval"] = aggregate.ErrTooManyBuckets.Error()
+		}
+	}
+	if len(errs) > 0 {
+		writeValidationError(w, errs)
+		return
+	}
+
+	fields := p.Fields
+	if fields == nil {
+		fields = slices.Clone(opts.Fields)
+	}
+	buckets, err := aggregate.Query(p.Filter(database.DB.Model(&model)), interval, fields)
+	if errors.Is(err, aggregate.ErrTooManyBuckets) {
+		writeValidationError(w, listing.Errors{"interval": err.Error()})
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, AggregateResponse{Interval: name, Fields: fields, Buckets: buckets})
+}
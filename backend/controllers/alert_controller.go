@@ -0,0 +1,64 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"backend/alerts"
+
+	"github.com/gorilla/mux"
+)
+
+// ListAlerts godoc
+// @Summary List sent alerts
+// @Description Returns the most recent rain, all-clear and drying-complete alerts, newest first.
+// @Tags Forecast
+// @Produce json
+// @Param limit query int false "Maximum number of alerts (default 20, max 200)"
+// @Success 200 {array} models.Alert
+// @Failure 400 {object} controllers.ErrorResponse "Invalid limit"
+// @Router /api/alerts [get]
+func ListAlerts(w http.ResponseWriter, r *http.Request) {
+	limit, err := intParam(r, "limit", 20, 1, 200)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	list, err := alerts.Recent(limit)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, list)
+}
+
+// AcknowledgeAlert godoc
+// @Summary Acknowledge an alert
+// @Description Marks an alert as seen. Acknowledging it again keeps the first time. Clients following alerts on the stream receive the updated alert.
+// @Tags Forecast
+// @Produce json
+// @Param id path int true "Alert ID"
+// @Success 200 {object} models.Alert
+// @Failure 404 {object} controllers.ErrorResponse "Alert not found"
+// @Router /api/alerts/{id}/ack [post]
+func AcknowledgeAlert(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 0)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid alert id")
+		return
+	}
+
+	a, err := alerts.Default.Acknowledge(uint(id), time.Now())
+	if errors.Is(err, alerts.ErrNotFound) {
+		writeError(w, http.StatusNotFound, "Alert not found")
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, a)
+}
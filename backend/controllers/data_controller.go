@@ -2,66 +2,124 @@ package controllers
 
 import (
 	"encoding/json"
-	"fmt"
+	"errors"
 	"log"
 	"math"
 	"net/http"
-	"os"
-	"sort"
 	"strconv"
-	"strings"
 	"time"
 
-	"backend/database"
+	"backend/combine"
+	"backend/estimator"
+	"backend/listing"
 	"backend/models"
-	"backend/utils"
+	"backend/repository"
+	"backend/sessions"
+	"backend/weather"
 
-	"github.com/joho/godotenv"
 	"gorm.io/gorm"
 )
 
+// DataHandler serves the sensor, weather, combined data and drying session
+// endpoints from its repositories, which tests replace with in-memory ones.
+type DataHandler struct {
+	Readings repository.Readings
+	Weather  repository.Weather
+	Combined repository.Combined
+	Sessions repository.Sessions
+	Models   repository.Models
+	// Combiner populates combined_data.
+	Combiner repository.Combiner
+	// Forecast is the weather provider of the rain forecast at Location,
+	// nil when none is configured.
+	Forecast   weather.Provider
+	Location   weather.Location
+	Thresholds weather.Thresholds
+}
+
+// NewDataHandler returns a DataHandler reading db, with the default
+// session manager and joiner and the weather provider of the environment.
+func NewDataHandler(db *gorm.DB) *DataHandler {
+	h := &DataHandler{
+		Readings:   repository.GormReadings{DB: db},
+		Weather:    repository.GormWeather{DB: db},
+		Combined:   repository.GormCombined{DB: db},
+		Sessions:   sessions.Default,
+		Models:     estimator.GormModels{DB: db},
+		Combiner:   combine.Default,
+		Thresholds: weather.ThresholdsFromEnv(),
+	}
+	provider, err := weather.FromEnv()
+	if err == nil {
+		h.Location, err = weather.LocationFromEnv()
+	}
+	if err != nil {
+		log.Println("Rain forecast disabled:", err)
+	} else {
+		h.Forecast = provider
+	}
+	return h
+}
+
 // GetTimeToDry godoc
-// @Summary Get all time_to_dry records
-// @Description Returns all sensor records from the time_to_dry table.
+// @Summary List time_to_dry records
+// @Description Returns a page of sensor records from the time_to_dry table, ordered by timestamp. Pass pagination.next_cursor as cursor to get the next page.
 // @Tags TimeToDry
 // @Produce json
-// @Success 200 {array} models.TimeToDry
+// @Param from query string false "Only rows at or after this time (2006-01-02, 2006-01-02 15:04:05 or RFC 3339)"
+// @Param to query string false "Only rows before this time"
+// @Param test_id query int false "Only rows of this drying session"
+// @Param limit query int false "Rows per page (default 100, max 1000)"
+// @Param cursor query string false "next_cursor of the previous page"
+// @Param order query string false "Timestamp order, asc (default) or desc"
+// @Param fields query string false "Comma-separated fields to return (default all)"
+// @Success 200 {object} listing.Response{data=[]models.TimeToDry}
+// @Failure 400 {object} controllers.ErrorResponse "Invalid query parameters"
 // @Router /api/timetodry [get]
-func GetTimeToDry(w http.ResponseWriter, r *http.Request) {
-	var data []models.TimeToDry
-	database.DB.Find(&data)
-	json.NewEncoder(w).Encode(data)
+func (h *DataHandler) GetTimeToDry(w http.ResponseWriter, r *http.Request) {
+	listTable(w, r, h.Readings, timeToDryList, func(row models.TimeToDry) listing.Cursor {
+		return listing.Cursor{Timestamp: row.Timestamp, ID: row.ID}
+	})
 }
 
+var timeToDryList = listing.Options{Fields: listing.FieldsOf(models.TimeToDry{}), TestID: true}
+
 // GetTMD godoc
-// @Summary Get all tmd records
-// @Description Returns all Weather API from tmd table.
+// @Summary List tmd records
+// @Description Returns a page of weather records from the tmd table, ordered by timestamp. Pass pagination.next_cursor as cursor to get the next page.
 // @Tags TMD
 // @Produce json
-// @Success 200 {array} models.TMD
+// @Param from query string false "Only rows at or after this time (2006-01-02, 2006-01-02 15:04:05 or RFC 3339)"
+// @Param to query string false "Only rows before this time"
+// @Param limit query int false "Rows per page (default 100, max 1000)"
+// @Param cursor query string false "next_cursor of the previous page"
+// @Param order query string false "Timestamp order, asc (default) or desc"
+// @Param fields query string false "Comma-separated fields to return (default all)"
+// @Success 200 {object} listing.Response{data=[]models.TMD}
+// @Failure 400 {object} controllers.ErrorResponse "Invalid query parameters"
 // @Router /api/tmd [get]
-func GetTMD(w http.ResponseWriter, r *http.Request) {
-	var data []models.TMD
-	database.DB.Find(&data)
-	json.NewEncoder(w).Encode(data)
+func (h *DataHandler) GetTMD(w http.ResponseWriter, r *http.Request) {
+	listTable(w, r, h.Weather, tmdList, func(row models.TMD) listing.Cursor {
+		return listing.Cursor{Timestamp: row.Timestamp, ID: row.ID}
+	})
 }
 
+var tmdList = listing.Options{Fields: listing.FieldsOf(models.TMD{})}
+
 // TMDToday godoc
 // @Summary Get today's TMD records
-// @Description Returns all weather data from the TMD table for today.
+// @Description Returns all weather data from the TMD table for today, in the display time zone.
 // @Tags TMD
 // @Produce json
 // @Success 200 {array} models.TMD
 // @Router /api/tmd/today [get]
-func TMDToday(w http.ResponseWriter, r*http.Request) {
+func (h *DataHandler) TMDToday(w http.ResponseWriter, r*http.Request) {
 	now := time.Now()
 	startOfDay := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
 	endOfDay := startOfDay.Add(24 * time.Hour)
 
-	var data []models.TMD
-	result := database.DB.Where("timestamp BETWEEN ? AND ?", startOfDay.Format("2006-01-02 15:04:05"), endOfDay.Format("2006-01-02 15:04:05")).Find(&data)
-
-	if result.Error != nil {
+	data, err := h.Weather.Between(startOfDay, endOfDay)
+	if err != nil {
 		http.Error(w, "Failed to fetch TMD data", http.StatusInternalServerError)
 		return
 	}
@@ -76,18 +134,12 @@ func TMDToday(w http.ResponseWriter, r*http.Request) {
 // @Produce json
 // @Success 200 {array} models.TMD
 // @Router /api/tmd/recent [get]
-func TMDLast24Hours(w http.ResponseWriter, r *http.Request) {
+func (h *DataHandler) TMDLast24Hours(w http.ResponseWriter, r *http.Request) {
 	now := time.Now()
 	past24 := now.Add(-24 * time.Hour)
 
-	var data []models.TMD
-	result := database.DB.
-		Where("timestamp >= ?", past24.Format("2006-01-02 15:04:05")).
-		Order("timestamp desc").
-		Limit(8).
-		Find(&data)
-
-	if result.Error != nil {
+	data, err := h.Weather.Recent(past24, 8)
+	if err != nil {
 		http.Error(w, "Failed to fetch recent TMD data", http.StatusInternalServerError)
 		return
 	}
@@ -97,33 +149,42 @@ func TMDLast24Hours(w http.ResponseWriter, r *http.Request) {
 
 
 // GetCombinedData godoc
-// @Summary Get all combined records
-// @Description Returns all Weather API from tmd table.
+// @Summary List combined records
+// @Description Returns a page of sensor records joined with the closest weather record from the combined_data table, ordered by timestamp. Pass pagination.next_cursor as cursor to get the next page.
 // @Tags CombinedData
 // @Produce json
-// @Success 200 {array} models.CombinedData
+// @Param from query string false "Only rows at or after this time (2006-01-02, 2006-01-02 15:04:05 or RFC 3339)"
+// @Param to query string false "Only rows before this time"
+// @Param test_id query int false "Only rows of this drying session"
+// @Param limit query int false "Rows per page (default 100, max 1000)"
+// @Param cursor query string false "next_cursor of the previous page"
+// @Param order query string false "Timestamp order, asc (default) or desc"
+// @Param fields query string false "Comma-separated fields to return (default all)"
+// @Success 200 {object} listing.Response{data=[]models.CombinedData}
+// @Failure 400 {object} controllers.ErrorResponse "Invalid query parameters"
 // @Router /api/combined [get]
-func GetCombinedData(w http.ResponseWriter, r *http.Request) {
-	var data []models.CombinedData
-	database.DB.Find(&data)
-	json.NewEncoder(w).Encode(data)
+func (h *DataHandler) GetCombinedData(w http.ResponseWriter, r *http.Request) {
+	listTable(w, r, h.Combined, combinedList, func(row models.CombinedData) listing.Cursor {
+		return listing.Cursor{Timestamp: row.Timestamp, ID: row.ID}
+	})
 }
 
+var combinedList = listing.Options{Fields: listing.FieldsOf(models.CombinedData{}), TestID: true}
+
 // GetLatestTestID godoc
 // @Summary Get the latest test_id
-// @Description Returns the highest test_id from time_to_dry table. ex.GET http://localhost:8080/api/ttd/status/check?test_id=5
+// @Description Returns the test_id of the most recent drying session. ex.GET http://localhost:8080/api/ttd/status/check?test_id=5
 // @Tags Test
 // @Produce json
 // @Success 200 {object} map[string]int
 // @Router /api/ttd/latest [get]
-func GetLatestTestID(w http.ResponseWriter, r *http.Request) {
-	var latest models.TimeToDry
-	result := database.DB.Order("test_id desc").First(&latest)
-	if result.Error != nil {
-		if result.Error == gorm.ErrRecordNotFound {
+func (h *DataHandler) GetLatestTestID(w http.ResponseWriter, r *http.Request) {
+	latest, err := h.Sessions.Latest()
+	if err != nil {
+		if errors.Is(err, sessions.ErrNotFound) {
 			http.Error(w, "No records found", http.StatusNotFound)
 		} else {
-			http.Error(w, result.Error.Error(), http.StatusInternalServerError)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
 		}
 		return
 	}
@@ -132,73 +193,84 @@ func GetLatestTestID(w http.ResponseWriter, r *http.Request) {
 
 // GetAllRowsOfLatestTestID godoc
 // @Summary Get all rows of latest test_id
-// @Description Returns all time_to_dry rows that share the latest test_id.
+// @Description Returns all time_to_dry rows recorded during the most recent drying session.
 // @Tags Test
 // @Produce json
 // @Success 200 {array} models.TimeToDry
 // @Router /api/ttd/latest/all [get]
-func GetAllRowsOfLatestTestID(w http.ResponseWriter, r *http.Request) {
-	var latest models.TimeToDry
-	if err := database.DB.Order("test_id desc").First(&latest).Error; err != nil {
+func (h *DataHandler) GetAllRowsOfLatestTestID(w http.ResponseWriter, r *http.Request) {
+	latest, err := h.Sessions.Latest()
+	if err != nil {
 		http.Error(w, "No records found", http.StatusNotFound)
 		return
 	}
 
-	var rows []models.TimeToDry
-	database.DB.Where("test_id = ?", latest.TestID).Find(&rows)
+	rows, err := h.Readings.ForTest(latest.TestID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
 	json.NewEncoder(w).Encode(rows)
 }
 
 // GetLastRowOfLatestTestID godoc
 // @Summary Get the most recent row of latest test_id
-// @Description Returns the latest time_to_dry row (by timestamp) for the latest test_id.
+// @Description Returns the latest time_to_dry row (by timestamp) of the most recent drying session.
 // @Tags Test
 // @Produce json
 // @Success 200 {object} models.TimeToDry
 // @Router /api/ttd/latest/last [get]
-func GetLastRowOfLatestTestID(w http.ResponseWriter, r *http.Request) {
-	var latest models.TimeToDry
-	if err := database.DB.Order("test_id desc").First(&latest).Error; err != nil {
+func (h *DataHandler) GetLastRowOfLatestTestID(w http.ResponseWriter, r *http.Request) {
+	latest, err := h.Sessions.Latest()
+	if err != nil {
 		http.Error(w, "No records found", http.StatusNotFound)
 		return
 	}
 
-	var last models.TimeToDry
-	database.DB.Where("test_id = ?", latest.TestID).Order("timestamp desc").First(&last)
+	last, err := h.Readings.Last(latest.TestID)
+	if err != nil {
+		http.Error(w, "No readings for latest session", http.StatusNotFound)
+		return
+	}
 	json.NewEncoder(w).Encode(last)
 }
 
 // CheckDeviceStatus godoc
 // @Summary Check device status
-// @Description Returns whether the device is active (sending data within last 5 minutes).
+// @Description Returns whether the device is active (sending data within last 5 minutes) and the active drying session, if any.
 // @Tags Device
 // @Produce json
 // @Success 200 {object} map[string]interface{}
 // @Router /api/ttd/status [get]
-func CheckDeviceStatus(w http.ResponseWriter, r *http.Request) {
-	var latest models.TimeToDry
-	if err := database.DB.Order("timestamp desc").First(&latest).Error; err != nil {
+func (h *DataHandler) CheckDeviceStatus(w http.ResponseWriter, r *http.Request) {
+	latest, err := h.Readings.Last(0)
+	if err != nil {
 		http.Error(w, "No recent record found", http.StatusNotFound)
 		return
 	}
-	timestamp, err := utils.ParseTimestamp(latest.Timestamp)
+	active, err := h.Sessions.Active()
 	if err != nil {
-		http.Error(w, "Invalid timestamp format", http.StatusInternalServerError)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	latestTestID := latest.TestID
+	if session, err := h.Sessions.Latest(); err == nil {
+		latestTestID = session.TestID
+	}
 
 	// Check if the latest timestamp is within last 5 minutes
-	isWorking := time.Since(timestamp) <= 5*time.Minute
+	isWorking := time.Since(latest.Timestamp) <= 5*time.Minute
 	json.NewEncoder(w).Encode(map[string]any{
-		"is_working": isWorking,
-		"latest_test_id": latest.TestID,
+		"is_working":     isWorking,
+		"latest_test_id": latestTestID,
 		"last_timestamp": latest.Timestamp,
+		"active_session": active,
 	})
 }
 
 // CheckTestStatus godoc
 // @Summary Check specific test status
-// @Description Returns whether a given test_id is still collecting data or completed.
+// @Description Returns whether the drying session for a given test_id is still in progress or completed, and why it ended.
 // @Tags Test
 // @Produce json
 // @Param test_id query int true "Test ID to check"
@@ -206,196 +278,120 @@ func CheckDeviceStatus(w http.ResponseWriter, r *http.Request) {
 // @Failure 400 {string} string "Missing or invalid test_id"
 // @Failure 404 {string} string "No records found"
 // @Router /api/ttd/status/check [get]
-func CheckTestStatus(w http.ResponseWriter, r *http.Request) {
+func (h *DataHandler) CheckTestStatus(w http.ResponseWriter, r *http.Request) {
 	query := r.URL.Query().Get("test_id")
 	if query == "" {
 		http.Error(w, "Missing test_id parameter", http.StatusBadRequest)
 		return
 	}
-
-	var latest models.TimeToDry
-	result := database.DB.Where("test_id = ?", query).Order("timestamp desc").First(&latest)
-	if result.Error != nil {
-		if result.Error == gorm.ErrRecordNotFound {
-			http.Error(w, "No records found for given test_id", http.StatusNotFound)
-		} else {
-			http.Error(w, result.Error.Error(), http.StatusInternalServerError)
-		}
+	testID, err := strconv.Atoi(query)
+	if err != nil {
+		http.Error(w, "Invalid test_id parameter", http.StatusBadRequest)
 		return
 	}
 
-	timestamp, err := utils.ParseTimestamp(latest.Timestamp)
+	session, err := h.Sessions.Get(testID)
 	if err != nil {
-		http.Error(w, "Invalid timestamp format", http.StatusInternalServerError)
+		if errors.Is(err, sessions.ErrNotFound) {
+			http.Error(w, "No records found for given test_id", http.StatusNotFound)
+		} else {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
 		return
 	}
 
-	// Compare timestamp to now
 	status := "completed"
-	if time.Since(timestamp) <= 5*time.Minute {
+	if session.Status == models.SessionActive {
 		status = "in_progress"
 	}
+	lastTimestamp := session.LastReadingAt
+	if lastTimestamp.IsZero() {
+		lastTimestamp = session.StartedAt
+	}
 
 	json.NewEncoder(w).Encode(map[string]any{
-		"test_id":        latest.TestID,
+		"test_id":        session.TestID,
 		"status":         status,
-		"last_timestamp": latest.Timestamp,
+		"started_at":     session.StartedAt,
+		"ended_at":       session.EndedAt,
+		"end_reason":     session.EndReason,
+		"last_timestamp": lastTimestamp,
 	})
 }
 
-
 // PopulateCombinedData godoc
 // @Summary Populate combined_data from time_to_dry and tmd
-// @Description Matches closest timestamp from tmd for each time_to_dry record and inserts combined row if not duplicate.
+// @Description Joins the time_to_dry records stored since the last run with tmd weather and inserts them into combined_data, recording the weather timestamp used and its distance. COMBINE_STRATEGY picks the closest observation (nearest, default), interpolates between the observations before and after (interpolate) or takes the last one before (carry_forward); observations further than COMBINE_MAX_GAP (default 3h) are not used. Records already combined are not inserted twice. Records newer than the latest weather are left for a later run until they are COMBINE_MAX_GAP old.
 // @Tags CombinedData
-// @Accept json
-// @Produce plain
-// @Success 200 {string} string "Combined data populated"
+// @Produce json
+// @Success 200 {object} combine.Result
+// @Failure 500 {object} controllers.ErrorResponse
 // @Router /api/combined/populate [post]
-func PopulateCombinedData(w http.ResponseWriter, r *http.Request) {
-	var timeData []models.TimeToDry
-	var tmdData []models.TMD
-	database.DB.Find(&timeData)
-	database.DB.Find(&tmdData)
-
-	log.Printf("Found %d time_to_dry records\n", len(timeData))
-	log.Printf("Found %d tmd records\n", len(tmdData))
-
-	// Sort TMD by time for faster closest lookup
-	sort.Slice(tmdData, func(i, j int) bool {
-		t1, _ := utils.ParseTimestamp(tmdData[i].Timestamp)
-		t2, _ := utils.ParseTimestamp(tmdData[j].Timestamp)
-		return t1.Before(t2)
-	})
-
-	for _, td := range timeData {
-		tdTime, err := utils.ParseTimestamp(td.Timestamp)
-		if err != nil {
-			log.Println("Failed to parse TimeToDry timestamp:", td.Timestamp)
-			continue
-		}
-
-		var closest *models.TMD
-		var minDiff time.Duration = time.Hour * 24
-
-		for _, tmd := range tmdData {
-			tmdTime, err := utils.ParseTimestamp(tmd.Timestamp)
-			if err != nil {
-				log.Println("Failed to parse TMD timestamp:", tmd.Timestamp)
-				continue
-			}
-			diff := tdTime.Sub(tmdTime)
-			if diff < 0 {
-				diff = -diff
-			}
-			if diff < minDiff {
-				minDiff = diff
-				closest = &tmd
-			}
-		}
-
-		// Add log to show matches or skips
-		if closest != nil && minDiff < 3*time.Hour {
-			log.Printf("Matched %s with %s (diff: %v)\n", td.Timestamp, closest.Timestamp, minDiff)
-			
-			var existing models.CombinedData
-			formattedTimestamp := tdTime.Format("2006-01-02 15:04:05")
-			result := database.DB.Where("timestamp = ? AND test_id = ?", formattedTimestamp, td.TestID).First(&existing)
-
-			if result.RowsAffected == 0 {
-				database.DB.Create(&models.CombinedData{
-					Timestamp:   formattedTimestamp,
-					Lat:         td.Lat,
-					Lon:         td.Lon,
-					TempIn:      td.TempIn,
-					TempOut:     td.TempOut,
-					HumIn:       td.HumIn,
-					HumOut:      td.HumOut,
-					DiffTemp:    td.DiffTemp,
-					DiffHum:     td.DiffHum,
-					TestID:      td.TestID,
-					APITemp:     closest.Temperature,
-					APIHumidity: closest.Humidity,
-					Rainfall:    closest.Rainfall,
-					CreatedAt:   time.Now().Format("2006-01-02 15:04:05"),
-				})
-				log.Printf("Inserted new combined entry for %s (test_id: %d)", td.Timestamp, td.TestID)
-			} else {
-				log.Printf("Skipped duplicate entry for %s (test_id: %d)", td.Timestamp, td.TestID)
-			}
-
-		} else {
-			log.Printf("No match found for %s (closest diff: %v)\n", td.Timestamp, minDiff)
-		}
+func (h *DataHandler) PopulateCombinedData(w http.ResponseWriter, r *http.Request) {
+	res, err := h.Combiner.Run()
+	if err != nil {
+		log.Println("Failed to populate combined data:", err)
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
 	}
-
-	w.Write([]byte("Combined data populated"))
+	log.Printf("Combined %d time_to_dry records: %d inserted, %d unmatched, %d pending\n", res.Processed, res.Inserted, res.Unmatched, res.Pending)
+	writeJSON(w, http.StatusOK, res)
 }
 
 // RainForecast godoc
 // @Summary Estimate if it's currently raining or likely to rain
-// @Description Uses current weather data to estimate rainfall based on weather description.
+// @Description Alerts are sent by the background rain watch, not by this endpoint. Combines current weather and the hourly forecast of the configured provider (WEATHER_PROVIDER) into the chance and amount of rain for each of the next hours, when rain is expected to start and until when laundry is safe outside. will_rain_now_or_soon is true if it is raining now or rain starts within soon_hours. partial is true when the hourly forecast was unavailable, so only the current weather was checked.
 // @Tags Forecast
 // @Produce json
+// @Param hours query int false "Forecast window in hours (default 12, max 48)"
+// @Param soon_hours query int false "How many hours ahead count as soon (default 3)"
 // @Success 200 {object} map[string]interface{}
+// @Failure 400 {string} string "Invalid hours or soon_hours"
+// @Failure 503 {string} string "No weather provider configured"
 // @Router /api/forecast/rain [get]
-func RainForecast(w http.ResponseWriter, r *http.Request) {
-	err := godotenv.Load(".env")
+func (h *DataHandler) RainForecast(w http.ResponseWriter, r *http.Request) {
+	hours, err := intParam(r, "hours", 12, 1, 48)
 	if err != nil {
-		http.Error(w, "Failed to load .env", http.StatusInternalServerError)
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
-
-	apiKey := os.Getenv("OWM_API_KEY")
-	lat := os.Getenv("LAT")
-	lon := os.Getenv("LON")
-
-	url := fmt.Sprintf("https://api.openweathermap.org/data/2.5/weather?lat=%s&lon=%s&units=metric&appid=%s", lat, lon, apiKey)
-
-	resp, err := http.Get(url)
-	if err != nil || resp.StatusCode != 200 {
-		http.Error(w, "Failed to fetch weather data", http.StatusInternalServerError)
+	soonHours, err := intParam(r, "soon_hours", 3, 0, hours)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
-	defer resp.Body.Close()
-
-	var data map[string]interface{}
-	json.NewDecoder(resp.Body).Decode(&data)
-
-	// Check weather[].main or description
-	willRain := false
-	if weatherArray, ok := data["weather"].([]interface{}); ok && len(weatherArray) > 0 {
-		condition := weatherArray[0].(map[string]interface{})
-		main := strings.ToLower(condition["main"].(string))
-		desc := strings.ToLower(condition["description"].(string))
-
-		// Basic keyword check
-		if strings.Contains(main, "rain") || strings.Contains(desc, "rain") ||
-			strings.Contains(desc, "shower") || strings.Contains(desc, "thunder") {
-			willRain = true
-		}
+
+	if h.Forecast == nil {
+		http.Error(w, "No weather provider configured", http.StatusServiceUnavailable)
+		return
 	}
 
-	// Line notification
-	if willRain {
-		err := utils.PushLineMessage("☔ It might rain soon. Take your clothes inside or Don't dry them now!", os.Getenv("LINE_USER_ID"))
-		log.Println("Try to send line")
-		if err != nil {
-			log.Println("Failed to send LINE alert:", err)
-		}
+	now := time.Now()
+	outlook, err := weather.FetchRainOutlook(r.Context(), h.Forecast, h.Location, hours, h.Thresholds, now)
+	if err != nil {
+		log.Println("Failed to fetch weather data:", err)
+		http.Error(w, "Failed to fetch weather data", http.StatusInternalServerError)
+		return
 	}
-	
+	willRain := outlook.RainSoon(now, time.Duration(soonHours)*time.Hour)
 
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"will_rain_now_or_soon": willRain,
-		"source":                data["weather"],
+		"provider":              h.Forecast.Name(),
+		"source":                outlook.Current,
+		"raining_now":           outlook.RainingNow,
+		"rain_expected":         outlook.RainExpected,
+		"rain_starts_at":        outlook.RainStartsAt,
+		"safe_to_hang_until":    outlook.SafeUntil,
+		"partial":               outlook.Partial,
+		"hours":                 hours,
+		"hourly":                outlook.Hours,
 	})
 }
 
-
 // EstimateDryTime godoc
 // @Summary Estimate drying time
-// @Description Estimate drying time in minutes using sensor variables
+// @Description Estimate drying time in minutes using sensor variables and the active model version (0 is the builtin formula).
 // @Tags Drying
 // @Produce json
 // @Param temp_in query float64 true "Internal temperature"
@@ -405,7 +401,7 @@ func RainForecast(w http.ResponseWriter, r *http.Request) {
 // @Param light query float64 true "Light intensity"
 // @Success 200 {object} map[string]interface{}
 // @Router /api/drytime/estimate [get]
-func EstimateDryTime(w http.ResponseWriter, r *http.Request) {
+func (h *DataHandler) EstimateDryTime(w http.ResponseWriter, r *http.Request) {
 	q := r.URL.Query()
 
 	tempIn, _ := strconv.ParseFloat(q.Get("temp_in"), 64)
@@ -417,18 +413,16 @@ func EstimateDryTime(w http.ResponseWriter, r *http.Request) {
 	diffTemp := tempIn - tempOut
 	diffHum := humIn - humOut
 
-	// 🔧 Empirical coefficients
-	base := 180.0 // base dry time in minutes
-	a, b, c := 5.0, 1.5, 0.0015
-
-	// Estimate formula
-	estimatedTime := (base - (a * diffTemp) - (b * diffHum) - (c * light)) * 3.5
-	if estimatedTime < 10 {
-		estimatedTime = 10 // Minimum dry time
+	model, err := h.Models.Active()
+	if err != nil {
+		http.Error(w, "Failed to load drying time model", http.StatusInternalServerError)
+		return
 	}
+	estimatedTime := estimator.CoefficientsOf(model).Estimate(diffTemp, diffHum, light)
 
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"estimated_drying_time_minutes": math.Round(estimatedTime),
+		"model_version":                 model.Version,
 		"inputs": map[string]float64{
 			"temp_in":  tempIn,
 			"temp_out": tempOut,
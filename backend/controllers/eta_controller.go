@@ -0,0 +1,47 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"backend/estimator"
+	"backend/sessions"
+
+	"github.com/gorilla/mux"
+)
+
+// GetSessionETA godoc
+// @Summary Predict when a drying session completes
+// @Description Uses every reading of the session so far to predict the remaining drying time, the completion time, how dry the laundry is and a 95% confidence interval that narrows as readings arrive. Completed sessions report their actual end.
+// @Tags Session
+// @Produce json
+// @Param test_id path int true "Session test_id"
+// @Success 200 {object} estimator.SessionETA
+// @Failure 404 {object} controllers.ErrorResponse "Session not found"
+// @Failure 409 {object} controllers.ErrorResponse "Session has no readings yet"
+// @Router /api/sessions/{test_id}/eta [get]
+func GetSessionETA(w http.ResponseWriter, r *http.Request) {
+	testID, err := strconv.Atoi(mux.Vars(r)["test_id"])
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid test_id")
+		return
+	}
+	s, err := sessions.Default.Get(testID)
+	if err != nil {
+		writeSessionError(w, err)
+		return
+	}
+
+	eta, err := estimator.LoadSessionETA(s, time.Now())
+	if errors.Is(err, estimator.ErrNoReadings) {
+		writeError(w, http.StatusConflict, err.Error())
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, eta)
+}
@@ -0,0 +1,123 @@
+package controllers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"backend/database"
+	"backend/export"
+	"backend/listing"
+	"backend/models"
+)
+
+// ExportTimeToDry godoc
+// @Summary Export time_to_dry records
+// @Description Streams time_to_dry records ordered by timestamp as a CSV, NDJSON or Parquet download.
+// @Tags TimeToDry
+// @Produce text/csv,application/x-ndjson,application/vnd.apache.parquet
+// @Param format query string false "csv (default), ndjson or parquet"
+// @Param from query string false "Only rows at or after this time (2006-01-02, 2006-01-02 15:04:05 or RFC 3339)"
+// @Param to query string false "Only rows before this time"
+// @Param test_id query int false "Only rows of this drying session"
+// @Success 200 {file} file
+// @Failure 400 {object} controllers.ErrorResponse "Invalid query parameters"
+// @Router /api/timetodry/export [get]
+func ExportTimeToDry(w http.ResponseWriter, r *http.Request) {
+	exportTable[models.TimeToDry](w, r, true)
+}
+
+// ExportTMD godoc
+// @Summary Export tmd records
+// @Description Streams tmd weather records ordered by timestamp as a CSV, NDJSON or Parquet download.
+// @Tags TMD
+// @Produce text/csv,application/x-ndjson,application/vnd.apache.parquet
+// @Param format query string false "csv (default), ndjson or parquet"
+// @Param from query string false "Only rows at or after this time (2006-01-02, 2006-01-02 15:04:05 or RFC 3339)"
+// @Param to query string false "Only rows before this time"
+// @Success 200 {file} file
+// @Failure 400 {object} controllers.ErrorResponse "Invalid query parameters"
+// @Router /api/tmd/export [get]
+func ExportTMD(w http.ResponseWriter, r *http.Request) {
+	exportTable[models.TMD](w, r, false)
+}
+
+// ExportCombinedData godoc
+// @Summary Export combined records
+// @Description Streams combined_data records ordered by timestamp as a CSV, NDJSON or Parquet download.
+// @Tags CombinedData
+// @Produce text/csv,application/x-ndjson,application/vnd.apache.parquet
+// @Param format query string false "csv (default), ndjson or parquet"
+// @Param from query string false "Only rows at or after this time (2006-01-02, 2006-01-02 15:04:05 or RFC 3339)"
+// @Param to query string false "Only rows before this time"
+// @Param test_id query int false "Only rows of this drying session"
+// @Success 200 {file} file
+// @Failure 400 {object} controllers.ErrorResponse "Invalid query parameters"
+// @Router /api/combined/export [get]
+func ExportCombinedData(w http.ResponseWriter, r *http.Request) {
+	exportTable[models.CombinedData](w, r, true)
+}
+
+// exportTable streams the table of T in the requested format, one row at a
+// time from the database to the client.
+func exportTable[T interface{ TableName() string }](w http.ResponseWriter, r *http.Request, hasTestID bool) {
+	p, errs := listing.ParseFilter(r.URL.Query(), listing.Options{TestID: hasTestID})
+	if errs == nil {
+		errs = listing.Errors{}
+	}
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = export.CSV
+	}
+	if !slices.Contains(export.Formats, format) {
+		errs["format"] = "must be one of " + strings.Join(export.Formats, ", ")
+	}
+	if len(errs) > 0 {
+		writeValidationError(w, errs)
+		return
+	}
+
+	var model T
+	rows, err := p.Filter(database.DB.Model(&model)).Order("timestamp").Order("id").Rows()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	defer rows.Close()
+
+	// The server's write timeout would cut a large export short.
+	http.NewResponseController(w).SetWriteDeadline(time.Time{})
+
+	filename := model.TableName()
+	if p.TestID != 0 {
+		filename += fmt.Sprintf("-test-%d", p.TestID)
+	}
+	w.Header().Set("Content-Type", export.ContentType(format))
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename+"."+format))
+
+	out, _ := export.NewWriter[T](w, format)
+	for rows.Next() {
+		var row T
+		if err = database.DB.ScanRows(rows, &row); err != nil {
+			break
+		}
+		if err = out.Write(row); err != nil {
+			break
+		}
+	}
+	if err == nil {
+		err = rows.Err()
+	}
+	if err == nil {
+		err = out.Close()
+	}
+	if err != nil {
+		// The status has been sent; abort so the client sees a failed
+		// download rather than a truncated file.
+		log.Printf("Export of %s failed: %v", model.TableName(), err)
+		panic(http.ErrAbortHandler)
+	}
+}
@@ -0,0 +1,89 @@
+package controllers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"backend/importer"
+	"backend/listing"
+
+	"github.com/gorilla/mux"
+)
+
+// maxImportSize bounds the size of an uploaded file.
+const maxImportSize = 256 << 20
+
+// ImportTable godoc
+// @Summary Import historical records
+// @Description Imports a CSV or NDJSON file into time_to_dry or tmd, either as the "file" part of a multipart form or as the raw request body. Timestamps may be in the stored format, RFC 3339, unix seconds or milliseconds, or common date-time layouts; the layout of the first row is used for the whole file. Rows that duplicate stored ones or earlier rows of the file are skipped and invalid rows are reported by line.
+// @Tags Import
+// @Accept text/csv,application/x-ndjson,multipart/form-data
+// @Produce json
+// @Param table path string true "time_to_dry or tmd"
+// @Param file formData file false "The file to import"
+// @Param format query string false "csv or ndjson, detected from the file name or content when omitted"
+// @Param map query string false "Column mapping as source=field pairs, e.g. Humidity In=hum_in,Time=timestamp"
+// @Param dry_run query bool false "Check the file without storing anything"
+// @Success 200 {object} importer.Report
+// @Failure 400 {object} controllers.ErrorResponse "Invalid options or file"
+// @Failure 413 {object} controllers.ErrorResponse "File too large"
+// @Router /api/import/{table} [post]
+func ImportTable(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	opts := importer.Options{Table: mux.Vars(r)["table"], Format: q.Get("format")}
+	errs := listing.Errors{}
+	if opts.Format != "" && opts.Format != importer.CSV && opts.Format != importer.NDJSON {
+		errs["format"] = "must be csv or ndjson"
+	}
+	mapping, err := importer.ParseMapping(q.Get("map"))
+	if err != nil {
+		errs["map"] = err.Error()
+	}
+	opts.Mapping = mapping
+	if v := q.Get("dry_run"); v != "" {
+		if opts.DryRun, err = strconv.ParseBool(v); err != nil {
+			errs["dry_run"] = "must be true or false"
+		}
+	}
+	if len(errs) > 0 {
+		writeValidationError(w, errs)
+		return
+	}
+
+	// Large files take longer than the server's read and write timeouts.
+	rc := http.NewResponseController(w)
+	rc.SetReadDeadline(time.Time{})
+	rc.SetWriteDeadline(time.Time{})
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportSize)
+	var body io.Reader = r.Body
+	if mr, err := r.MultipartReader(); err == nil {
+		body = nil
+		for body == nil {
+			part, err := mr.NextPart()
+			if err != nil {
+				writeError(w, http.StatusBadRequest, `Missing "file" part`)
+				return
+			}
+			if part.FormName() == "file" {
+				body, opts.Filename = part, part.FileName()
+			}
+		}
+	}
+
+	report, err := importer.Default.Import(body, opts)
+	var tooLarge *http.MaxBytesError
+	switch {
+	case errors.As(err, &tooLarge):
+		writeError(w, http.StatusRequestEntityTooLarge, "File too large")
+	case errors.Is(err, importer.ErrInvalidInput):
+		writeError(w, http.StatusBadRequest, err.Error())
+	case err != nil:
+		writeError(w, http.StatusInternalServerError, err.Error())
+	default:
+		writeJSON(w, http.StatusOK, report)
+	}
+}
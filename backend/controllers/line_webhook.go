@@ -1,14 +1,18 @@
 package controllers
 
 import (
-	"fmt"
+	"log"
 	"net/http"
 	"os"
 
+	"backend/linecmd"
+
 	"github.com/line/line-bot-sdk-go/v7/linebot"
 )
 
-// LineWebhook handles incoming messages from LINE users.
+// LineWebhook answers commands sent by LINE users with Flex message replies
+// and keeps the alert subscriptions in step with follow, unfollow, join and
+// leave events.
 func LineWebhook(w http.ResponseWriter, r *http.Request) {
 	bot, err := linebot.New(
 		os.Getenv("LINE_CHANNEL_SECRET"),
@@ -26,14 +30,12 @@ func LineWebhook(w http.ResponseWriter, r *http.Request) {
 	}
 
 	for _, event := range events {
-		if event.Type == linebot.EventTypeMessage {
-			switch msg := event.Message.(type) {
-			case *linebot.TextMessage:
-				reply := fmt.Sprintf("You said: %s", msg.Text)
-				if _, err := bot.ReplyMessage(event.ReplyToken, linebot.NewTextMessage(reply)).Do(); err != nil {
-					fmt.Println("Reply error:", err)
-				}
-			}
+		reply := linecmd.Default.HandleEvent(r.Context(), event)
+		if reply == nil || event.ReplyToken == "" {
+			continue
+		}
+		if _, err := bot.ReplyMessage(event.ReplyToken, reply).Do(); err != nil {
+			log.Println("Reply error:", err)
 		}
 	}
 }
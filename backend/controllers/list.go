@@ -0,0 +1,36 @@
+package controllers
+
+import (
+	"net/http"
+
+	"backend/listing"
+	"backend/repository"
+)
+
+// writeValidationError reports invalid query parameters, one detail per parameter.
+func writeValidationError(w http.ResponseWriter, errs listing.Errors) {
+	writeJSON(w, http.StatusBadRequest, ErrorResponse{Error: "Invalid query parameters", Details: errs})
+}
+
+// listTable answers a list request for table with a page of rows and
+// pagination metadata. key returns the cursor position of a row.
+func listTable[T any](w http.ResponseWriter, r *http.Request, table repository.Table[T], opts listing.Options, key func(T) listing.Cursor) {
+	p, errs := listing.Parse(r.URL.Query(), opts)
+	if errs != nil {
+		writeValidationError(w, errs)
+		return
+	}
+
+	rows, total, err := table.Page(p)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	resp, err := listing.Respond(p, rows, total, key)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
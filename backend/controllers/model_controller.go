@@ -0,0 +1,95 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"backend/estimator"
+
+	"github.com/gorilla/mux"
+)
+
+// TrainDryTimeModel godoc
+// @Summary Train a drying time model
+// @Description Fits the EstimateDryTime coefficients by least squares on the sessions that ended detected dry, timed from their start to their end, with their combined_data averages, and stores them as a new model version. The new version is activated unless activate=false.
+// @Tags Drying
+// @Produce json
+// @Param activate query bool false "Activate the new version (default true)"
+// @Success 201 {object} models.DryTimeModel
+// @Failure 422 {object} controllers.ErrorResponse "Not enough or degenerate training data"
+// @Router /api/drytime/train [post]
+func TrainDryTimeModel(w http.ResponseWriter, r *http.Request) {
+	activate := true
+	if q := r.URL.Query().Get("activate"); q != "" {
+		b, err := strconv.ParseBool(q)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "activate must be true or false")
+			return
+		}
+		activate = b
+	}
+
+	m, err := estimator.Train(activate)
+	if err != nil {
+		if errors.Is(err, estimator.ErrNotEnoughSamples) || errors.Is(err, estimator.ErrSingular) {
+			writeError(w, http.StatusUnprocessableEntity, err.Error())
+			return
+		}
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusCreated, m)
+}
+
+// ListDryTimeModels godoc
+// @Summary List drying time models
+// @Description Returns every trained model version, newest first, and the version currently used by the estimate endpoint.
+// @Tags Drying
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Router /api/drytime/models [get]
+func ListDryTimeModels(w http.ResponseWriter, r *http.Request) {
+	list, err := estimator.ListModels()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	active, err := estimator.ActiveModel()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"active_version": active.Version,
+		"models":         list,
+	})
+}
+
+// ActivateDryTimeModel godoc
+// @Summary Activate a drying time model version
+// @Description Makes the given version the one used by the estimate endpoint. Version 0 restores the builtin coefficients.
+// @Tags Drying
+// @Produce json
+// @Param version path int true "Model version"
+// @Success 200 {object} models.DryTimeModel
+// @Failure 404 {object} controllers.ErrorResponse "Unknown version"
+// @Router /api/drytime/models/{version}/activate [post]
+func ActivateDryTimeModel(w http.ResponseWriter, r *http.Request) {
+	version, err := strconv.Atoi(mux.Vars(r)["version"])
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid version")
+		return
+	}
+
+	m, err := estimator.Activate(version)
+	if err != nil {
+		if errors.Is(err, estimator.ErrModelNotFound) {
+			writeError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, m)
+}
@@ -0,0 +1,126 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"backend/ingest"
+	"backend/models"
+	"backend/utils"
+)
+
+// maxReadingsBatch bounds how many readings a single request may carry.
+const maxReadingsBatch = 1000
+
+// DeviceHeader names the device that sent readings over HTTP.
+const DeviceHeader = "X-Device-ID"
+
+// ReadingResult reports the outcome for one reading of an ingestion request.
+type ReadingResult struct {
+	Index   int                 `json:"index"`
+	Status  string              `json:"status"`
+	Reading *models.TimeToDry   `json:"reading,omitempty"`
+	Errors  []ingest.FieldError `json:"errors,omitempty"`
+}
+
+// readingRequest is a reading as sent to IngestReadings. Its timestamp may be
+// RFC 3339, or "2006-01-02 15:04:05" in local time as devices used to send it.
+type readingRequest struct {
+	models.TimeToDry
+	Timestamp string `json:"timestamp"`
+}
+
+// IngestReadingsResponse summarises an ingestion request.
+type IngestReadingsResponse struct {
+	Accepted int             `json:"accepted"`
+	Rejected int             `json:"rejected"`
+	Results  []ReadingResult `json:"results"`
+}
+
+// IngestReadings godoc
+// @Summary Ingest sensor readings
+// @Description Accepts one reading or an array of readings in the time_to_dry shape. Humidity must be 0–100, temperatures between -40 and 85 °C and light non-negative. diff_temp and diff_hum are recomputed server-side; timestamp is RFC 3339 or "2006-01-02 15:04:05" in local time, defaults to now, and test_id is assigned when omitted.
+// @Tags TimeToDry
+// @Accept json
+// @Produce json
+// @Param readings body models.TimeToDry true "A reading or an array of readings"
+// @Param X-Device-ID header string false "Device that sent the readings, used to filter the live stream"
+// @Success 201 {object} controllers.IngestReadingsResponse "All readings stored"
+// @Success 207 {object} controllers.IngestReadingsResponse "Some readings rejected"
+// @Failure 400 {object} controllers.ErrorResponse "Malformed body"
+// @Failure 422 {object} controllers.IngestReadingsResponse "No reading stored"
+// @Router /api/ttd/readings [post]
+func IngestReadings(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, 4<<20))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Request body too large or unreadable")
+		return
+	}
+
+	var readings []readingRequest
+	trimmed := bytes.TrimSpace(body)
+	if len(trimmed) > 0 && trimmed[0] == '[' {
+		err = json.Unmarshal(trimmed, &readings)
+	} else {
+		var single readingRequest
+		err = json.Unmarshal(trimmed, &single)
+		readings = []readingRequest{single}
+	}
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid JSON: "+err.Error())
+		return
+	}
+	if len(readings) == 0 {
+		writeError(w, http.StatusBadRequest, "No readings supplied")
+		return
+	}
+	if len(readings) > maxReadingsBatch {
+		writeError(w, http.StatusBadRequest, "Too many readings in one request")
+		return
+	}
+
+	device := r.Header.Get(DeviceHeader)
+	resp := IngestReadingsResponse{Results: make([]ReadingResult, 0, len(readings))}
+	for i := range readings {
+		reading := readings[i].TimeToDry
+		result := ReadingResult{Index: i}
+
+		var err error
+		if ts := readings[i].Timestamp; ts != "" {
+			if reading.Timestamp, err = utils.ParseLocalTimestamp(ts); err != nil {
+				err = &ingest.ValidationError{Fields: []ingest.FieldError{{Field: "timestamp", Message: "must be RFC 3339 or \"2006-01-02 15:04:05\""}}}
+			}
+		}
+		if err == nil {
+			err = ingest.Default.IngestFrom(device, &reading)
+		}
+		var verr *ingest.ValidationError
+		switch {
+		case err == nil:
+			result.Status = "created"
+			result.Reading = &reading
+			resp.Accepted++
+		case errors.As(err, &verr):
+			result.Status = "rejected"
+			result.Errors = verr.Fields
+			resp.Rejected++
+		default:
+			result.Status = "error"
+			result.Errors = []ingest.FieldError{{Field: "", Message: err.Error()}}
+			resp.Rejected++
+		}
+		resp.Results = append(resp.Results, result)
+	}
+
+	status := http.StatusCreated
+	switch {
+	case resp.Accepted == 0:
+		status = http.StatusUnprocessableEntity
+	case resp.Rejected > 0:
+		status = http.StatusMultiStatus
+	}
+	writeJSON(w, status, resp)
+}
@@ -0,0 +1,47 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"backend/listing"
+	"backend/retention"
+)
+
+// GetRetentionStatus godoc
+// @Summary Retention metrics
+// @Description Returns the totals of the retention runs since the server started, dry runs excluded: rows removed by table, rollups written and pruned, and the last report.
+// @Tags Retention
+// @Produce json
+// @Success 200 {object} retention.Metrics
+// @Router /api/retention [get]
+func GetRetentionStatus(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, retention.Default.Metrics())
+}
+
+// RunRetention godoc
+// @Summary Run the retention job
+// @Description Summarises the time_to_dry, tmd and combined_data rows older than their retention (RETENTION_*_DAYS) into the per-minute and per-hour rollup tables and deletes them, then deletes the per-minute rollups older than RETENTION_MINUTE_ROLLUP_DAYS. With dry_run it only reports what would be removed.
+// @Tags Retention
+// @Produce json
+// @Param dry_run query bool false "Report without removing anything"
+// @Success 200 {object} retention.Report
+// @Failure 400 {object} controllers.ErrorResponse "Invalid dry_run"
+// @Failure 500 {object} controllers.ErrorResponse
+// @Router /api/retention/run [post]
+func RunRetention(w http.ResponseWriter, r *http.Request) {
+	var dryRun bool
+	if v := r.URL.Query().Get("dry_run"); v != "" {
+		var err error
+		if dryRun, err = strconv.ParseBool(v); err != nil {
+			writeValidationError(w, listing.Errors{"dry_run": "must be true or false"})
+			return
+		}
+	}
+	report, err := retention.Default.Prune(dryRun)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, report)
+}
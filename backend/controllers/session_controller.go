@@ -0,0 +1,159 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"backend/models"
+	"backend/sessions"
+
+	"github.com/gorilla/mux"
+)
+
+// StopSessionRequest is the optional body of a stop request.
+type StopSessionRequest struct {
+	Reason string `json:"reason"`
+}
+
+// ListSessions godoc
+// @Summary List drying sessions
+// @Description Returns drying sessions, newest first.
+// @Tags Session
+// @Produce json
+// @Param limit query int false "Maximum number of sessions (default 50)"
+// @Success 200 {array} models.DryingSession
+// @Router /api/sessions [get]
+func ListSessions(w http.ResponseWriter, r *http.Request) {
+	limit := 50
+	if q := r.URL.Query().Get("limit"); q != "" {
+		n, err := strconv.Atoi(q)
+		if err != nil || n <= 0 {
+			writeError(w, http.StatusBadRequest, "limit must be a positive integer")
+			return
+		}
+		limit = n
+	}
+
+	list, err := sessions.Default.List(limit)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, list)
+}
+
+// GetActiveSession godoc
+// @Summary Get the active drying session
+// @Tags Session
+// @Produce json
+// @Success 200 {object} models.DryingSession
+// @Failure 404 {object} controllers.ErrorResponse "No active session"
+// @Router /api/sessions/active [get]
+func GetActiveSession(w http.ResponseWriter, r *http.Request) {
+	active, err := sessions.Default.Active()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if active == nil {
+		writeError(w, http.StatusNotFound, "No active session")
+		return
+	}
+	writeJSON(w, http.StatusOK, active)
+}
+
+// GetSession godoc
+// @Summary Get a drying session
+// @Tags Session
+// @Produce json
+// @Param test_id path int true "Session test_id"
+// @Success 200 {object} models.DryingSession
+// @Failure 404 {object} controllers.ErrorResponse "Session not found"
+// @Router /api/sessions/{test_id} [get]
+func GetSession(w http.ResponseWriter, r *http.Request) {
+	testID, err := strconv.Atoi(mux.Vars(r)["test_id"])
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid test_id")
+		return
+	}
+
+	s, err := sessions.Default.Get(testID)
+	if err != nil {
+		writeSessionError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, s)
+}
+
+// StartSession godoc
+// @Summary Start a drying session
+// @Description Starts a manual drying session. Readings without a test_id are attached to it.
+// @Tags Session
+// @Produce json
+// @Success 201 {object} models.DryingSession
+// @Failure 409 {object} controllers.ErrorResponse "A session is already active"
+// @Router /api/sessions/start [post]
+func StartSession(w http.ResponseWriter, r *http.Request) {
+	s, err := sessions.Default.Start()
+	if err != nil {
+		writeSessionError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, s)
+}
+
+// StopSession godoc
+// @Summary Stop a drying session
+// @Description Ends an active drying session. reason is one of manual (default), dry_detected, timeout or rain.
+// @Tags Session
+// @Accept json
+// @Produce json
+// @Param test_id path int true "Session test_id"
+// @Param request body controllers.StopSessionRequest false "End reason"
+// @Success 200 {object} models.DryingSession
+// @Failure 400 {object} controllers.ErrorResponse "Invalid reason"
+// @Failure 404 {object} controllers.ErrorResponse "Session not found"
+// @Failure 409 {object} controllers.ErrorResponse "Session already ended"
+// @Router /api/sessions/{test_id}/stop [post]
+func StopSession(w http.ResponseWriter, r *http.Request) {
+	testID, err := strconv.Atoi(mux.Vars(r)["test_id"])
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid test_id")
+		return
+	}
+
+	req := StopSessionRequest{Reason: models.EndReasonManual}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid JSON: "+err.Error())
+			return
+		}
+		if req.Reason == "" {
+			req.Reason = models.EndReasonManual
+		}
+	}
+	if !models.IsValidEndReason(req.Reason) {
+		writeError(w, http.StatusBadRequest, "reason must be one of manual, dry_detected, timeout, rain")
+		return
+	}
+
+	s, err := sessions.Default.Stop(testID, req.Reason)
+	if err != nil {
+		writeSessionError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, s)
+}
+
+func writeSessionError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, sessions.ErrNotFound):
+		writeError(w, http.StatusNotFound, "Session not found")
+	case errors.Is(err, sessions.ErrSessionActive), errors.Is(err, sessions.ErrSessionEnded):
+		writeError(w, http.StatusConflict, err.Error())
+	default:
+		writeError(w, http.StatusInternalServerError, err.Error())
+	}
+}
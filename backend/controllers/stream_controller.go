@@ -0,0 +1,127 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"backend/events"
+)
+
+// streamHeartbeat is how often a comment is sent on an idle stream, so
+// proxies do not close it.
+const streamHeartbeat = 15 * time.Second
+
+var streamTypes = []string{events.TypeReading, events.TypeSession, events.TypeETA, events.TypeAlert}
+
+// StreamEvents godoc
+// @Summary Live event stream
+// @Description Server-Sent Events stream of new readings (reading), session starts and ends (session), ETA updates of the running session (eta) and sent alerts (alert). Each event's data is the JSON event envelope. Reconnecting clients send Last-Event-ID (or last_event_id) to receive the events they missed; if those are no longer available a reset event is sent first and the client should refetch its state.
+// @Tags Stream
+// @Produce text/event-stream
+// @Param types query string false "Comma-separated event types (default all)"
+// @Param device query string false "Only readings from this device"
+// @Param test_id query int false "Only events of this session"
+// @Param last_event_id query int false "Resume after this event ID"
+// @Param Last-Event-ID header int false "Resume after this event ID"
+// @Success 200 {object} events.Event
+// @Failure 400 {object} controllers.ErrorResponse "Invalid filter"
+// @Router /api/stream [get]
+func StreamEvents(w http.ResponseWriter, r *http.Request) {
+	filter, err := eventFilter(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	lastID := r.Header.Get("Last-Event-ID")
+	if lastID == "" {
+		lastID = r.URL.Query().Get("last_event_id")
+	}
+	var after uint64
+	if lastID != "" {
+		if after, err = strconv.ParseUint(lastID, 10, 64); err != nil {
+			writeError(w, http.StatusBadRequest, "Last-Event-ID must be an event ID")
+			return
+		}
+	}
+
+	// The server's write timeout would cut the stream; it lives as long as the client.
+	rc := http.NewResponseController(w)
+	rc.SetWriteDeadline(time.Time{})
+
+	sub, backlog, complete := events.Default.Subscribe(filter, after)
+	defer events.Default.Unsubscribe(sub)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	fmt.Fprint(w, "retry: 3000\n\n")
+	if !complete {
+		fmt.Fprintf(w, "event: reset\ndata: {\"last_event_id\":%d}\n\n", events.Default.LastID())
+	}
+	for _, e := range backlog {
+		writeEvent(w, e)
+	}
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": ping\n\n")
+		case e, ok := <-sub.C:
+			if !ok {
+				// Dropped for falling behind; the client reconnects and resumes.
+				return
+			}
+			writeEvent(w, e)
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// eventFilter reads the types, device and test_id query parameters.
+func eventFilter(r *http.Request) (events.Filter, error) {
+	q := r.URL.Query()
+	f := events.Filter{Device: q.Get("device")}
+	if types := q.Get("types"); types != "" {
+		for _, t := range strings.Split(types, ",") {
+			t = strings.TrimSpace(t)
+			if !slices.Contains(streamTypes, t) {
+				return f, fmt.Errorf("unknown event type %q, expected one of %s", t, strings.Join(streamTypes, ", "))
+			}
+			f.Types = append(f.Types, t)
+		}
+	}
+	if q.Get("test_id") != "" {
+		id, err := strconv.Atoi(q.Get("test_id"))
+		if err != nil || id <= 0 {
+			return f, fmt.Errorf("test_id must be a positive integer")
+		}
+		f.TestID = id
+	}
+	return f, nil
+}
+
+func writeEvent(w io.Writer, e events.Event) {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return
+	}
+	fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", e.ID, e.Type, data)
+}
@@ -0,0 +1,18 @@
+package controllers
+
+import (
+	"net/http"
+
+	"backend/ws"
+)
+
+// WebSocket godoc
+// @Summary WebSocket API
+// @Description Upgrades to a WebSocket. Clients send JSON commands with a type and an optional id that is echoed in the answer: subscribe (topics device, session and/or alerts, optional device, test_id and last_event_id), unsubscribe (topics), start_session, stop_session (optional test_id, default the active session, and reason) and ack_alert (alert_id). The server answers each command with a result or error message, and sends event messages with the same event envelope as /api/stream for the subscribed topics. A reset message means events were lost and the client should reload its state.
+// @Tags Stream
+// @Success 101 {object} ws.ServerMessage
+// @Failure 400 {string} string "Not a WebSocket request"
+// @Router /api/ws [get]
+func WebSocket(w http.ResponseWriter, r *http.Request) {
+	ws.Default.ServeHTTP(w, r)
+}
@@ -1,11 +1,18 @@
 package database
 
 import (
+	"database/sql/driver"
+	"errors"
 	"fmt"
+	"io/fs"
 	"log"
 	"os"
 	"time"
 
+	"backend/config"
+	"backend/models"
+
+	"github.com/glebarez/sqlite"
 	"github.com/joho/godotenv"
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
@@ -13,35 +20,141 @@ import (
 
 var DB *gorm.DB
 
-func Connect() {
-	err := godotenv.Load(".env")
-	if err != nil {
-		log.Fatal("Error loading .env file")
-	}
+// Drivers.
+const (
+	MySQL  = "mysql"
+	SQLite = "sqlite"
+)
 
-	user := os.Getenv("DB_USER")
-	password := os.Getenv("DB_PASSWORD")
-	host := os.Getenv("DB_HOST")
-	dbname := os.Getenv("DB_NAME")
+// Config selects the database to connect to.
+type Config struct {
+	Driver string
+	// DSN is the MySQL data source name, or the SQLite file (":memory:" for
+	// a database that lives as long as the process).
+	DSN string
+}
 
-	dsn := fmt.Sprintf("%s:%s@tcp(%s)/%s?parseTime=true", user, password, host, dbname)
+// ConfigFromEnv reads DB_DRIVER (mysql or sqlite, default mysql). MySQL is
+// configured by DB_USER, DB_PASSWORD, DB_HOST and DB_NAME, SQLite by
+// DB_PATH (default time_to_dry.db). MySQL times are read and written in UTC.
+func ConfigFromEnv() (Config, error) {
+	switch driver := config.GetEnv("DB_DRIVER", MySQL); driver {
+	case MySQL:
+		dsn := fmt.Sprintf("%s:%s@tcp(%s)/%s?parseTime=true&loc=UTC",
+			os.Getenv("DB_USER"), os.Getenv("DB_PASSWORD"), os.Getenv("DB_HOST"), os.Getenv("DB_NAME"))
+		return Config{Driver: MySQL, DSN: dsn}, nil
+	case SQLite:
+		return Config{Driver: SQLite, DSN: config.GetEnv("DB_PATH", "time_to_dry.db")}, nil
+	default:
+		return Config{}, fmt.Errorf("unknown DB_DRIVER %q, expected %s or %s", driver, MySQL, SQLite)
+	}
+}
+
+// Open connects to the database described by cfg.
+func Open(cfg Config) (*gorm.DB, error) {
+	switch cfg.Driver {
+	case MySQL:
+		db, err := gorm.Open(mysql.Open(cfg.DSN), &gorm.Config{})
+		if err != nil {
+			return nil, err
+		}
+		sqlDB, err := db.DB()
+		if err != nil {
+			return nil, err
+		}
+		// ✅ Connection Pooling Configuration
+		sqlDB.SetMaxOpenConns(10)                  // max open connections
+		sqlDB.SetMaxIdleConns(5)                   // max idle connections
+		sqlDB.SetConnMaxLifetime(30 * time.Minute) // recycle connections every 30 min
+		return db, nil
+	case SQLite:
+		dsn := cfg.DSN
+		if dsn != ":memory:" {
+			// Readers do not block the writer, and a busy writer is waited for.
+			dsn += "?_pragma=journal_mode(WAL)&_pragma=busy_timeout(5000)"
+		}
+		db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{})
+		if err != nil {
+			return nil, err
+		}
+		sqlDB, err := db.DB()
+		if err != nil {
+			return nil, err
+		}
+		// SQLite has a single writer, and every connection to ":memory:"
+		// would open a database of its own.
+		sqlDB.SetMaxOpenConns(1)
+		return db, nil
+	default:
+		return nil, fmt.Errorf("unknown database driver %q", cfg.Driver)
+	}
+}
 
-	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{})
+// Connect opens the database configured in the environment, or in a .env
+// file when there is one, and sets DB.
+func Connect() {
+	if err := godotenv.Load(".env"); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		log.Fatalf("Error loading .env file: %v", err)
+	}
+	cfg, err := ConfigFromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+	db, err := Open(cfg)
 	if err != nil {
 		log.Fatalf("Failed to connect to database: %v", err)
 	}
+	log.Printf("Database connected (%s)", cfg.Driver)
+	DB = db
+}
 
-	sqlDB, err := db.DB()
-	if err != nil {
-		log.Fatalf("Failed to get database connection: %v", err)
+// Migrate creates the tables owned by the backend itself. The sensor tables
+// (time_to_dry, tmd, combined_data) are versioned by the migrations package.
+func Migrate() {
+	if err := DB.AutoMigrate(&models.DryingSession{}, &models.DryTimeModel{}, &models.Alert{}, &models.LineSubscriber{}, &models.Watermark{}); err != nil {
+		log.Fatalf("Failed to migrate database: %v", err)
 	}
+}
 
-	// ✅ Connection Pooling Configuration
-	sqlDB.SetMaxOpenConns(10)               // max open connections
-	sqlDB.SetMaxIdleConns(5)                // max idle connections
-	sqlDB.SetConnMaxLifetime(30 * time.Minute) // recycle connections every 30 min
+// Time scans a timestamp column, or the MIN or MAX of one, which SQLite
+// returns as text rather than as a time. NULL scans as the zero time.
+type Time struct{ time.Time }
 
-	log.Println("Database connected")
+// timeLayouts are the layouts times are read from text in.
+var timeLayouts = []string{"2006-01-02 15:04:05.999999999-07:00", time.RFC3339Nano, "2006-01-02 15:04:05"}
 
-	DB = db
-}
\ No newline at end of file
+func (t *Time) Scan(value any) error {
+	switch v := value.(type) {
+	case nil:
+		t.Time = time.Time{}
+		return nil
+	case time.Time:
+		t.Time = v
+		return nil
+	case []byte:
+		return t.Scan(string(v))
+	case string:
+		parsed, err := ParseTime(v)
+		t.Time = parsed
+		return err
+	}
+	return fmt.Errorf("cannot scan %T into a time", value)
+}
+
+func (t Time) Value() (driver.Value, error) {
+	if t.IsZero() {
+		return nil, nil
+	}
+	return t.Time, nil
+}
+
+// ParseTime reads a time as SQLite returns it as text, or in RFC 3339.
+// Times without a zone are in UTC.
+func ParseTime(s string) (time.Time, error) {
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q", s)
+}
@@ -0,0 +1,126 @@
+package detector
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"backend/config"
+)
+
+// Config tunes when laundry is considered dry.
+//
+// Wet clothes keep the air around them more humid (and cooler) than the
+// outside air, so hum_in - hum_out starts high and converges as they dry.
+// Laundry is dry once both differences have stayed below their thresholds,
+// and DiffHum has stopped moving, for a sustained window.
+type Config struct {
+	// MaxDiffHum is the largest |DiffHum| in percentage points that counts as dry.
+	MaxDiffHum float64
+	// MaxDiffTemp is the largest |DiffTemp| in °C that counts as dry.
+	MaxDiffTemp float64
+	// Plateau is how far DiffHum may move within the window and still count as flat.
+	Plateau float64
+	// Window is how long the dry conditions must hold.
+	Window time.Duration
+	// MinDuration is how long a session must have been running before it can be detected dry.
+	MinDuration time.Duration
+}
+
+// DefaultConfig is used when no DRY_* variables are set.
+var DefaultConfig = Config{
+	MaxDiffHum:  3,
+	MaxDiffTemp: 1.5,
+	Plateau:     1.5,
+	Window:      15 * time.Minute,
+	MinDuration: 20 * time.Minute,
+}
+
+// ConfigFromEnv reads DRY_MAX_DIFF_HUM, DRY_MAX_DIFF_TEMP, DRY_PLATEAU,
+// DRY_WINDOW and DRY_MIN_DURATION, falling back to DefaultConfig.
+func ConfigFromEnv() Config {
+	return Config{
+		MaxDiffHum:  config.GetEnvFloat("DRY_MAX_DIFF_HUM", DefaultConfig.MaxDiffHum),
+		MaxDiffTemp: config.GetEnvFloat("DRY_MAX_DIFF_TEMP", DefaultConfig.MaxDiffTemp),
+		Plateau:     config.GetEnvFloat("DRY_PLATEAU", DefaultConfig.Plateau),
+		Window:      config.GetEnvDuration("DRY_WINDOW", DefaultConfig.Window),
+		MinDuration: config.GetEnvDuration("DRY_MIN_DURATION", DefaultConfig.MinDuration),
+	}
+}
+
+// Reading is the part of a sensor reading the detector looks at.
+type Reading struct {
+	At       time.Time
+	DiffTemp float64
+	DiffHum  float64
+}
+
+// Detector follows the readings of each drying session and reports when
+// the laundry in it has become dry. It is safe for concurrent use.
+type Detector struct {
+	Config Config
+
+	mu     sync.Mutex
+	tracks map[int]*track
+}
+
+// New returns a Detector using cfg.
+func New(cfg Config) *Detector {
+	return &Detector{Config: cfg, tracks: map[int]*track{}}
+}
+
+// track holds the current run of dry-looking readings of one session.
+type track struct {
+	started time.Time
+	run     []Reading
+}
+
+// Observe adds a reading of the session with the given TestID, started at
+// sessionStart. Once the laundry is detected dry it returns true and the
+// time it became dry: the first reading of the qualifying run. The session
+// is then forgotten.
+//
+// Only one session runs at a time, so the first reading of a new session
+// drops the state of the previous ones.
+func (d *Detector) Observe(testID int, sessionStart time.Time, r Reading) (time.Time, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	t := d.tracks[testID]
+	if t == nil {
+		clear(d.tracks)
+		t = &track{started: sessionStart}
+		d.tracks[testID] = t
+	}
+
+	if math.Abs(r.DiffHum) > d.Config.MaxDiffHum || math.Abs(r.DiffTemp) > d.Config.MaxDiffTemp {
+		t.run = t.run[:0]
+		return time.Time{}, false
+	}
+	if n := len(t.run); n > 0 && !r.At.After(t.run[n-1].At) {
+		// Out-of-order or duplicate readings do not extend the run.
+		return time.Time{}, false
+	}
+	t.run = append(t.run, r)
+
+	// Drop readings from the start of the run until DiffHum is flat again.
+	for len(t.run) > 1 && spread(t.run) > d.Config.Plateau {
+		t.run = t.run[1:]
+	}
+
+	first, last := t.run[0], t.run[len(t.run)-1]
+	if last.At.Sub(first.At) < d.Config.Window || last.At.Sub(t.started) < d.Config.MinDuration {
+		return time.Time{}, false
+	}
+	delete(d.tracks, testID)
+	return first.At, true
+}
+
+func spread(run []Reading) float64 {
+	lo, hi := run[0].DiffHum, run[0].DiffHum
+	for _, r := range run[1:] {
+		lo = math.Min(lo, r.DiffHum)
+		hi = math.Max(hi, r.DiffHum)
+	}
+	return hi - lo
+}
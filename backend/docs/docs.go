@@ -15,37 +15,281 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/api/combined": {
+        "/api/alerts": {
             "get": {
-                "description": "Returns all Weather API from tmd table.",
+                "description": "Returns the most recent rain, all-clear and drying-complete alerts, newest first.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "CombinedData"
+                    "Forecast"
+                ],
+                "summary": "List sent alerts",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of alerts (default 20, max 200)",
+                        "name": "limit",
+                        "in": "query"
+                    }
                 ],
-                "summary": "Get all combined records",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/models.CombinedData"
+                                "$ref": "#/definitions/models.Alert"
                             }
                         }
+                    },
+                    "400": {
+                        "description": "Invalid limit",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
                     }
                 }
             }
         },
-        "/api/combined/populate": {
+        "/api/alerts/{id}/ack": {
             "post": {
-                "description": "Matches closest timestamp from tmd for each time_to_dry record and inserts combined row if not duplicate.",
-                "consumes": [
+                "description": "Marks an alert as seen. Acknowledging it again keeps the first time. Clients following alerts on the stream receive the updated alert.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Forecast"
+                ],
+                "summary": "Acknowledge an alert",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Alert ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Alert"
+                        }
+                    },
+                    "404": {
+                        "description": "Alert not found",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/combined": {
+            "get": {
+                "description": "Returns a page of sensor records joined with the closest weather record from the combined_data table, ordered by timestamp. Pass pagination.next_cursor as cursor to get the next page.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "CombinedData"
+                ],
+                "summary": "List combined records",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Only rows at or after this time (2006-01-02, 2006-01-02 15:04:05 or RFC 3339)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only rows before this time",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Only rows of this drying session",
+                        "name": "test_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Rows per page (default 100, max 1000)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Timestamp order, asc (default) or desc",
+                        "name": "order",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated fields to return (default all)",
+                        "name": "fields",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/listing.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/models.CombinedData"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid query parameters",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/combined/aggregate": {
+            "get": {
+                "description": "Returns combined_data records downsampled into buckets of interval with the min, max and average of each field. Buckets are aligned to local midnight and empty buckets are left out.",
+                "produces": [
                     "application/json"
                 ],
+                "tags": [
+                    "CombinedData"
+                ],
+                "summary": "Aggregate combined records",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bucket size: 1m, 5m, 1h or 1d",
+                        "name": "interval",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated numeric fields (default all)",
+                        "name": "fields",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only rows at or after this time (2006-01-02, 2006-01-02 15:04:05 or RFC 3339)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only rows before this time",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Only rows of this drying session",
+                        "name": "test_id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.AggregateResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid query parameters",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/combined/export": {
+            "get": {
+                "description": "Streams combined_data records ordered by timestamp as a CSV, NDJSON or Parquet download.",
+                "produces": [
+                    "text/csv",
+                    "application/x-ndjson",
+                    "application/vnd.apache.parquet"
+                ],
+                "tags": [
+                    "CombinedData"
+                ],
+                "summary": "Export combined records",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "csv (default), ndjson or parquet",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only rows at or after this time (2006-01-02, 2006-01-02 15:04:05 or RFC 3339)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only rows before this time",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Only rows of this drying session",
+                        "name": "test_id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid query parameters",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/combined/populate": {
+            "post": {
+                "description": "Joins the time_to_dry records stored since the last run with tmd weather and inserts them into combined_data, recording the weather timestamp used and its distance. COMBINE_STRATEGY picks the closest observation (nearest, default), interpolates between the observations before and after (interpolate) or takes the last one before (carry_forward); observations further than COMBINE_MAX_GAP (default 3h) are not used. Records already combined are not inserted twice. Records newer than the latest weather are left for a later run until they are COMBINE_MAX_GAP old.",
                 "produces": [
-                    "text/plain"
+                    "application/json"
                 ],
                 "tags": [
                     "CombinedData"
@@ -53,9 +297,15 @@ const docTemplate = `{
                 "summary": "Populate combined_data from time_to_dry and tmd",
                 "responses": {
                     "200": {
-                        "description": "Combined data populated",
+                        "description": "OK",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/combine.Result"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
                         }
                     }
                 }
@@ -63,7 +313,7 @@ const docTemplate = `{
         },
         "/api/drytime/estimate": {
             "get": {
-                "description": "Estimate drying time in minutes using sensor variables",
+                "description": "Estimate drying time in minutes using sensor variables and the active model version (0 is the builtin formula).",
                 "produces": [
                     "application/json"
                 ],
@@ -119,16 +369,16 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/forecast/rain": {
+        "/api/drytime/models": {
             "get": {
-                "description": "Uses current weather data to estimate rainfall based on weather description.",
+                "description": "Returns every trained model version, newest first, and the version currently used by the estimate endpoint.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Forecast"
+                    "Drying"
                 ],
-                "summary": "Estimate if it's currently raining or likely to rain",
+                "summary": "List drying time models",
                 "responses": {
                     "200": {
                         "description": "OK",
@@ -140,106 +390,957 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/timetodry": {
-            "get": {
-                "description": "Returns all sensor records from the time_to_dry table.",
+        "/api/drytime/models/{version}/activate": {
+            "post": {
+                "description": "Makes the given version the one used by the estimate endpoint. Version 0 restores the builtin coefficients.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "TimeToDry"
+                    "Drying"
+                ],
+                "summary": "Activate a drying time model version",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Model version",
+                        "name": "version",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
-                "summary": "Get all time_to_dry records",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/models.TimeToDry"
-                            }
+                            "$ref": "#/definitions/models.DryTimeModel"
+                        }
+                    },
+                    "404": {
+                        "description": "Unknown version",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
                         }
                     }
                 }
             }
         },
-        "/api/tmd": {
-            "get": {
-                "description": "Returns all Weather API from tmd table.",
+        "/api/drytime/train": {
+            "post": {
+                "description": "Fits the EstimateDryTime coefficients by least squares on the sessions that ended detected dry, timed from their start to their end, with their combined_data averages, and stores them as a new model version. The new version is activated unless activate=false.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "TMD"
+                    "Drying"
+                ],
+                "summary": "Train a drying time model",
+                "parameters": [
+                    {
+                        "type": "boolean",
+                        "description": "Activate the new version (default true)",
+                        "name": "activate",
+                        "in": "query"
+                    }
                 ],
-                "summary": "Get all tmd records",
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "201": {
+                        "description": "Created",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/models.TMD"
-                            }
+                            "$ref": "#/definitions/models.DryTimeModel"
+                        }
+                    },
+                    "422": {
+                        "description": "Not enough or degenerate training data",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
                         }
                     }
                 }
             }
         },
-        "/api/tmd/recent": {
+        "/api/forecast/rain": {
             "get": {
-                "description": "Returns the 8 most recent weather data records from the TMD table within the last 24 hours.",
+                "description": "Alerts are sent by the background rain watch, not by this endpoint. Combines current weather and the hourly forecast of the configured provider (WEATHER_PROVIDER) into the chance and amount of rain for each of the next hours, when rain is expected to start and until when laundry is safe outside. will_rain_now_or_soon is true if it is raining now or rain starts within soon_hours. partial is true when the hourly forecast was unavailable, so only the current weather was checked.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "TMD"
+                    "Forecast"
+                ],
+                "summary": "Estimate if it's currently raining or likely to rain",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Forecast window in hours (default 12, max 48)",
+                        "name": "hours",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "How many hours ahead count as soon (default 3)",
+                        "name": "soon_hours",
+                        "in": "query"
+                    }
                 ],
-                "summary": "Get last 24 hours of TMD data (latest 8 rows)",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/models.TMD"
-                            }
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid hours or soon_hours",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "503": {
+                        "description": "No weather provider configured",
+                        "schema": {
+                            "type": "string"
                         }
                     }
                 }
             }
         },
-        "/api/tmd/today": {
-            "get": {
-                "description": "Returns all weather data from the TMD table for today.",
+        "/api/import/{table}": {
+            "post": {
+                "description": "Imports a CSV or NDJSON file into time_to_dry or tmd, either as the \"file\" part of a multipart form or as the raw request body. Timestamps may be in the stored format, RFC 3339, unix seconds or milliseconds, or common date-time layouts; the layout of the first row is used for the whole file. Rows that duplicate stored ones or earlier rows of the file are skipped and invalid rows are reported by line.",
+                "consumes": [
+                    "text/csv",
+                    "application/x-ndjson",
+                    "multipart/form-data"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "TMD"
+                    "Import"
+                ],
+                "summary": "Import historical records",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "time_to_dry or tmd",
+                        "name": "table",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "file",
+                        "description": "The file to import",
+                        "name": "file",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "csv or ndjson, detected from the file name or content when omitted",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Column mapping as source=field pairs, e.g. Humidity In=hum_in,Time=timestamp",
+                        "name": "map",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Check the file without storing anything",
+                        "name": "dry_run",
+                        "in": "query"
+                    }
                 ],
-                "summary": "Get today's TMD records",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/models.TMD"
-                            }
+                            "$ref": "#/definitions/importer.Report"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid options or file",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "File too large",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
                         }
                     }
                 }
             }
         },
-        "/api/ttd/latest": {
+        "/api/retention": {
             "get": {
-                "description": "Returns the highest test_id from time_to_dry table. ex.GET http://localhost:8080/api/ttd/status/check?test_id=5",
+                "description": "Returns the totals of the retention runs since the server started, dry runs excluded: rows removed by table, rollups written and pruned, and the last report.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Test"
+                    "Retention"
+                ],
+                "summary": "Retention metrics",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/retention.Metrics"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/retention/run": {
+            "post": {
+                "description": "Summarises the time_to_dry, tmd and combined_data rows older than their retention (RETENTION_*_DAYS) into the per-minute and per-hour rollup tables and deletes them, then deletes the per-minute rollups older than RETENTION_MINUTE_ROLLUP_DAYS. With dry_run it only reports what would be removed.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Retention"
+                ],
+                "summary": "Run the retention job",
+                "parameters": [
+                    {
+                        "type": "boolean",
+                        "description": "Report without removing anything",
+                        "name": "dry_run",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/retention.Report"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid dry_run",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/sessions": {
+            "get": {
+                "description": "Returns drying sessions, newest first.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Session"
+                ],
+                "summary": "List drying sessions",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of sessions (default 50)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.DryingSession"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/sessions/active": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Session"
+                ],
+                "summary": "Get the active drying session",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.DryingSession"
+                        }
+                    },
+                    "404": {
+                        "description": "No active session",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/sessions/start": {
+            "post": {
+                "description": "Starts a manual drying session. Readings without a test_id are attached to it.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Session"
+                ],
+                "summary": "Start a drying session",
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.DryingSession"
+                        }
+                    },
+                    "409": {
+                        "description": "A session is already active",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/sessions/{test_id}": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Session"
+                ],
+                "summary": "Get a drying session",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Session test_id",
+                        "name": "test_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.DryingSession"
+                        }
+                    },
+                    "404": {
+                        "description": "Session not found",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/sessions/{test_id}/eta": {
+            "get": {
+                "description": "Uses every reading of the session so far to predict the remaining drying time, the completion time, how dry the laundry is and a 95% confidence interval that narrows as readings arrive. Completed sessions report their actual end.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Session"
+                ],
+                "summary": "Predict when a drying session completes",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Session test_id",
+                        "name": "test_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/estimator.SessionETA"
+                        }
+                    },
+                    "404": {
+                        "description": "Session not found",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Session has no readings yet",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/sessions/{test_id}/stop": {
+            "post": {
+                "description": "Ends an active drying session. reason is one of manual (default), dry_detected, timeout or rain.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Session"
+                ],
+                "summary": "Stop a drying session",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Session test_id",
+                        "name": "test_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "End reason",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.StopSessionRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.DryingSession"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid reason",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Session not found",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Session already ended",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/stream": {
+            "get": {
+                "description": "Server-Sent Events stream of new readings (reading), session starts and ends (session), ETA updates of the running session (eta) and sent alerts (alert). Each event's data is the JSON event envelope. Reconnecting clients send Last-Event-ID (or last_event_id) to receive the events they missed; if those are no longer available a reset event is sent first and the client should refetch its state.",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "Stream"
+                ],
+                "summary": "Live event stream",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Comma-separated event types (default all)",
+                        "name": "types",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only readings from this device",
+                        "name": "device",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Only events of this session",
+                        "name": "test_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Resume after this event ID",
+                        "name": "last_event_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Resume after this event ID",
+                        "name": "Last-Event-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/events.Event"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid filter",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/timetodry": {
+            "get": {
+                "description": "Returns a page of sensor records from the time_to_dry table, ordered by timestamp. Pass pagination.next_cursor as cursor to get the next page.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "TimeToDry"
+                ],
+                "summary": "List time_to_dry records",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Only rows at or after this time (2006-01-02, 2006-01-02 15:04:05 or RFC 3339)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only rows before this time",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Only rows of this drying session",
+                        "name": "test_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Rows per page (default 100, max 1000)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Timestamp order, asc (default) or desc",
+                        "name": "order",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated fields to return (default all)",
+                        "name": "fields",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/listing.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/models.TimeToDry"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid query parameters",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/timetodry/aggregate": {
+            "get": {
+                "description": "Returns time_to_dry readings downsampled into buckets of interval with the min, max and average of each field. Buckets are aligned to local midnight and empty buckets are left out.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "TimeToDry"
+                ],
+                "summary": "Aggregate time_to_dry records",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bucket size: 1m, 5m, 1h or 1d",
+                        "name": "interval",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated numeric fields (default all)",
+                        "name": "fields",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only rows at or after this time (2006-01-02, 2006-01-02 15:04:05 or RFC 3339)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only rows before this time",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Only rows of this drying session",
+                        "name": "test_id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.AggregateResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid query parameters",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/timetodry/export": {
+            "get": {
+                "description": "Streams time_to_dry records ordered by timestamp as a CSV, NDJSON or Parquet download.",
+                "produces": [
+                    "text/csv",
+                    "application/x-ndjson",
+                    "application/vnd.apache.parquet"
+                ],
+                "tags": [
+                    "TimeToDry"
+                ],
+                "summary": "Export time_to_dry records",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "csv (default), ndjson or parquet",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only rows at or after this time (2006-01-02, 2006-01-02 15:04:05 or RFC 3339)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only rows before this time",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Only rows of this drying session",
+                        "name": "test_id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid query parameters",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/tmd": {
+            "get": {
+                "description": "Returns a page of weather records from the tmd table, ordered by timestamp. Pass pagination.next_cursor as cursor to get the next page.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "TMD"
+                ],
+                "summary": "List tmd records",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Only rows at or after this time (2006-01-02, 2006-01-02 15:04:05 or RFC 3339)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only rows before this time",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Rows per page (default 100, max 1000)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Timestamp order, asc (default) or desc",
+                        "name": "order",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated fields to return (default all)",
+                        "name": "fields",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/listing.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/models.TMD"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid query parameters",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/tmd/aggregate": {
+            "get": {
+                "description": "Returns tmd weather records downsampled into buckets of interval with the min, max and average of each field. Buckets are aligned to local midnight and empty buckets are left out.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "TMD"
+                ],
+                "summary": "Aggregate tmd records",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bucket size: 1m, 5m, 1h or 1d",
+                        "name": "interval",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated numeric fields (default all)",
+                        "name": "fields",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only rows at or after this time (2006-01-02, 2006-01-02 15:04:05 or RFC 3339)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only rows before this time",
+                        "name": "to",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.AggregateResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid query parameters",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/tmd/export": {
+            "get": {
+                "description": "Streams tmd weather records ordered by timestamp as a CSV, NDJSON or Parquet download.",
+                "produces": [
+                    "text/csv",
+                    "application/x-ndjson",
+                    "application/vnd.apache.parquet"
+                ],
+                "tags": [
+                    "TMD"
+                ],
+                "summary": "Export tmd records",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "csv (default), ndjson or parquet",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only rows at or after this time (2006-01-02, 2006-01-02 15:04:05 or RFC 3339)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only rows before this time",
+                        "name": "to",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid query parameters",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/tmd/recent": {
+            "get": {
+                "description": "Returns the 8 most recent weather data records from the TMD table within the last 24 hours.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "TMD"
+                ],
+                "summary": "Get last 24 hours of TMD data (latest 8 rows)",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.TMD"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/tmd/today": {
+            "get": {
+                "description": "Returns all weather data from the TMD table for today, in the display time zone.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "TMD"
+                ],
+                "summary": "Get today's TMD records",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.TMD"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/ttd/latest": {
+            "get": {
+                "description": "Returns the test_id of the most recent drying session. ex.GET http://localhost:8080/api/ttd/status/check?test_id=5",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Test"
                 ],
                 "summary": "Get the latest test_id",
                 "responses": {
@@ -247,122 +1348,570 @@ const docTemplate = `{
                         "description": "OK",
                         "schema": {
                             "type": "object",
-                            "additionalProperties": {
-                                "type": "integer"
-                            }
+                            "additionalProperties": {
+                                "type": "integer"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/ttd/latest/all": {
+            "get": {
+                "description": "Returns all time_to_dry rows recorded during the most recent drying session.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Test"
+                ],
+                "summary": "Get all rows of latest test_id",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.TimeToDry"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/ttd/latest/last": {
+            "get": {
+                "description": "Returns the latest time_to_dry row (by timestamp) of the most recent drying session.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Test"
+                ],
+                "summary": "Get the most recent row of latest test_id",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.TimeToDry"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/ttd/readings": {
+            "post": {
+                "description": "Accepts one reading or an array of readings in the time_to_dry shape. Humidity must be 0–100, temperatures between -40 and 85 °C and light non-negative. diff_temp and diff_hum are recomputed server-side; timestamp is RFC 3339 or \"2006-01-02 15:04:05\" in local time, defaults to now, and test_id is assigned when omitted.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "TimeToDry"
+                ],
+                "summary": "Ingest sensor readings",
+                "parameters": [
+                    {
+                        "description": "A reading or an array of readings",
+                        "name": "readings",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.TimeToDry"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Device that sent the readings, used to filter the live stream",
+                        "name": "X-Device-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "All readings stored",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.IngestReadingsResponse"
+                        }
+                    },
+                    "207": {
+                        "description": "Some readings rejected",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.IngestReadingsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Malformed body",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "No reading stored",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.IngestReadingsResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/ttd/status": {
+            "get": {
+                "description": "Returns whether the device is active (sending data within last 5 minutes) and the active drying session, if any.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Device"
+                ],
+                "summary": "Check device status",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/api/ttd/status/check": {
+            "get": {
+                "description": "Returns whether the drying session for a given test_id is still in progress or completed, and why it ended.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Test"
+                ],
+                "summary": "Check specific test status",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Test ID to check",
+                        "name": "test_id",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Missing or invalid test_id",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "No records found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/ws": {
+            "get": {
+                "description": "Upgrades to a WebSocket. Clients send JSON commands with a type and an optional id that is echoed in the answer: subscribe (topics device, session and/or alerts, optional device, test_id and last_event_id), unsubscribe (topics), start_session, stop_session (optional test_id, default the active session, and reason) and ack_alert (alert_id). The server answers each command with a result or error message, and sends event messages with the same event envelope as /api/stream for the subscribed topics. A reset message means events were lost and the client should reload its state.",
+                "tags": [
+                    "Stream"
+                ],
+                "summary": "WebSocket API",
+                "responses": {
+                    "101": {
+                        "description": "Switching Protocols",
+                        "schema": {
+                            "$ref": "#/definitions/ws.ServerMessage"
+                        }
+                    },
+                    "400": {
+                        "description": "Not a WebSocket request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "aggregate.Bucket": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer"
+                },
+                "fields": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/aggregate.Stats"
+                    }
+                },
+                "start": {
+                    "type": "string"
+                }
+            }
+        },
+        "aggregate.Stats": {
+            "type": "object",
+            "properties": {
+                "avg": {
+                    "type": "number"
+                },
+                "count": {
+                    "description": "Count is the number of values of the field, which may be less than\nthe readings of the bucket when some are missing.",
+                    "type": "integer"
+                },
+                "max": {
+                    "type": "number"
+                },
+                "min": {
+                    "type": "number"
+                }
+            }
+        },
+        "combine.Result": {
+            "type": "object",
+            "properties": {
+                "inserted": {
+                    "description": "Inserted counts the combined rows stored; readings already combined are not stored twice.",
+                    "type": "integer"
+                },
+                "invalid": {
+                    "description": "Invalid counts readings whose timestamp cannot be parsed.",
+                    "type": "integer"
+                },
+                "pending": {
+                    "description": "Pending counts readings left for a later run, as weather near them may still arrive.",
+                    "type": "integer"
+                },
+                "processed": {
+                    "description": "Processed counts the readings handled, matched or not.",
+                    "type": "integer"
+                },
+                "unmatched": {
+                    "description": "Unmatched counts readings without weather within the maximum gap, or\nwithout observations on both sides when interpolating. They are joined\nby Rejoin when weather of their time is imported.",
+                    "type": "integer"
+                },
+                "watermark": {
+                    "description": "Watermark is the id of the last reading handled.",
+                    "type": "integer"
+                }
+            }
+        },
+        "controllers.AggregateResponse": {
+            "type": "object",
+            "properties": {
+                "buckets": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/aggregate.Bucket"
+                    }
+                },
+                "fields": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "interval": {
+                    "type": "string"
+                }
+            }
+        },
+        "controllers.ErrorResponse": {
+            "type": "object",
+            "properties": {
+                "details": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "error": {
+                    "type": "string"
+                }
+            }
+        },
+        "controllers.IngestReadingsResponse": {
+            "type": "object",
+            "properties": {
+                "accepted": {
+                    "type": "integer"
+                },
+                "rejected": {
+                    "type": "integer"
+                },
+                "results": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/controllers.ReadingResult"
+                    }
+                }
+            }
+        },
+        "controllers.ReadingResult": {
+            "type": "object",
+            "properties": {
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/ingest.FieldError"
+                    }
+                },
+                "index": {
+                    "type": "integer"
+                },
+                "reading": {
+                    "$ref": "#/definitions/models.TimeToDry"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "controllers.StopSessionRequest": {
+            "type": "object",
+            "properties": {
+                "reason": {
+                    "type": "string"
+                }
+            }
+        },
+        "estimator.ETAInterval": {
+            "type": "object",
+            "properties": {
+                "earliest": {
+                    "type": "string"
+                },
+                "high_minutes": {
+                    "type": "number"
+                },
+                "latest": {
+                    "type": "string"
+                },
+                "level": {
+                    "type": "number"
+                },
+                "low_minutes": {
+                    "type": "number"
+                }
+            }
+        },
+        "estimator.SessionETA": {
+            "type": "object",
+            "properties": {
+                "confidence_interval": {
+                    "description": "ConfidenceInterval bounds the remaining time with 95% confidence.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/estimator.ETAInterval"
                         }
+                    ]
+                },
+                "elapsed_minutes": {
+                    "type": "number"
+                },
+                "model_minutes": {
+                    "type": "number"
+                },
+                "model_version": {
+                    "type": "integer"
+                },
+                "percent_dry": {
+                    "type": "number"
+                },
+                "predicted_completion": {
+                    "type": "string"
+                },
+                "readings": {
+                    "type": "integer"
+                },
+                "remaining_minutes": {
+                    "type": "number"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "test_id": {
+                    "type": "integer"
+                },
+                "trend_minutes": {
+                    "type": "number"
+                },
+                "trend_weight": {
+                    "type": "number"
+                }
+            }
+        },
+        "events.Event": {
+            "type": "object",
+            "properties": {
+                "data": {},
+                "device": {
+                    "description": "Device is the sensor the event came from, empty for events that are not device specific.",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "test_id": {
+                    "description": "TestID is the drying session of the event, 0 for events that are not session specific.",
+                    "type": "integer"
+                },
+                "time": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "importer.Report": {
+            "type": "object",
+            "properties": {
+                "dry_run": {
+                    "type": "boolean"
+                },
+                "errors": {
+                    "description": "Errors lists the first MaxReportedErrors invalid rows.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/importer.RowError"
+                    }
+                },
+                "format": {
+                    "type": "string"
+                },
+                "from": {
+                    "description": "From and To are the oldest and newest timestamps imported.",
+                    "type": "string"
+                },
+                "ignored_columns": {
+                    "description": "IgnoredColumns are source columns that match no field.",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
                     }
+                },
+                "imported": {
+                    "description": "Imported counts the rows inserted, or that would be in a dry run.",
+                    "type": "integer"
+                },
+                "invalid": {
+                    "type": "integer"
+                },
+                "rows": {
+                    "type": "integer"
+                },
+                "skipped": {
+                    "description": "Skipped counts duplicates of stored rows or of earlier rows in the file.",
+                    "type": "integer"
+                },
+                "table": {
+                    "type": "string"
+                },
+                "timestamp_format": {
+                    "description": "TimestampFormat is the layout detected for the file's timestamps.",
+                    "type": "string"
+                },
+                "to": {
+                    "type": "string"
                 }
             }
         },
-        "/api/ttd/latest/all": {
-            "get": {
-                "description": "Returns all time_to_dry rows that share the latest test_id.",
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "Test"
-                ],
-                "summary": "Get all rows of latest test_id",
-                "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/models.TimeToDry"
-                            }
-                        }
-                    }
+        "importer.RowError": {
+            "type": "object",
+            "properties": {
+                "line": {
+                    "description": "Line is the line of the file, counting a CSV header.",
+                    "type": "integer"
+                },
+                "message": {
+                    "type": "string"
                 }
             }
         },
-        "/api/ttd/latest/last": {
-            "get": {
-                "description": "Returns the latest time_to_dry row (by timestamp) for the latest test_id.",
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "Test"
-                ],
-                "summary": "Get the most recent row of latest test_id",
-                "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "$ref": "#/definitions/models.TimeToDry"
-                        }
-                    }
+        "ingest.FieldError": {
+            "type": "object",
+            "properties": {
+                "field": {
+                    "type": "string"
+                },
+                "message": {
+                    "type": "string"
                 }
             }
         },
-        "/api/ttd/status": {
-            "get": {
-                "description": "Returns whether the device is active (sending data within last 5 minutes).",
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "Device"
-                ],
-                "summary": "Check device status",
-                "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": true
-                        }
-                    }
+        "listing.Pagination": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "description": "Count is the number of rows on this page.",
+                    "type": "integer"
+                },
+                "has_more": {
+                    "type": "boolean"
+                },
+                "limit": {
+                    "type": "integer"
+                },
+                "next_cursor": {
+                    "description": "NextCursor is passed as cursor to fetch the next page.",
+                    "type": "string"
+                },
+                "order": {
+                    "type": "string"
+                },
+                "total": {
+                    "description": "Total is the number of rows matching the filters across all pages.",
+                    "type": "integer"
                 }
             }
         },
-        "/api/ttd/status/check": {
-            "get": {
-                "description": "Returns whether a given test_id is still collecting data or completed.",
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "Test"
-                ],
-                "summary": "Check specific test status",
-                "parameters": [
-                    {
-                        "type": "integer",
-                        "description": "Test ID to check",
-                        "name": "test_id",
-                        "in": "query",
-                        "required": true
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": true
-                        }
-                    },
-                    "400": {
-                        "description": "Missing or invalid test_id",
-                        "schema": {
-                            "type": "string"
-                        }
-                    },
-                    "404": {
-                        "description": "No records found",
-                        "schema": {
-                            "type": "string"
-                        }
-                    }
+        "listing.Response": {
+            "type": "object",
+            "properties": {
+                "data": {},
+                "pagination": {
+                    "$ref": "#/definitions/listing.Pagination"
                 }
             }
-        }
-    },
-    "definitions": {
+        },
+        "models.Alert": {
+            "type": "object",
+            "properties": {
+                "acknowledged_at": {
+                    "description": "AcknowledgedAt is when a user confirmed seeing the alert, nil until then.",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "kind": {
+                    "type": "string"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "rain_starts_at": {
+                    "type": "string"
+                },
+                "sent_at": {
+                    "type": "string"
+                }
+            }
+        },
         "models.CombinedData": {
             "type": "object",
             "properties": {
@@ -410,6 +1959,81 @@ const docTemplate = `{
                 },
                 "timestamp": {
                     "type": "string"
+                },
+                "weather_gap_seconds": {
+                    "description": "WeatherGapSeconds is how far from the reading the farthest observation used is.",
+                    "type": "integer"
+                },
+                "weather_timestamp": {
+                    "description": "WeatherTimestamp is the tmd observation the weather fields come from,\nthe nearer one when they were interpolated. It is nil on rows\ncombined before it was recorded.",
+                    "type": "string"
+                }
+            }
+        },
+        "models.DryTimeModel": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "type": "boolean"
+                },
+                "coef_diff_hum": {
+                    "type": "number"
+                },
+                "coef_diff_temp": {
+                    "type": "number"
+                },
+                "coef_light": {
+                    "type": "number"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "intercept": {
+                    "type": "number"
+                },
+                "r2": {
+                    "type": "number"
+                },
+                "rmse": {
+                    "type": "number"
+                },
+                "samples": {
+                    "type": "integer"
+                },
+                "trained_at": {
+                    "type": "string"
+                },
+                "version": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.DryingSession": {
+            "type": "object",
+            "properties": {
+                "end_reason": {
+                    "type": "string"
+                },
+                "ended_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "last_reading_at": {
+                    "type": "string"
+                },
+                "origin": {
+                    "type": "string"
+                },
+                "started_at": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "test_id": {
+                    "type": "integer"
                 }
             }
         },
@@ -473,6 +2097,104 @@ const docTemplate = `{
                     "type": "string"
                 }
             }
+        },
+        "retention.Metrics": {
+            "type": "object",
+            "properties": {
+                "failures": {
+                    "type": "integer"
+                },
+                "last_error": {
+                    "type": "string"
+                },
+                "last_run": {
+                    "$ref": "#/definitions/retention.Report"
+                },
+                "rollups_pruned": {
+                    "type": "integer"
+                },
+                "rollups_written": {
+                    "type": "integer"
+                },
+                "rows_removed": {
+                    "description": "RowsRemoved counts the rows deleted by table.",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer"
+                    }
+                },
+                "runs": {
+                    "type": "integer"
+                }
+            }
+        },
+        "retention.Report": {
+            "type": "object",
+            "properties": {
+                "dry_run": {
+                    "type": "boolean"
+                },
+                "duration_ms": {
+                    "type": "integer"
+                },
+                "pruned_rollups": {
+                    "description": "PrunedRollups is the number of per-minute rollups deleted.",
+                    "type": "integer"
+                },
+                "started_at": {
+                    "type": "string"
+                },
+                "tables": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/retention.TableReport"
+                    }
+                }
+            }
+        },
+        "retention.TableReport": {
+            "type": "object",
+            "properties": {
+                "cutoff": {
+                    "description": "Cutoff is the start of the hour before which rows are removed; it is\nzero when the table is kept whole.",
+                    "type": "string"
+                },
+                "hour_rollups": {
+                    "type": "integer"
+                },
+                "keep_days": {
+                    "type": "integer"
+                },
+                "minute_rollups": {
+                    "description": "MinuteRollups and HourRollups count the rollups written, merged with\nexisting ones where they cover the same bucket.",
+                    "type": "integer"
+                },
+                "removed": {
+                    "description": "Removed is the number of rows deleted.",
+                    "type": "integer"
+                },
+                "table": {
+                    "type": "string"
+                }
+            }
+        },
+        "ws.ServerMessage": {
+            "type": "object",
+            "properties": {
+                "data": {},
+                "error": {
+                    "type": "string"
+                },
+                "event": {
+                    "$ref": "#/definitions/events.Event"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
         }
     }
 }`
@@ -0,0 +1,185 @@
+package estimator
+
+import (
+	"errors"
+	"math"
+	"time"
+)
+
+// DefaultRMSE is the model error assumed, in minutes, when the active model
+// has not been evaluated (the builtin formula).
+const DefaultRMSE = 45.0
+
+// TrendWindow is how far back readings are used to measure the current drying rate.
+const TrendWindow = 30 * time.Minute
+
+// ErrNoReadings is returned by PredictETA for a session without readings.
+var ErrNoReadings = errors.New("session has no readings yet")
+
+// Point is one reading of an in-progress session.
+type Point struct {
+	At       time.Time
+	DiffTemp float64
+	DiffHum  float64
+	Light    float64
+}
+
+// ETAInput is what PredictETA needs to know about a session.
+type ETAInput struct {
+	// Readings of the session, oldest first.
+	Readings []Point
+	Started  time.Time
+	Now      time.Time
+	Model    Coefficients
+	// ModelRMSE is the model's error in minutes; DefaultRMSE is used when it is 0.
+	ModelRMSE float64
+	// DryDiffHum is the humidity difference at which laundry counts as dry.
+	DryDiffHum float64
+}
+
+// ETA is the predicted end of an in-progress drying session. All durations
+// are in minutes.
+type ETA struct {
+	ElapsedMinutes   float64
+	RemainingMinutes float64
+	// LowMinutes and HighMinutes bound RemainingMinutes with 95% confidence.
+	LowMinutes  float64
+	HighMinutes float64
+	CompletesAt time.Time
+	PercentDry  float64
+	// ModelMinutes is the total drying time predicted by the model from the
+	// average conditions so far.
+	ModelMinutes float64
+	// TrendMinutes is the remaining time extrapolated from how fast the
+	// humidity difference is currently falling, if it is falling.
+	TrendMinutes *float64
+	// TrendWeight is the share of TrendMinutes in RemainingMinutes.
+	TrendWeight float64
+}
+
+// PredictETA blends two estimates of the remaining drying time: the model's
+// prediction from the average conditions so far, and the extrapolated
+// humidity trend of the last TrendWindow, each weighted by its precision.
+// The trend gets more precise as readings arrive and the laundry nears the
+// dry level, so the confidence interval narrows as the session progresses.
+func PredictETA(in ETAInput) (ETA, error) {
+	n := len(in.Readings)
+	if n == 0 {
+		return ETA{}, ErrNoReadings
+	}
+
+	var mean Point
+	for _, p := range in.Readings {
+		mean.DiffTemp += p.DiffTemp / float64(n)
+		mean.DiffHum += p.DiffHum / float64(n)
+		mean.Light += p.Light / float64(n)
+	}
+
+	eta := ETA{
+		ElapsedMinutes: math.Max(0, in.Now.Sub(in.Started).Minutes()),
+		ModelMinutes:   in.Model.Estimate(mean.DiffTemp, mean.DiffHum, mean.Light),
+	}
+	modelRemaining := math.Max(0, eta.ModelMinutes-eta.ElapsedMinutes)
+
+	progress := humidityProgress(in.Readings, in.DryDiffHum)
+	if math.IsNaN(progress) {
+		progress = math.Min(1, eta.ElapsedMinutes/eta.ModelMinutes)
+	}
+	eta.PercentDry = math.Round(progress * 100)
+
+	modelSigma := in.ModelRMSE
+	if modelSigma <= 0 {
+		modelSigma = DefaultRMSE
+	}
+
+	remaining := modelRemaining
+	sigma := modelSigma * math.Max(0.1, 1-progress)
+	if trend, trendSigma, ok := humidityTrend(in.Readings, in.DryDiffHum, in.Now); ok {
+		// Drying slows down towards the end, so a straight line is only an
+		// approximation; allow for that on top of the fit's own error.
+		trendSigma = math.Hypot(trendSigma, 0.25*trend*(1-progress))
+
+		// Weigh both by their precision, and widen the interval by how much
+		// they disagree.
+		w := modelSigma * modelSigma / (modelSigma*modelSigma + trendSigma*trendSigma)
+		remaining = (1-w)*modelRemaining + w*trend
+		disagreement := trend - modelRemaining
+		sigma = math.Sqrt((1-w)*modelSigma*modelSigma + w*trendSigma*trendSigma + w*(1-w)*disagreement*disagreement)
+		eta.TrendMinutes = &trend
+		eta.TrendWeight = w
+	}
+
+	eta.RemainingMinutes = math.Round(remaining)
+	eta.LowMinutes = math.Round(math.Max(0, remaining-1.96*sigma))
+	eta.HighMinutes = math.Round(remaining + 1.96*sigma)
+	eta.CompletesAt = in.Now.Add(time.Duration(remaining * float64(time.Minute))).Truncate(time.Second)
+	return eta, nil
+}
+
+// humidityProgress is how far DiffHum has come down from its first reading
+// to the dry level, between 0 and 1. It is NaN when the session started
+// too close to the dry level to tell.
+func humidityProgress(readings []Point, dryDiffHum float64) float64 {
+	start := readings[0].DiffHum
+	if start-dryDiffHum < 1 {
+		return math.NaN()
+	}
+	// Average the last few readings so one noisy value does not jump the progress.
+	last := readings[max(0, len(readings)-3):]
+	var current float64
+	for _, p := range last {
+		current += p.DiffHum / float64(len(last))
+	}
+	return math.Max(0, math.Min(1, (start-current)/(start-dryDiffHum)))
+}
+
+// humidityTrend fits a line to DiffHum over the last TrendWindow and returns
+// the minutes from now until it reaches dryDiffHum, with the standard error
+// of that time. ok is false when there are too few readings or DiffHum is
+// not falling.
+func humidityTrend(readings []Point, dryDiffHum float64, now time.Time) (minutes, sigma float64, ok bool) {
+	last := readings[len(readings)-1]
+	var window []Point
+	for _, p := range readings {
+		if last.At.Sub(p.At) <= TrendWindow {
+			window = append(window, p)
+		}
+	}
+	n := float64(len(window))
+	if n < 3 {
+		return 0, 0, false
+	}
+
+	var meanX, meanY float64
+	for _, p := range window {
+		meanX += p.At.Sub(last.At).Minutes() / n
+		meanY += p.DiffHum / n
+	}
+	var sxx, sxy float64
+	for _, p := range window {
+		dx := p.At.Sub(last.At).Minutes() - meanX
+		sxx += dx * dx
+		sxy += dx * (p.DiffHum - meanY)
+	}
+	if sxx == 0 {
+		return 0, 0, false
+	}
+	slope := sxy / sxx
+	if slope >= 0 {
+		return 0, 0, false
+	}
+
+	var sse float64
+	for _, p := range window {
+		fit := meanY + slope*(p.At.Sub(last.At).Minutes()-meanX)
+		sse += (p.DiffHum - fit) * (p.DiffHum - fit)
+	}
+	slopeSE := math.Sqrt(sse/(n-2)) / math.Sqrt(sxx)
+
+	// Time from the last reading until the fitted line reaches the dry level.
+	current := meanY + slope*(0-meanX)
+	fromLast := math.Max(0, (current-dryDiffHum)/-slope)
+	minutes = math.Max(0, fromLast-now.Sub(last.At).Minutes())
+	sigma = fromLast * slopeSE / -slope
+	return minutes, sigma, true
+}
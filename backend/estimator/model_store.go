@@ -0,0 +1,197 @@
+package estimator
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"backend/database"
+	"backend/models"
+
+	"gorm.io/gorm"
+)
+
+// ErrModelNotFound is returned when a model version does not exist.
+var ErrModelNotFound = errors.New("model version not found")
+
+// BuiltinModel is version 0: the hand-tuned coefficients, used until a model has been trained.
+func BuiltinModel() *models.DryTimeModel {
+	return &models.DryTimeModel{
+		Version:      0,
+		Intercept:    DefaultCoefficients.Intercept,
+		CoefDiffTemp: DefaultCoefficients.DiffTemp,
+		CoefDiffHum:  DefaultCoefficients.DiffHum,
+		CoefLight:    DefaultCoefficients.Light,
+		Active:       true,
+	}
+}
+
+// CoefficientsOf returns the coefficients stored on a model row.
+func CoefficientsOf(m *models.DryTimeModel) Coefficients {
+	return Coefficients{
+		Intercept: m.Intercept,
+		DiffTemp:  m.CoefDiffTemp,
+		DiffHum:   m.CoefDiffHum,
+		Light:     m.CoefLight,
+	}
+}
+
+// GormModels reads the models of the dry_time_models table of DB.
+type GormModels struct {
+	DB *gorm.DB
+}
+
+// Active returns the active trained model, or BuiltinModel when none is active.
+func (s GormModels) Active() (*models.DryTimeModel, error) {
+	var m models.DryTimeModel
+	err := s.DB.Where("active = ?", true).Order("version desc").First(&m).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return BuiltinModel(), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// ActiveModel returns the active model of database.DB.
+func ActiveModel() (*models.DryTimeModel, error) {
+	return GormModels{DB: database.DB}.Active()
+}
+
+// ListModels returns all trained models, newest first.
+func ListModels() ([]models.DryTimeModel, error) {
+	list := []models.DryTimeModel{}
+	err := database.DB.Order("version desc").Find(&list).Error
+	return list, err
+}
+
+// Activate makes the given version the active model. Version 0 reverts to the builtin coefficients.
+func Activate(version int) (*models.DryTimeModel, error) {
+	if version == 0 {
+		err := database.DB.Model(&models.DryTimeModel{}).Where("active = ?", true).Update("active", false).Error
+		return BuiltinModel(), err
+	}
+
+	var m models.DryTimeModel
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("version = ?", version).First(&m).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrModelNotFound
+			}
+			return err
+		}
+		if err := tx.Model(&models.DryTimeModel{}).Where("active = ?", true).Update("active", false).Error; err != nil {
+			return err
+		}
+		m.Active = true
+		return tx.Save(&m).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// Train fits a new model from the sessions detected dry and stores it as
+// the next version. The new version becomes active when activate is true.
+func Train(activate bool) (*models.DryTimeModel, error) {
+	samples, err := LoadTrainingSamples()
+	if err != nil {
+		return nil, err
+	}
+	c, st, err := Fit(samples)
+	if err != nil {
+		return nil, err
+	}
+
+	m := models.DryTimeModel{
+		Intercept:    c.Intercept,
+		CoefDiffTemp: c.DiffTemp,
+		CoefDiffHum:  c.DiffHum,
+		CoefLight:    c.Light,
+		Samples:      st.Samples,
+		RMSE:         st.RMSE,
+		R2:           st.R2,
+		Active:       activate,
+		TrainedAt:    time.Now(),
+	}
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.DryTimeModel{}).Select("COALESCE(MAX(version), 0) + 1").Scan(&m.Version).Error; err != nil {
+			return err
+		}
+		if activate {
+			if err := tx.Model(&models.DryTimeModel{}).Where("active = ?", true).Update("active", false).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Create(&m).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("Trained drying time model v%d on %d sessions (RMSE %.1f min, R² %.2f)", m.Version, m.Samples, m.RMSE, m.R2)
+	return &m, nil
+}
+
+// LoadTrainingSamples builds one sample per drying session that ended because
+// the laundry was detected dry: the mean temperature and humidity
+// differences over the session from combined_data, the mean light level
+// from time_to_dry, and the minutes from the start to the end of the
+// session as the target. Sessions stopped by hand, by rain or by timeout
+// say nothing about how long the laundry took to dry.
+func LoadTrainingSamples() ([]Sample, error) {
+	type sessionRow struct {
+		TestID    int
+		DiffTemp  float64
+		DiffHum   float64
+		StartedAt database.Time
+		EndedAt   database.Time
+	}
+	var rows []sessionRow
+	err := database.DB.Model(&models.CombinedData{}).
+		Select("combined_data.test_id, AVG(combined_data.diff_temp) AS diff_temp, AVG(combined_data.diff_hum) AS diff_hum, drying_sessions.started_at, drying_sessions.ended_at").
+		Joins("JOIN drying_sessions ON drying_sessions.test_id = combined_data.test_id").
+		Where("drying_sessions.status = ? AND drying_sessions.end_reason = ?", models.SessionCompleted, models.EndReasonDryDetected).
+		Group("combined_data.test_id, drying_sessions.started_at, drying_sessions.ended_at").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	type lightRow struct {
+		TestID int
+		Light  float64
+	}
+	var lights []lightRow
+	err = database.DB.Model(&models.TimeToDry{}).
+		Select("test_id, AVG(light) AS light").
+		Group("test_id").
+		Scan(&lights).Error
+	if err != nil {
+		return nil, err
+	}
+	lightByTest := make(map[int]float64, len(lights))
+	for _, l := range lights {
+		lightByTest[l.TestID] = l.Light
+	}
+
+	samples := make([]Sample, 0, len(rows))
+	for _, r := range rows {
+		if r.StartedAt.IsZero() || r.EndedAt.IsZero() {
+			continue
+		}
+		minutes := r.EndedAt.Sub(r.StartedAt.Time).Minutes()
+		if minutes < MinimumDryTime {
+			continue
+		}
+		samples = append(samples, Sample{
+			TestID:   r.TestID,
+			DiffTemp: r.DiffTemp,
+			DiffHum:  r.DiffHum,
+			Light:    lightByTest[r.TestID],
+			Minutes:  minutes,
+		})
+	}
+	return samples, nil
+}
@@ -0,0 +1,197 @@
+package estimator
+
+import (
+	"errors"
+	"math"
+)
+
+// MinimumDryTime is the shortest estimate ever returned, in minutes.
+const MinimumDryTime = 10.0
+
+// MinSamples is the number of completed sessions needed before fitting.
+const MinSamples = 5
+
+// ErrNotEnoughSamples is returned by Fit when there are fewer than MinSamples samples.
+var ErrNotEnoughSamples = errors.New("not enough completed sessions to train a model")
+
+// ErrSingular is returned by Fit when the samples cannot determine the coefficients.
+var ErrSingular = errors.New("training data is degenerate, coefficients cannot be determined")
+
+// Coefficients of the linear drying time model, in minutes.
+type Coefficients struct {
+	Intercept float64 `json:"intercept"`
+	DiffTemp  float64 `json:"diff_temp"`
+	DiffHum   float64 `json:"diff_hum"`
+	Light     float64 `json:"light"`
+}
+
+// DefaultCoefficients reproduce the original hand-tuned formula
+// (180 - 5*diff_temp - 1.5*diff_hum - 0.0015*light) * 3.5.
+var DefaultCoefficients = Coefficients{
+	Intercept: 180 * 3.5,
+	DiffTemp:  -5.0 * 3.5,
+	DiffHum:   -1.5 * 3.5,
+	Light:     -0.0015 * 3.5,
+}
+
+// Estimate returns the drying time in minutes, never less than MinimumDryTime.
+func (c Coefficients) Estimate(diffTemp, diffHum, light float64) float64 {
+	minutes := c.predict(diffTemp, diffHum, light)
+	if minutes < MinimumDryTime {
+		return MinimumDryTime
+	}
+	return minutes
+}
+
+func (c Coefficients) predict(diffTemp, diffHum, light float64) float64 {
+	return c.Intercept + c.DiffTemp*diffTemp + c.DiffHum*diffHum + c.Light*light
+}
+
+// Sample is one completed drying session used for training.
+type Sample struct {
+	TestID   int
+	DiffTemp float64
+	DiffHum  float64
+	Light    float64
+	Minutes  float64
+}
+
+// FitStats describes how well fitted coefficients explain the samples.
+type FitStats struct {
+	Samples int     `json:"samples"`
+	RMSE    float64 `json:"rmse"`
+	R2      float64 `json:"r2"`
+}
+
+// Fit finds the coefficients minimising the squared error on samples
+// (ordinary least squares, solved through the normal equations).
+func Fit(samples []Sample) (Coefficients, FitStats, error) {
+	if len(samples) < MinSamples {
+		return Coefficients{}, FitStats{}, ErrNotEnoughSamples
+	}
+
+	// Light is in lux and dwarfs the other features, so features are
+	// standardised before solving to keep the system well conditioned.
+	mean, scale := featureScaling(samples)
+
+	var ata [4][4]float64
+	var aty [4]float64
+	for _, s := range samples {
+		row := scaledRow(s, mean, scale)
+		for i := range row {
+			for j := range row {
+				ata[i][j] += row[i] * row[j]
+			}
+			aty[i] += row[i] * s.Minutes
+		}
+	}
+
+	// A feature that never varies cannot be fitted; pin its coefficient to zero.
+	for i, sc := range scale {
+		if sc == 0 {
+			ata[i+1][i+1] = 1
+		}
+	}
+
+	beta, ok := solve(ata, aty)
+	if !ok {
+		return Coefficients{}, FitStats{}, ErrSingular
+	}
+
+	// Undo the scaling so the coefficients apply to raw readings.
+	var coef [3]float64
+	for i, sc := range scale {
+		if sc > 0 {
+			coef[i] = beta[i+1] / sc
+		}
+	}
+	c := Coefficients{DiffTemp: coef[0], DiffHum: coef[1], Light: coef[2]}
+	c.Intercept = beta[0] - c.DiffTemp*mean[0] - c.DiffHum*mean[1] - c.Light*mean[2]
+
+	return c, stats(c, samples), nil
+}
+
+func featureScaling(samples []Sample) (mean, scale [3]float64) {
+	n := float64(len(samples))
+	for _, s := range samples {
+		mean[0] += s.DiffTemp / n
+		mean[1] += s.DiffHum / n
+		mean[2] += s.Light / n
+	}
+	for _, s := range samples {
+		scale[0] += (s.DiffTemp - mean[0]) * (s.DiffTemp - mean[0]) / n
+		scale[1] += (s.DiffHum - mean[1]) * (s.DiffHum - mean[1]) / n
+		scale[2] += (s.Light - mean[2]) * (s.Light - mean[2]) / n
+	}
+	for i := range scale {
+		scale[i] = math.Sqrt(scale[i])
+	}
+	return mean, scale
+}
+
+func scaledRow(s Sample, mean, scale [3]float64) [4]float64 {
+	raw := [3]float64{s.DiffTemp, s.DiffHum, s.Light}
+	row := [4]float64{1}
+	for i, v := range raw {
+		if scale[i] > 0 {
+			row[i+1] = (v - mean[i]) / scale[i]
+		}
+	}
+	return row
+}
+
+// solve runs Gaussian elimination with partial pivoting on a 4x4 system.
+func solve(a [4][4]float64, b [4]float64) ([4]float64, bool) {
+	const n = 4
+	for col := 0; col < n; col++ {
+		pivot := col
+		for row := col + 1; row < n; row++ {
+			if math.Abs(a[row][col]) > math.Abs(a[pivot][col]) {
+				pivot = row
+			}
+		}
+		if math.Abs(a[pivot][col]) < 1e-9 || math.IsNaN(a[pivot][col]) {
+			return [4]float64{}, false
+		}
+		a[col], a[pivot] = a[pivot], a[col]
+		b[col], b[pivot] = b[pivot], b[col]
+
+		for row := col + 1; row < n; row++ {
+			f := a[row][col] / a[col][col]
+			for k := col; k < n; k++ {
+				a[row][k] -= f * a[col][k]
+			}
+			b[row] -= f * b[col]
+		}
+	}
+
+	var x [4]float64
+	for row := n - 1; row >= 0; row-- {
+		sum := b[row]
+		for k := row + 1; k < n; k++ {
+			sum -= a[row][k] * x[k]
+		}
+		x[row] = sum / a[row][row]
+	}
+	return x, true
+}
+
+func stats(c Coefficients, samples []Sample) FitStats {
+	var mean float64
+	for _, s := range samples {
+		mean += s.Minutes / float64(len(samples))
+	}
+
+	var ssRes, ssTot float64
+	for _, s := range samples {
+		residual := s.Minutes - c.predict(s.DiffTemp, s.DiffHum, s.Light)
+		ssRes += residual * residual
+		ssTot += (s.Minutes - mean) * (s.Minutes - mean)
+	}
+
+	st := FitStats{Samples: len(samples), RMSE: math.Sqrt(ssRes / float64(len(samples)))}
+	if ssTot > 0 {
+		st.R2 = 1 - ssRes/ssTot
+	}
+	return st
+}
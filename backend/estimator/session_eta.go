@@ -0,0 +1,191 @@
+package estimator
+
+import (
+	"context"
+	"errors"
+	"log"
+	"math"
+	"time"
+
+	"backend/database"
+	"backend/detector"
+	"backend/events"
+	"backend/models"
+)
+
+// SessionETA is the predicted end of a drying session.
+type SessionETA struct {
+	TestID       int    `json:"test_id"`
+	Status       string `json:"status"`
+	ModelVersion int    `json:"model_version"`
+	Readings     int    `json:"readings"`
+
+	ElapsedMinutes      float64   `json:"elapsed_minutes"`
+	RemainingMinutes    float64   `json:"remaining_minutes"`
+	PredictedCompletion time.Time `json:"predicted_completion"`
+	PercentDry          float64   `json:"percent_dry"`
+	// ConfidenceInterval bounds the remaining time with 95% confidence.
+	ConfidenceInterval ETAInterval `json:"confidence_interval"`
+
+	ModelMinutes float64  `json:"model_minutes"`
+	TrendMinutes *float64 `json:"trend_minutes"`
+	TrendWeight  float64  `json:"trend_weight"`
+}
+
+// ETAInterval is a range of remaining minutes and the matching completion
+// times, in UTC.
+type ETAInterval struct {
+	Level       float64   `json:"level"`
+	LowMinutes  float64   `json:"low_minutes"`
+	HighMinutes float64   `json:"high_minutes"`
+	Earliest    time.Time `json:"earliest"`
+	Latest      time.Time `json:"latest"`
+}
+
+// ForSession predicts the end of session s from its readings, oldest first.
+// A session that is no longer running reports its actual end.
+func ForSession(s *models.DryingSession, rows []models.TimeToDry, model *models.DryTimeModel, dryDiffHum float64, now time.Time) (*SessionETA, error) {
+	if s.Status != models.SessionActive {
+		return completedETA(s, len(rows)), nil
+	}
+	points := make([]Point, 0, len(rows))
+	for _, row := range rows {
+		points = append(points, Point{At: row.Timestamp, DiffTemp: row.DiffTemp, DiffHum: row.DiffHum, Light: row.Light})
+	}
+
+	eta, err := PredictETA(ETAInput{
+		Readings:   points,
+		Started:    s.StartedAt,
+		Now:        now,
+		Model:      CoefficientsOf(model),
+		ModelRMSE:  model.RMSE,
+		DryDiffHum: dryDiffHum,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &SessionETA{
+		TestID:              s.TestID,
+		Status:              s.Status,
+		ModelVersion:        model.Version,
+		Readings:            len(points),
+		ElapsedMinutes:      math.Round(eta.ElapsedMinutes),
+		RemainingMinutes:    eta.RemainingMinutes,
+		PredictedCompletion: models.StoredTime(eta.CompletesAt),
+		PercentDry:          eta.PercentDry,
+		ConfidenceInterval: ETAInterval{
+			Level:       0.95,
+			LowMinutes:  eta.LowMinutes,
+			HighMinutes: eta.HighMinutes,
+			Earliest:    models.StoredTime(now.Add(time.Duration(eta.LowMinutes) * time.Minute)),
+			Latest:      models.StoredTime(now.Add(time.Duration(eta.HighMinutes) * time.Minute)),
+		},
+		ModelMinutes: math.Round(eta.ModelMinutes),
+		TrendMinutes: eta.TrendMinutes,
+		TrendWeight:  math.Round(eta.TrendWeight*100) / 100,
+	}, nil
+}
+
+// completedETA reports the actual end of a session that is no longer running.
+func completedETA(s *models.DryingSession, readings int) *SessionETA {
+	ended := s.LastReadingAt
+	if s.EndedAt != nil {
+		ended = *s.EndedAt
+	}
+	var elapsed float64
+	if !s.StartedAt.IsZero() && !ended.IsZero() {
+		elapsed = math.Round(ended.Sub(s.StartedAt).Minutes())
+	}
+	at := models.StoredTime(ended)
+	return &SessionETA{
+		TestID:              s.TestID,
+		Status:              s.Status,
+		Readings:            readings,
+		ElapsedMinutes:      elapsed,
+		PredictedCompletion: at,
+		PercentDry:          100,
+		ConfidenceInterval:  ETAInterval{Level: 0.95, Earliest: at, Latest: at},
+	}
+}
+
+// LoadSessionETA predicts the end of s from its readings in the database
+// and the active model.
+func LoadSessionETA(s *models.DryingSession, now time.Time) (*SessionETA, error) {
+	var rows []models.TimeToDry
+	if err := database.DB.Where("test_id = ?", s.TestID).Order("timestamp").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	model, err := ActiveModel()
+	if err != nil {
+		return nil, err
+	}
+	return ForSession(s, rows, model, detector.ConfigFromEnv().MaxDiffHum, now)
+}
+
+// ETAPublishInterval is how often the ETA of a session is published at
+// most. Each ETA reads every reading of the session, so publishing one per
+// reading would cost more the longer the session runs.
+const ETAPublishInterval = time.Minute
+
+// PublishETAs publishes an ETA event for the running session after its
+// readings, at most once per ETAPublishInterval, until ctx is cancelled.
+func PublishETAs(ctx context.Context, bus *events.Bus, active func() (*models.DryingSession, error)) {
+	var last published
+	for {
+		sub, _, _ := bus.Subscribe(events.Filter{Types: []string{events.TypeReading}}, 0)
+		if !consumeReadings(ctx, bus, sub, active, &last) {
+			return
+		}
+		// The subscription was dropped for falling behind. Only the latest
+		// ETA matters, so resubscribe without catching up.
+	}
+}
+
+// published is the session whose ETA was published last, and when.
+type published struct {
+	testID int
+	at     time.Time
+}
+
+// consumeReadings publishes ETAs until ctx is cancelled (false) or the
+// subscription is dropped (true).
+func consumeReadings(ctx context.Context, bus *events.Bus, sub *events.Subscription, active func() (*models.DryingSession, error), last *published) bool {
+	for {
+		select {
+		case <-ctx.Done():
+			bus.Unsubscribe(sub)
+			return false
+		case e, ok := <-sub.C:
+			if !ok {
+				return true
+			}
+			now := time.Now()
+			if e.TestID == last.testID && now.Sub(last.at) < ETAPublishInterval {
+				continue
+			}
+			if publishETA(bus, e, active, now) {
+				*last = published{testID: e.TestID, at: now}
+			}
+		}
+	}
+}
+
+// publishETA publishes the ETA of the running session if the reading is
+// one of its own, and reports whether it did.
+func publishETA(bus *events.Bus, reading events.Event, active func() (*models.DryingSession, error), now time.Time) bool {
+	s, err := active()
+	if err != nil || s == nil || s.TestID != reading.TestID {
+		return false
+	}
+	eta, err := LoadSessionETA(s, now)
+	if errors.Is(err, ErrNoReadings) {
+		return false
+	}
+	if err != nil {
+		log.Printf("Failed to update ETA of session %d: %v", s.TestID, err)
+		return false
+	}
+	bus.Publish(events.TypeETA, reading.Device, s.TestID, eta)
+	return true
+}
@@ -0,0 +1,173 @@
+package events
+
+import (
+	"slices"
+	"sync"
+	"time"
+)
+
+// Event types.
+const (
+	// TypeReading carries a stored models.TimeToDry.
+	TypeReading = "reading"
+	// TypeSession carries a models.DryingSession that started or ended.
+	TypeSession = "session"
+	// TypeETA carries the updated prediction of a running session.
+	TypeETA = "eta"
+	// TypeAlert carries a sent models.Alert.
+	TypeAlert = "alert"
+)
+
+// Event is something that happened, as delivered to stream clients.
+type Event struct {
+	ID   uint64 `json:"id"`
+	Type string `json:"type"`
+	// Device is the sensor the event came from, empty for events that are not device specific.
+	Device string `json:"device,omitempty"`
+	// TestID is the drying session of the event, 0 for events that are not session specific.
+	TestID int       `json:"test_id,omitempty"`
+	Time   time.Time `json:"time"`
+	Data   any       `json:"data"`
+}
+
+// Filter selects events for a subscriber. Zero fields match everything.
+// Events without a device or session, such as alerts, pass the Device and
+// TestID filters.
+type Filter struct {
+	Types  []string
+	Device string
+	TestID int
+}
+
+// Match reports whether e passes the filter.
+func (f Filter) Match(e Event) bool {
+	if len(f.Types) > 0 && !slices.Contains(f.Types, e.Type) {
+		return false
+	}
+	if f.Device != "" && e.Device != "" && e.Device != f.Device {
+		return false
+	}
+	if f.TestID != 0 && e.TestID != 0 && e.TestID != f.TestID {
+		return false
+	}
+	return true
+}
+
+// DefaultCapacity is the number of events kept for resuming streams.
+const DefaultCapacity = 1024
+
+// subscriberBuffer is how many events may queue for a slow subscriber
+// before it is dropped.
+const subscriberBuffer = 64
+
+// Bus fans events out to subscribers and keeps the most recent ones in a
+// ring buffer, so a client that reconnects can resume where it left off.
+type Bus struct {
+	mu     sync.Mutex
+	ring   []Event
+	start  int
+	nextID uint64
+	subs   map[*Subscription]struct{}
+	now    func() time.Time
+}
+
+// NewBus returns a Bus that keeps the last capacity events.
+func NewBus(capacity int) *Bus {
+	return &Bus{
+		ring:   make([]Event, 0, capacity),
+		nextID: 1,
+		subs:   map[*Subscription]struct{}{},
+		now:    time.Now,
+	}
+}
+
+// Default is the bus shared by ingestion, sessions, alerts and the stream endpoints.
+var Default = NewBus(DefaultCapacity)
+
+// Subscription receives the events matching its filter on C. C is closed
+// when the subscription ends, either by Unsubscribe or because the
+// subscriber fell too far behind; it can then resubscribe from the last
+// event it saw.
+type Subscription struct {
+	C      <-chan Event
+	c      chan Event
+	filter Filter
+}
+
+// Publish stamps an event with the next ID and the current time, stores it
+// and delivers it to matching subscribers.
+func (b *Bus) Publish(typ, device string, testID int, data any) Event {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	e := Event{ID: b.nextID, Type: typ, Device: device, TestID: testID, Time: b.now(), Data: data}
+	b.nextID++
+	if len(b.ring) < cap(b.ring) {
+		b.ring = append(b.ring, e)
+	} else if cap(b.ring) > 0 {
+		b.ring[b.start] = e
+		b.start = (b.start + 1) % cap(b.ring)
+	}
+
+	for s := range b.subs {
+		if !s.filter.Match(e) {
+			continue
+		}
+		select {
+		case s.c <- e:
+		default:
+			b.dropLocked(s)
+		}
+	}
+	return e
+}
+
+// Subscribe starts a subscription. With afterID > 0 the stored events after
+// that ID that match the filter are returned as backlog, oldest first; complete
+// is false when some of them are no longer stored, so the subscriber may
+// have missed events.
+func (b *Bus) Subscribe(f Filter, afterID uint64) (s *Subscription, backlog []Event, complete bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	complete = true
+	if afterID > 0 {
+		oldest := b.nextID
+		if len(b.ring) > 0 {
+			oldest = b.ring[b.start].ID
+		}
+		complete = afterID+1 >= oldest && afterID < b.nextID
+		for i := range b.ring {
+			e := b.ring[(b.start+i)%len(b.ring)]
+			if e.ID > afterID && f.Match(e) {
+				backlog = append(backlog, e)
+			}
+		}
+	}
+
+	c := make(chan Event, subscriberBuffer)
+	s = &Subscription{C: c, c: c, filter: f}
+	b.subs[s] = struct{}{}
+	return s, backlog, complete
+}
+
+// Unsubscribe ends s and closes its channel. It is safe to call more than once.
+func (b *Bus) Unsubscribe(s *Subscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.dropLocked(s)
+}
+
+// LastID returns the ID of the most recent event, 0 if there was none.
+func (b *Bus) LastID() uint64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.nextID - 1
+}
+
+func (b *Bus) dropLocked(s *Subscription) {
+	if _, ok := b.subs[s]; ok {
+		delete(b.subs, s)
+		close(s.c)
+	}
+}
@@ -0,0 +1,153 @@
+// Package export writes rows as CSV, NDJSON or Parquet one at a time, so
+// large tables can be streamed without holding them in memory.
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+// Formats.
+const (
+	CSV     = "csv"
+	NDJSON  = "ndjson"
+	Parquet = "parquet"
+)
+
+// Formats lists the supported formats.
+var Formats = []string{CSV, NDJSON, Parquet}
+
+// ContentType returns the MIME type of a format.
+func ContentType(format string) string {
+	switch format {
+	case CSV:
+		return "text/csv; charset=utf-8"
+	case NDJSON:
+		return "application/x-ndjson"
+	default:
+		return "application/vnd.apache.parquet"
+	}
+}
+
+// parquetRowGroup bounds how many rows the Parquet writer buffers before
+// writing them out as a row group.
+const parquetRowGroup = 10000
+
+// Writer writes rows of T in one format. Close must be called after the
+// last row to complete the output.
+type Writer[T any] interface {
+	Write(row T) error
+	Close() error
+}
+
+// NewWriter returns a Writer of format to w. Columns are named after the
+// JSON names of T's fields, and Parquet columns after their parquet tags.
+func NewWriter[T any](w io.Writer, format string) (Writer[T], error) {
+	switch format {
+	case CSV:
+		return newCSVWriter[T](w), nil
+	case NDJSON:
+		return ndjsonWriter[T]{json.NewEncoder(w)}, nil
+	case Parquet:
+		return parquetWriter[T]{parquet.NewGenericWriter[T](w, parquet.MaxRowsPerRowGroup(parquetRowGroup), parquet.Compression(&parquet.Snappy))}, nil
+	}
+	return nil, fmt.Errorf("unknown format %q, expected one of %s", format, strings.Join(Formats, ", "))
+}
+
+type ndjsonWriter[T any] struct {
+	enc *json.Encoder
+}
+
+func (n ndjsonWriter[T]) Write(row T) error { return n.enc.Encode(row) }
+func (n ndjsonWriter[T]) Close() error      { return nil }
+
+type parquetWriter[T any] struct {
+	w *parquet.GenericWriter[T]
+}
+
+func (p parquetWriter[T]) Write(row T) error {
+	_, err := p.w.Write([]T{row})
+	return err
+}
+
+func (p parquetWriter[T]) Close() error { return p.w.Close() }
+
+// csvWriter writes a header of JSON field names, then one record per row.
+type csvWriter[T any] struct {
+	w       *csv.Writer
+	fields  []int
+	header  []string
+	record  []string
+	started bool
+}
+
+func newCSVWriter[T any](w io.Writer) *csvWriter[T] {
+	c := &csvWriter[T]{w: csv.NewWriter(w)}
+	t := reflect.TypeFor[T]()
+	for i := range t.NumField() {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+		c.fields = append(c.fields, i)
+		c.header = append(c.header, name)
+	}
+	c.record = make([]string, len(c.fields))
+	return c
+}
+
+func (c *csvWriter[T]) Write(row T) error {
+	if !c.started {
+		c.started = true
+		if err := c.w.Write(c.header); err != nil {
+			return err
+		}
+	}
+	v := reflect.ValueOf(row)
+	for i, f := range c.fields {
+		c.record[i] = formatValue(v.Field(f))
+	}
+	return c.w.Write(c.record)
+}
+
+// Close writes the header if there were no rows, and flushes.
+func (c *csvWriter[T]) Close() error {
+	if !c.started {
+		c.started = true
+		c.w.Write(c.header)
+	}
+	c.w.Flush()
+	return c.w.Error()
+}
+
+func formatValue(v reflect.Value) string {
+	if t, ok := v.Interface().(time.Time); ok {
+		return t.Format(time.RFC3339)
+	}
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			return ""
+		}
+		return formatValue(v.Elem())
+	case reflect.String:
+		return v.String()
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, 64)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10)
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool())
+	}
+	return fmt.Sprint(v.Interface())
+}
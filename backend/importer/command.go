@@ -0,0 +1,53 @@
+package importer
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+)
+
+// RunCommand runs the import subcommand with its arguments, printing one
+// report per file to out:
+//
+//	go run main.go import -table tmd [-format csv] [-map "Time=timestamp"] [-dry-run] file...
+func (im *Importer) RunCommand(args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	fs.SetOutput(out)
+	table := fs.String("table", "", "table to import into: time_to_dry or tmd")
+	format := fs.String("format", "", "csv or ndjson (default: detected from the file)")
+	mapping := fs.String("map", "", "column mapping as source=field pairs, e.g. \"Humidity In=hum_in,Time=timestamp\"")
+	dryRun := fs.Bool("dry-run", false, "check the files without storing anything")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return errors.New("no files given")
+	}
+	m, err := ParseMapping(*mapping)
+	if err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+	for _, name := range fs.Args() {
+		f, err := os.Open(name)
+		if err != nil {
+			return err
+		}
+		report, err := im.Import(f, Options{Table: *table, Format: *format, Filename: name, Mapping: m, DryRun: *dryRun})
+		f.Close()
+		if report != nil {
+			fmt.Fprintf(out, "%s:\n", name)
+			enc.Encode(report)
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	return nil
+}
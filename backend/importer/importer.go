@@ -0,0 +1,435 @@
+// Package importer loads historical sensor logs and weather exports from
+// CSV or NDJSON files into the time_to_dry and tmd tables.
+package importer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"backend/combine"
+	"backend/sessions"
+)
+
+// Tables that can be imported.
+const (
+	TableTimeToDry = "time_to_dry"
+	TableTMD       = "tmd"
+)
+
+// Formats.
+const (
+	CSV    = "csv"
+	NDJSON = "ndjson"
+)
+
+// DefaultBatchSize is how many rows are checked for duplicates and inserted together.
+const DefaultBatchSize = 500
+
+// MaxReportedErrors bounds the row errors listed in a Report.
+const MaxReportedErrors = 100
+
+// ErrInvalidInput is wrapped by the errors Import returns for bad options or
+// an unreadable file, as opposed to failures of the store.
+var ErrInvalidInput = errors.New("invalid import")
+
+func invalidf(format string, args ...any) error {
+	return fmt.Errorf("%w: "+format, append([]any{ErrInvalidInput}, args...)...)
+}
+
+// Options control one import.
+type Options struct {
+	Table string
+	// Format is csv or ndjson; when empty it is detected from Filename or the content.
+	Format   string
+	Filename string
+	// Mapping renames source columns to table fields, e.g. "Humidity In" to
+	// hum_in. Columns are matched case-insensitively.
+	Mapping map[string]string
+	// DryRun checks every row without inserting anything.
+	DryRun bool
+}
+
+// RowError explains why a row was not imported.
+type RowError struct {
+	// Line is the line of the file, counting a CSV header.
+	Line    int    `json:"line"`
+	Message string `json:"message"`
+}
+
+// Report summarises an import.
+type Report struct {
+	Table  string `json:"table"`
+	Format string `json:"format"`
+	DryRun bool   `json:"dry_run"`
+	// TimestampFormat is the layout detected for the file's timestamps.
+	TimestampFormat string `json:"timestamp_format,omitempty"`
+	// IgnoredColumns are source columns that match no field.
+	IgnoredColumns []string `json:"ignored_columns,omitempty"`
+	Rows           int      `json:"rows"`
+	// Imported counts the rows inserted, or that would be in a dry run.
+	Imported int `json:"imported"`
+	// Skipped counts duplicates of stored rows or of earlier rows in the file.
+	Skipped int `json:"skipped"`
+	Invalid int `json:"invalid"`
+	// Errors lists the first MaxReportedErrors invalid rows.
+	Errors []RowError `json:"errors,omitempty"`
+	// From and To are the oldest and newest timestamps imported.
+	From time.Time `json:"from,omitzero"`
+	To   time.Time `json:"to,omitzero"`
+}
+
+func (r *Report) invalid(line int, err error) {
+	r.Invalid++
+	if len(r.Errors) < MaxReportedErrors {
+		r.Errors = append(r.Errors, RowError{Line: line, Message: err.Error()})
+	}
+}
+
+// Importer reads files into a Store.
+type Importer struct {
+	Store     Store
+	BatchSize int
+	// AfterImport, if set, is called once rows have been inserted into a
+	// table, with the report of the import.
+	AfterImport func(report *Report) error
+}
+
+// New returns an Importer that writes to the database, creates drying
+// sessions for imported test_ids and joins imported weather with the
+// readings of its time that were left without.
+func New() *Importer {
+	return &Importer{
+		Store:     GormStore{},
+		BatchSize: DefaultBatchSize,
+		AfterImport: func(report *Report) error {
+			if report.Table == TableTMD {
+				_, err := combine.Default.Rejoin(report.From, report.To)
+				return err
+			}
+			_, err := sessions.BackfillFromReadings(sessions.DefaultGap)
+			return err
+		},
+	}
+}
+
+// Default is the Importer used by the import command and endpoint.
+var Default = New()
+
+// Import reads all rows of r. Invalid and duplicate rows are counted in the
+// report; an error is only returned when the file or the store fails.
+func (im *Importer) Import(r io.Reader, opts Options) (*Report, error) {
+	spec, ok := tables[opts.Table]
+	if !ok {
+		return nil, invalidf("unknown table %q, expected %s or %s", opts.Table, TableTimeToDry, TableTMD)
+	}
+	mapping, err := normalizeMapping(opts.Mapping, spec.fields)
+	if err != nil {
+		return nil, err
+	}
+
+	br := bufio.NewReader(r)
+	format := opts.Format
+	if format == "" {
+		format = detectFormat(opts.Filename, br)
+	}
+	var src source
+	switch format {
+	case CSV:
+		src, err = newCSVSource(br)
+	case NDJSON:
+		src = newNDJSONSource(br)
+	default:
+		return nil, invalidf("unknown format %q, expected %s or %s", format, CSV, NDJSON)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{Table: opts.Table, Format: format, DryRun: opts.DryRun}
+	batchSize := im.BatchSize
+	if batchSize <= 0 {
+		batchSize = DefaultBatchSize
+	}
+	b := &batch{spec: spec, store: im.Store, dryRun: opts.DryRun, seen: map[string]bool{}, report: report}
+	ts := &timestampParser{}
+	ignored := map[string]bool{}
+
+	for {
+		line, raw, err := src.next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			var rowErr *rowError
+			if errors.As(err, &rowErr) {
+				report.Rows++
+				report.invalid(line, rowErr.err)
+				continue
+			}
+			return report, err
+		}
+		report.Rows++
+
+		values := map[string]string{}
+		for col, v := range raw {
+			field, ok := mapping[normalizeColumn(col)]
+			if !ok {
+				field = normalizeColumn(col)
+			}
+			if slices.Contains(spec.fields, field) {
+				values[field] = v
+			} else if !ignored[col] {
+				ignored[col] = true
+				report.IgnoredColumns = append(report.IgnoredColumns, col)
+			}
+		}
+		row, err := spec.parse(values, ts)
+		if err != nil {
+			report.invalid(line, err)
+			continue
+		}
+		b.add(row)
+		if len(b.rows) >= batchSize {
+			if err := b.flush(); err != nil {
+				return report, err
+			}
+		}
+	}
+	if err := b.flush(); err != nil {
+		return report, err
+	}
+	slices.Sort(report.IgnoredColumns)
+	report.TimestampFormat = ts.layout
+
+	if !opts.DryRun && report.Imported > 0 && im.AfterImport != nil {
+		if err := im.AfterImport(report); err != nil {
+			return report, err
+		}
+	}
+	return report, nil
+}
+
+// batch collects parsed rows until they are checked against the store and inserted.
+type batch struct {
+	spec   tableSpec
+	store  Store
+	dryRun bool
+	rows   []row
+	// seen holds the keys of rows earlier in the file.
+	seen   map[string]bool
+	report *Report
+}
+
+func (b *batch) add(r row) {
+	if b.seen[r.key] {
+		b.report.Skipped++
+		return
+	}
+	b.seen[r.key] = true
+	b.rows = append(b.rows, r)
+}
+
+func (b *batch) flush() error {
+	if len(b.rows) == 0 {
+		return nil
+	}
+	keys := make([]string, len(b.rows))
+	for i, r := range b.rows {
+		keys[i] = r.key
+	}
+	existing, err := b.store.Existing(b.spec.name, keys)
+	if err != nil {
+		return err
+	}
+
+	var fresh []any
+	for _, r := range b.rows {
+		if existing[r.key] {
+			b.report.Skipped++
+			continue
+		}
+		fresh = append(fresh, r.model)
+		if b.report.From.IsZero() || r.at.Before(b.report.From) {
+			b.report.From = r.at
+		}
+		if r.at.After(b.report.To) {
+			b.report.To = r.at
+		}
+	}
+	b.rows = b.rows[:0]
+	if len(fresh) == 0 {
+		return nil
+	}
+	if !b.dryRun {
+		if err := b.store.Insert(b.spec.name, fresh); err != nil {
+			return err
+		}
+	}
+	b.report.Imported += len(fresh)
+	return nil
+}
+
+// ParseMapping reads a mapping written as "source=field,source=field".
+func ParseMapping(s string) (map[string]string, error) {
+	mapping := map[string]string{}
+	if strings.TrimSpace(s) == "" {
+		return mapping, nil
+	}
+	for _, pair := range strings.Split(s, ",") {
+		from, to, ok := strings.Cut(pair, "=")
+		if !ok || strings.TrimSpace(from) == "" || strings.TrimSpace(to) == "" {
+			return nil, invalidf("invalid mapping %q, expected source=field", pair)
+		}
+		mapping[strings.TrimSpace(from)] = strings.TrimSpace(to)
+	}
+	return mapping, nil
+}
+
+// normalizeMapping keys the mapping by normalized source column and checks its targets.
+func normalizeMapping(mapping map[string]string, fields []string) (map[string]string, error) {
+	out := make(map[string]string, len(mapping))
+	for from, to := range mapping {
+		to = normalizeColumn(to)
+		if !slices.Contains(fields, to) {
+			return nil, invalidf("cannot map %q to unknown field %q, expected one of %s", from, to, strings.Join(fields, ", "))
+		}
+		out[normalizeColumn(from)] = to
+	}
+	return out, nil
+}
+
+func normalizeColumn(s string) string {
+	return strings.ToLower(strings.TrimSpace(s))
+}
+
+// detectFormat goes by the file extension, then by whether the content
+// starts with a JSON object.
+func detectFormat(filename string, br *bufio.Reader) string {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".csv":
+		return CSV
+	case ".ndjson", ".jsonl", ".json":
+		return NDJSON
+	}
+	head, _ := br.Peek(512)
+	if bytes.HasPrefix(bytes.TrimSpace(bytes.TrimPrefix(head, []byte("\xef\xbb\xbf"))), []byte("{")) {
+		return NDJSON
+	}
+	return CSV
+}
+
+// source yields the rows of a file as column values by column name.
+type source interface {
+	// next returns the line of the row and its values, io.EOF at the end,
+	// or a *rowError for a row that cannot be read.
+	next() (line int, values map[string]string, err error)
+}
+
+// rowError marks a single unreadable row; reading continues after it.
+type rowError struct {
+	err error
+}
+
+func (e *rowError) Error() string { return e.err.Error() }
+
+type csvSource struct {
+	r      *csv.Reader
+	header []string
+}
+
+func newCSVSource(r io.Reader) (*csvSource, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+	header, err := cr.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, invalidf("the file is empty")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: reading CSV header: %w", ErrInvalidInput, err)
+	}
+	header[0] = strings.TrimPrefix(header[0], "\ufeff")
+	return &csvSource{r: cr, header: header}, nil
+}
+
+func (s *csvSource) next() (int, map[string]string, error) {
+	record, err := s.r.Read()
+	line, _ := s.r.FieldPos(0)
+	if err != nil {
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			return parseErr.StartLine, nil, &rowError{err}
+		}
+		return line, nil, err
+	}
+	if len(record) != len(s.header) {
+		return line, nil, &rowError{fmt.Errorf("has %d columns, the header has %d", len(record), len(s.header))}
+	}
+	values := make(map[string]string, len(record))
+	for i, v := range record {
+		values[s.header[i]] = v
+	}
+	return line, values, nil
+}
+
+type ndjsonSource struct {
+	s    *bufio.Scanner
+	line int
+}
+
+func newNDJSONSource(r io.Reader) *ndjsonSource {
+	s := bufio.NewScanner(r)
+	s.Buffer(make([]byte, 64*1024), 1024*1024)
+	return &ndjsonSource{s: s}
+}
+
+func (s *ndjsonSource) next() (int, map[string]string, error) {
+	for s.s.Scan() {
+		s.line++
+		text := bytes.TrimSpace(s.s.Bytes())
+		if len(text) == 0 {
+			continue
+		}
+		dec := json.NewDecoder(bytes.NewReader(text))
+		dec.UseNumber()
+		var obj map[string]any
+		if err := dec.Decode(&obj); err != nil {
+			return s.line, nil, &rowError{fmt.Errorf("invalid JSON: %w", err)}
+		}
+		values := make(map[string]string, len(obj))
+		for k, v := range obj {
+			switch v := v.(type) {
+			case nil:
+			case string:
+				values[k] = v
+			case json.Number:
+				values[k] = v.String()
+			default:
+				return s.line, nil, &rowError{fmt.Errorf("%s must be a string or number", k)}
+			}
+		}
+		return s.line, values, nil
+	}
+	if err := s.s.Err(); errors.Is(err, bufio.ErrTooLong) {
+		return s.line + 1, nil, fmt.Errorf("%w: line %d is longer than 1 MiB", ErrInvalidInput, s.line+1)
+	} else if err != nil {
+		return s.line, nil, err
+	}
+	return s.line, nil, io.EOF
+}
+
+// row is a parsed row with its duplicate key and timestamp.
+type row struct {
+	key   string
+	at    time.Time
+	model any
+}
@@ -0,0 +1,76 @@
+package importer
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"backend/database"
+	"backend/models"
+)
+
+// Store checks for and inserts imported rows.
+type Store interface {
+	// Existing reports which of the duplicate keys are already stored. A
+	// key is the UTC timestamp in RFC 3339, followed by "|" and the test_id
+	// for time_to_dry.
+	Existing(table string, keys []string) (map[string]bool, error)
+	// Insert stores rows of the table; they are *models.TimeToDry or *models.TMD.
+	Insert(table string, rows []any) error
+}
+
+// GormStore is the Store backed by database.DB.
+type GormStore struct{}
+
+func (GormStore) Existing(table string, keys []string) (map[string]bool, error) {
+	// Narrow by timestamp, the indexed part of both keys, then compare whole keys.
+	timestamps := make([]time.Time, 0, len(keys))
+	for _, key := range keys {
+		ts, _, _ := strings.Cut(key, "|")
+		t, err := time.Parse(time.RFC3339, ts)
+		if err != nil {
+			return nil, fmt.Errorf("invalid key %q", key)
+		}
+		timestamps = append(timestamps, t.UTC())
+	}
+	existing := map[string]bool{}
+	switch table {
+	case TableTimeToDry:
+		var rows []models.TimeToDry
+		if err := database.DB.Select("timestamp", "test_id").Where("timestamp IN ?", timestamps).Find(&rows).Error; err != nil {
+			return nil, err
+		}
+		for _, r := range rows {
+			existing[timeToDryKey(r.Timestamp, r.TestID)] = true
+		}
+	case TableTMD:
+		var rows []models.TMD
+		if err := database.DB.Select("timestamp").Where("timestamp IN ?", timestamps).Find(&rows).Error; err != nil {
+			return nil, err
+		}
+		for _, r := range rows {
+			existing[timestampKey(r.Timestamp)] = true
+		}
+	default:
+		return nil, fmt.Errorf("unknown table %q", table)
+	}
+	return existing, nil
+}
+
+func (GormStore) Insert(table string, rows []any) error {
+	switch table {
+	case TableTimeToDry:
+		return database.DB.CreateInBatches(typed[models.TimeToDry](rows), len(rows)).Error
+	case TableTMD:
+		return database.DB.CreateInBatches(typed[models.TMD](rows), len(rows)).Error
+	}
+	return fmt.Errorf("unknown table %q", table)
+}
+
+func typed[T any](rows []any) []T {
+	out := make([]T, len(rows))
+	for i, r := range rows {
+		out[i] = *r.(*T)
+	}
+	return out
+}
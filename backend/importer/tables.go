@@ -0,0 +1,217 @@
+package importer
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"backend/ingest"
+	"backend/models"
+	"backend/utils"
+)
+
+// tableSpec describes how rows of a table are parsed.
+type tableSpec struct {
+	name string
+	// fields are the columns a file may provide. id and derived fields are
+	// not imported.
+	fields []string
+	parse  func(values map[string]string, ts *timestampParser) (row, error)
+}
+
+var tables = map[string]tableSpec{
+	TableTimeToDry: {
+		name:   TableTimeToDry,
+		fields: []string{"timestamp", "lat", "lon", "light", "temp_in", "temp_out", "hum_in", "hum_out", "test_id"},
+		parse:  parseTimeToDry,
+	},
+	TableTMD: {
+		name:   TableTMD,
+		fields: []string{"timestamp", "temperature", "humidity", "rainfall"},
+		parse:  parseTMD,
+	},
+}
+
+// parseTimeToDry reads a sensor reading. Readings are validated and their
+// differences recomputed the same way as live ones. They need a test_id,
+// as the drying sessions are created from it after the import, and are
+// duplicates when they have the same timestamp and test_id.
+func parseTimeToDry(v map[string]string, ts *timestampParser) (row, error) {
+	p := fieldParser{values: v}
+	r := &models.TimeToDry{
+		Timestamp: p.timestamp(ts),
+		Lat:       p.float("lat", false),
+		Lon:       p.float("lon", false),
+		Light:     p.float("light", false),
+		TempIn:    p.float("temp_in", true),
+		TempOut:   p.float("temp_out", true),
+		HumIn:     p.float("hum_in", true),
+		HumOut:    p.float("hum_out", true),
+		TestID:    p.positiveInt("test_id"),
+	}
+	if err := p.err(); err != nil {
+		return row{}, err
+	}
+	if err := ingest.Validate(r); err != nil {
+		return row{}, err
+	}
+	ingest.Normalize(r)
+	return row{key: timeToDryKey(r.Timestamp, r.TestID), at: r.Timestamp, model: r}, nil
+}
+
+func timeToDryKey(timestamp time.Time, testID int) string {
+	return timestampKey(timestamp) + "|" + strconv.Itoa(testID)
+}
+
+// timestampKey is the stored timestamp in RFC 3339.
+func timestampKey(timestamp time.Time) string {
+	return models.StoredTime(timestamp).Format(time.RFC3339)
+}
+
+// parseTMD reads a weather record. Records are duplicates when they have
+// the same timestamp.
+func parseTMD(v map[string]string, ts *timestampParser) (row, error) {
+	p := fieldParser{values: v}
+	r := &models.TMD{
+		Timestamp:   p.timestamp(ts),
+		Temperature: p.float("temperature", true),
+		Humidity:    p.float("humidity", true),
+		Rainfall:    p.float("rainfall", false),
+	}
+	if err := p.err(); err != nil {
+		return row{}, err
+	}
+	switch {
+	case r.Temperature < ingest.MinTemperature || r.Temperature > ingest.MaxTemperature:
+		return row{}, fmt.Errorf("temperature must be between %g and %g, got %g", ingest.MinTemperature, ingest.MaxTemperature, r.Temperature)
+	case r.Humidity < ingest.MinHumidity || r.Humidity > ingest.MaxHumidity:
+		return row{}, fmt.Errorf("humidity must be between %g and %g, got %g", ingest.MinHumidity, ingest.MaxHumidity, r.Humidity)
+	case r.Rainfall < 0:
+		return row{}, fmt.Errorf("rainfall must not be negative, got %g", r.Rainfall)
+	}
+	return row{key: timestampKey(r.Timestamp), at: r.Timestamp, model: r}, nil
+}
+
+// fieldParser converts the values of one row, collecting every problem.
+type fieldParser struct {
+	values map[string]string
+	errs   []string
+}
+
+func (p *fieldParser) float(field string, required bool) float64 {
+	v := strings.TrimSpace(p.values[field])
+	if v == "" {
+		if required {
+			p.errs = append(p.errs, field+" is missing")
+		}
+		return 0
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		p.errs = append(p.errs, fmt.Sprintf("%s %q is not a number", field, v))
+	}
+	return f
+}
+
+// positiveInt reads a required integer above zero.
+func (p *fieldParser) positiveInt(field string) int {
+	v := strings.TrimSpace(p.values[field])
+	if v == "" {
+		p.errs = append(p.errs, field+" is missing")
+		return 0
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		p.errs = append(p.errs, fmt.Sprintf("%s %q is not an integer", field, v))
+	} else if n <= 0 {
+		p.errs = append(p.errs, fmt.Sprintf("%s must be positive, got %d", field, n))
+	}
+	return n
+}
+
+func (p *fieldParser) timestamp(ts *timestampParser) time.Time {
+	v := strings.TrimSpace(p.values["timestamp"])
+	if v == "" {
+		p.errs = append(p.errs, "timestamp is missing")
+		return time.Time{}
+	}
+	t, err := ts.parse(v)
+	if err != nil {
+		p.errs = append(p.errs, err.Error())
+		return time.Time{}
+	}
+	return models.StoredTime(t)
+}
+
+func (p *fieldParser) err() error {
+	if len(p.errs) == 0 {
+		return nil
+	}
+	return errors.New(strings.Join(p.errs, "; "))
+}
+
+// Timestamp layouts tried after utils.ParseTimestamp's RFC 3339 and stored
+// format. Times without an offset are server local time.
+var timestampLayouts = []string{
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04",
+	"2006/01/02 15:04:05",
+	"2006/01/02 15:04",
+	"02/01/2006 15:04:05",
+	"02/01/2006 15:04",
+}
+
+// Names of the layouts detected by timestampParser besides timestampLayouts.
+const (
+	layoutStored = utils.TimestampLayout
+	layoutRFC    = time.RFC3339
+	layoutUnix   = "unix"
+)
+
+// timestampParser detects the timestamp format of a file from its first
+// valid timestamp and then holds every row to it, so a file mixing
+// day-first and month-first dates is not read half wrong.
+type timestampParser struct {
+	layout string
+}
+
+func (p *timestampParser) parse(v string) (time.Time, error) {
+	if p.layout != "" {
+		t, err := parseLayout(p.layout, v)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("timestamp %q does not match the file's format %s", v, p.layout)
+		}
+		return t, nil
+	}
+	for _, layout := range append([]string{layoutStored, layoutRFC, layoutUnix}, timestampLayouts...) {
+		if t, err := parseLayout(layout, v); err == nil {
+			p.layout = layout
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("timestamp %q has an unknown format", v)
+}
+
+func parseLayout(layout, v string) (time.Time, error) {
+	switch layout {
+	case layoutStored, layoutRFC:
+		// Stored timestamps are server local time; RFC 3339 ones carry their offset.
+		if _, err := time.Parse(layout, v); err != nil {
+			return time.Time{}, err
+		}
+		return utils.ParseLocalTimestamp(v)
+	case layoutUnix:
+		secs, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return time.Time{}, err
+		}
+		// Milliseconds, as exported by JavaScript and many loggers.
+		if secs > 1e11 {
+			return time.UnixMilli(secs), nil
+		}
+		return time.Unix(secs, 0), nil
+	}
+	return time.ParseInLocation(layout, v, time.Local)
+}
@@ -0,0 +1,158 @@
+package ingest
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"backend/database"
+	"backend/detector"
+	"backend/events"
+	"backend/models"
+	"backend/sessions"
+	"backend/utils"
+)
+
+// Payload is the JSON document published by KidBright/Data_Collector.py.
+type Payload struct {
+	Lat      float64 `json:"lat"`
+	Lon      float64 `json:"lon"`
+	Light    float64 `json:"light"`
+	TempIn   float64 `json:"temp_in"`
+	TempOut  float64 `json:"temp_out"`
+	HumIn    float64 `json:"hum_in"`
+	HumOut   float64 `json:"hum_out"`
+	DiffTemp float64 `json:"diff_temp"`
+	DiffHum  float64 `json:"diff_hum"`
+}
+
+// Ingestor validates and stamps incoming readings, attaches them to a
+// drying session and persists them.
+type Ingestor struct {
+	// Sessions assigns the TestID of readings that arrive without one.
+	Sessions *sessions.Manager
+	// Detector, if set, ends the session with dry_detected once its readings show the laundry is dry.
+	Detector *detector.Detector
+	// Save persists a reading. Defaults to inserting into database.DB.
+	Save func(*models.TimeToDry) error
+	// Events, if set, receives a reading event for every stored reading.
+	Events *events.Bus
+	// Now returns the receive time used to stamp readings.
+	Now func() time.Time
+}
+
+// NewIngestor returns an Ingestor that writes to the time_to_dry table,
+// detecting dry laundry with detector.DefaultConfig.
+func NewIngestor() *Ingestor {
+	return &Ingestor{
+		Sessions: sessions.Default,
+		Detector: detector.New(detector.DefaultConfig),
+		Save:     saveToDB,
+		Events:   events.Default,
+		Now:      time.Now,
+	}
+}
+
+// NewIngestorFromEnv is NewIngestor with the DRY_* thresholds of the
+// environment, which must be loaded first.
+func NewIngestorFromEnv() *Ingestor {
+	in := NewIngestor()
+	in.Detector = detector.New(detector.ConfigFromEnv())
+	return in
+}
+
+// Default is the Ingestor shared by the MQTT subscriber and the HTTP
+// readings endpoint. main replaces it with NewIngestorFromEnv once the
+// environment is loaded.
+var Default = NewIngestor()
+
+func saveToDB(reading *models.TimeToDry) error {
+	return database.DB.Create(reading).Error
+}
+
+// HandlePayload decodes a raw sensor payload sent by device and ingests it.
+func (in *Ingestor) HandlePayload(device string, body []byte) (*models.TimeToDry, error) {
+	var p Payload
+	if err := json.Unmarshal(body, &p); err != nil {
+		return nil, fmt.Errorf("decode payload: %w", err)
+	}
+
+	reading := &models.TimeToDry{
+		Lat:     p.Lat,
+		Lon:     p.Lon,
+		Light:   p.Light,
+		TempIn:  p.TempIn,
+		TempOut: p.TempOut,
+		HumIn:   p.HumIn,
+		HumOut:  p.HumOut,
+	}
+	// diff_temp and diff_hum are recomputed by Ingest rather than trusted.
+	if err := in.IngestFrom(device, reading); err != nil {
+		return nil, err
+	}
+	return reading, nil
+}
+
+// Ingest validates a reading, recomputes its difference fields, stamps it
+// with the current time unless it already carries a timestamp, attaches it
+// to the active drying session when no TestID was given and saves it.
+// Invalid readings are rejected with a *ValidationError.
+//
+// Readings attached to the active session are also passed to the Detector;
+// when it finds the laundry dry the session is stopped with dry_detected at
+// the detected dry time.
+func (in *Ingestor) Ingest(reading *models.TimeToDry) error {
+	return in.IngestFrom("", reading)
+}
+
+// IngestFrom is Ingest for a reading sent by the given device. The device
+// only tags the published reading event; it is not stored.
+func (in *Ingestor) IngestFrom(device string, reading *models.TimeToDry) error {
+	if verr := Validate(reading); verr != nil {
+		return verr
+	}
+	Normalize(reading)
+
+	at := in.Now()
+	if !reading.Timestamp.IsZero() {
+		at = reading.Timestamp
+	}
+	reading.ID = 0
+	reading.Timestamp = models.StoredTime(at)
+	var session *models.DryingSession
+	if reading.TestID == 0 {
+		var err error
+		if session, err = in.Sessions.Record(at); err != nil {
+			return fmt.Errorf("assign drying session: %w", err)
+		}
+		reading.TestID = session.TestID
+	}
+
+	if err := in.Save(reading); err != nil {
+		return fmt.Errorf("save reading: %w", err)
+	}
+	if in.Events != nil {
+		in.Events.Publish(events.TypeReading, device, reading.TestID, *reading)
+	}
+	if session != nil && session.Status == models.SessionActive && in.Detector != nil {
+		in.detectDry(session, reading, at)
+	}
+	return nil
+}
+
+func (in *Ingestor) detectDry(session *models.DryingSession, reading *models.TimeToDry, at time.Time) {
+	dryAt, dry := in.Detector.Observe(session.TestID, session.StartedAt, detector.Reading{
+		At:       at,
+		DiffTemp: reading.DiffTemp,
+		DiffHum:  reading.DiffHum,
+	})
+	if !dry {
+		return
+	}
+	if _, err := in.Sessions.StopAt(session.TestID, models.EndReasonDryDetected, dryAt); err != nil {
+		log.Printf("Failed to end drying session %d as dry: %v", session.TestID, err)
+		return
+	}
+	log.Printf("Drying session %d detected dry at %s", session.TestID, utils.FormatTimestamp(dryAt))
+}
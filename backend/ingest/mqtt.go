@@ -0,0 +1,123 @@
+package ingest
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"backend/config"
+	"backend/utils"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+)
+
+// DefaultTopic is the topic KidBright/Data_Collector.py publishes to.
+const DefaultTopic = "b6610545391/time_to_dry"
+
+// MQTTConfig describes the broker connection used for sensor ingestion.
+type MQTTConfig struct {
+	Broker   string
+	Topic    string
+	ClientID string
+	Username string
+	Password string
+	QoS      byte
+	// MaxReconnectInterval caps the exponential backoff between reconnect attempts.
+	MaxReconnectInterval time.Duration
+}
+
+// MQTTConfigFromEnv reads MQTT_BROKER, MQTT_TOPIC, MQTT_CLIENT_ID, MQTT_USER,
+// MQTT_PASS and MQTT_MAX_RECONNECT_INTERVAL.
+func MQTTConfigFromEnv() MQTTConfig {
+	return MQTTConfig{
+		Broker:               config.GetEnv("MQTT_BROKER", ""),
+		Topic:                config.GetEnv("MQTT_TOPIC", DefaultTopic),
+		ClientID:             config.GetEnv("MQTT_CLIENT_ID", "time-to-dry-backend"),
+		Username:             config.GetEnv("MQTT_USER", ""),
+		Password:             config.GetEnv("MQTT_PASS", ""),
+		QoS:                  1,
+		MaxReconnectInterval: config.GetEnvDuration("MQTT_MAX_RECONNECT_INTERVAL", 2*time.Minute),
+	}
+}
+
+// MQTTSubscriber feeds messages from the sensor topic into an Ingestor.
+type MQTTSubscriber struct {
+	cfg      MQTTConfig
+	ingestor *Ingestor
+	client   mqtt.Client
+}
+
+// NewMQTTSubscriber prepares a subscriber; call Start to connect.
+func NewMQTTSubscriber(cfg MQTTConfig, ingestor *Ingestor) *MQTTSubscriber {
+	s := &MQTTSubscriber{cfg: cfg, ingestor: ingestor}
+
+	opts := mqtt.NewClientOptions().
+		AddBroker(cfg.Broker).
+		SetClientID(cfg.ClientID).
+		SetUsername(cfg.Username).
+		SetPassword(cfg.Password).
+		SetAutoReconnect(true).
+		SetConnectRetry(true).
+		SetConnectRetryInterval(5 * time.Second).
+		SetMaxReconnectInterval(cfg.MaxReconnectInterval).
+		SetOnConnectHandler(s.onConnect).
+		SetConnectionLostHandler(func(_ mqtt.Client, err error) {
+			log.Println("MQTT connection lost, reconnecting:", err)
+		}).
+		SetReconnectingHandler(func(_ mqtt.Client, _ *mqtt.ClientOptions) {
+			log.Println("MQTT reconnecting to", cfg.Broker)
+		})
+
+	s.client = mqtt.NewClient(opts)
+	return s
+}
+
+// Start connects to the broker in the background. The client keeps retrying
+// until the broker is reachable, so Start only fails on invalid configuration.
+func (s *MQTTSubscriber) Start() error {
+	if s.cfg.Broker == "" {
+		return fmt.Errorf("MQTT broker is not configured")
+	}
+	s.client.Connect()
+	return nil
+}
+
+// Stop disconnects from the broker, giving in-flight messages a moment to finish.
+func (s *MQTTSubscriber) Stop() {
+	s.client.Disconnect(250)
+}
+
+// IsConnected reports whether the client currently holds a broker connection.
+func (s *MQTTSubscriber) IsConnected() bool {
+	return s.client.IsConnectionOpen()
+}
+
+// onConnect (re)subscribes on every connection, since the broker drops
+// subscriptions of clean sessions when the connection is lost.
+func (s *MQTTSubscriber) onConnect(c mqtt.Client) {
+	log.Printf("MQTT connected to %s, subscribing to %s", s.cfg.Broker, s.cfg.Topic)
+	token := c.Subscribe(s.cfg.Topic, s.cfg.QoS, s.onMessage)
+	go func() {
+		token.Wait()
+		if err := token.Error(); err != nil {
+			log.Println("MQTT subscribe failed:", err)
+		}
+	}()
+}
+
+func (s *MQTTSubscriber) onMessage(_ mqtt.Client, msg mqtt.Message) {
+	reading, err := s.ingestor.HandlePayload(DeviceFromTopic(msg.Topic()), msg.Payload())
+	if err != nil {
+		log.Printf("Dropped MQTT message on %s: %v", msg.Topic(), err)
+		return
+	}
+	log.Printf("Stored reading for test_id %d at %s", reading.TestID, utils.FormatTimestamp(reading.Timestamp))
+}
+
+// DeviceFromTopic names the device publishing on topic after the first
+// topic level, e.g. "b6610545391" for "b6610545391/time_to_dry".
+func DeviceFromTopic(topic string) string {
+	device, _, _ := strings.Cut(topic, "/")
+	return device
+}
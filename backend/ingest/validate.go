@@ -0,0 +1,70 @@
+package ingest
+
+import (
+	"fmt"
+	"strings"
+
+	"backend/models"
+)
+
+// Physical limits a reading must respect to be stored. The DHT11 sensors
+// only cover 0–50 °C, but the range is kept wide enough for other hardware.
+const (
+	MinTemperature = -40.0
+	MaxTemperature = 85.0
+	MinHumidity    = 0.0
+	MaxHumidity    = 100.0
+)
+
+// FieldError describes why a single field of a reading was rejected.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationError is returned by Ingest when a reading is outside physical ranges.
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	parts := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		parts[i] = f.Field + " " + f.Message
+	}
+	return "invalid reading: " + strings.Join(parts, "; ")
+}
+
+// Validate checks that a reading is physically plausible. It returns nil when the reading is valid.
+func Validate(r *models.TimeToDry) *ValidationError {
+	var errs []FieldError
+	inRange := func(field string, value, min, max float64) {
+		if value < min || value > max {
+			errs = append(errs, FieldError{field, fmt.Sprintf("must be between %g and %g, got %g", min, max, value)})
+		}
+	}
+
+	inRange("temp_in", r.TempIn, MinTemperature, MaxTemperature)
+	inRange("temp_out", r.TempOut, MinTemperature, MaxTemperature)
+	inRange("hum_in", r.HumIn, MinHumidity, MaxHumidity)
+	inRange("hum_out", r.HumOut, MinHumidity, MaxHumidity)
+	inRange("lat", r.Lat, -90, 90)
+	inRange("lon", r.Lon, -180, 180)
+	if r.Light < 0 {
+		errs = append(errs, FieldError{"light", fmt.Sprintf("must not be negative, got %g", r.Light)})
+	}
+	if r.TestID < 0 {
+		errs = append(errs, FieldError{"test_id", "must not be negative"})
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+	return &ValidationError{Fields: errs}
+}
+
+// Normalize recomputes the derived difference fields instead of trusting the device.
+func Normalize(r *models.TimeToDry) {
+	r.DiffTemp = r.TempIn - r.TempOut
+	r.DiffHum = r.HumIn - r.HumOut
+}
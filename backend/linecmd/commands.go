@@ -0,0 +1,460 @@
+package linecmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"strings"
+	"time"
+
+	"backend/database"
+	"backend/estimator"
+	"backend/models"
+	"backend/sessions"
+	"backend/subscribers"
+	"backend/utils"
+	"backend/weather"
+
+	"github.com/line/line-bot-sdk-go/v7/linebot"
+)
+
+// DeviceTimeout is how long the device may stay silent and still be reported online.
+const DeviceTimeout = 5 * time.Minute
+
+// Handler answers the text commands household members send to the LINE bot.
+type Handler struct {
+	Sessions    *sessions.Manager
+	Subscribers *subscribers.Registry
+	// LastReading returns the most recent time_to_dry row.
+	LastReading func() (*models.TimeToDry, error)
+	// Model returns the active drying time model.
+	Model func() (*models.DryTimeModel, error)
+	// Outlook returns the rain outlook for the next hours.
+	Outlook func(ctx context.Context, now time.Time) (*weather.RainOutlook, error)
+	Now     func() time.Time
+}
+
+// NewHandler returns a Handler backed by the database, the default session
+// manager and the configured weather provider.
+func NewHandler() *Handler {
+	return &Handler{
+		Sessions:    sessions.Default,
+		Subscribers: subscribers.Default,
+		LastReading: lastReading,
+		Model:       estimator.ActiveModel,
+		Outlook:     outlookFromEnv,
+		Now:         time.Now,
+	}
+}
+
+// Default is the Handler used by the LINE webhook.
+var Default = NewHandler()
+
+// Source is the LINE chat a command came from.
+type Source struct {
+	// ID is the user, group or room ID.
+	ID string
+	// Type is one of models.LineSourceUser, LineSourceGroup and LineSourceRoom.
+	Type string
+}
+
+// SourceOf returns the chat of a webhook event. Commands sent in a group or
+// room apply to the whole group or room.
+func SourceOf(src *linebot.EventSource) Source {
+	switch src.Type {
+	case linebot.EventSourceTypeGroup:
+		return Source{ID: src.GroupID, Type: models.LineSourceGroup}
+	case linebot.EventSourceTypeRoom:
+		return Source{ID: src.RoomID, Type: models.LineSourceRoom}
+	}
+	return Source{ID: src.UserID, Type: models.LineSourceUser}
+}
+
+type request struct {
+	Source Source
+	// Args are the words after the command name.
+	Args []string
+}
+
+type command struct {
+	name        string
+	description string
+	run         func(h *Handler, ctx context.Context, req request) *linebot.FlexMessage
+}
+
+var commands []command
+
+func init() {
+	commands = []command{
+		{"status", "Device status and last reading", (*Handler).status},
+		{"estimate", "Estimated drying time", (*Handler).estimate},
+		{"rain", "Rain forecast", (*Handler).rain},
+		{"start", "Start a drying session", (*Handler).start},
+		{"stop", "Stop the drying session", (*Handler).stop},
+		{"subscribe", "Get rain and dry alerts (add rain or dry for only one)", (*Handler).subscribe},
+		{"unsubscribe", "Stop alerts (add rain or dry for only one)", (*Handler).unsubscribe},
+		{"alerts", "Which alerts this chat gets", (*Handler).alerts},
+		{"help", "List the commands", (*Handler).help},
+	}
+}
+
+// HandleEvent handles one webhook event and returns the reply to send, or
+// nil when the event needs no reply. Following the bot or adding it to a
+// group subscribes the chat to alerts; unfollowing or removing it
+// unsubscribes.
+func (h *Handler) HandleEvent(ctx context.Context, event *linebot.Event) linebot.SendingMessage {
+	src := SourceOf(event.Source)
+	switch event.Type {
+	case linebot.EventTypeMessage:
+		if msg, ok := event.Message.(*linebot.TextMessage); ok {
+			return h.Handle(ctx, src, msg.Text)
+		}
+	case linebot.EventTypeFollow, linebot.EventTypeJoin:
+		if _, err := h.Subscribers.Follow(src.ID, src.Type); err != nil {
+			return errorCard("Subscribe", err)
+		}
+		return h.welcome()
+	case linebot.EventTypeUnfollow, linebot.EventTypeLeave:
+		if err := h.Subscribers.Unfollow(src.ID); err != nil {
+			log.Printf("Failed to unsubscribe LINE %s %s: %v", src.Type, src.ID, err)
+		}
+	}
+	return nil
+}
+
+// Handle runs the command in text sent from src and returns the reply.
+// Commands are case-insensitive and may be prefixed with a slash; anything
+// else gets the help card.
+func (h *Handler) Handle(ctx context.Context, src Source, text string) *linebot.FlexMessage {
+	fields := strings.Fields(strings.ToLower(strings.TrimPrefix(strings.TrimSpace(text), "/")))
+	if len(fields) == 0 {
+		return h.unknown(text)
+	}
+	for _, c := range commands {
+		if c.name == fields[0] {
+			return c.run(h, ctx, request{Source: src, Args: fields[1:]})
+		}
+	}
+	return h.unknown(text)
+}
+
+func (h *Handler) status(context.Context, request) *linebot.FlexMessage {
+	last, err := h.LastReading()
+	if err != nil {
+		return errorCard("Status", err)
+	}
+	if last == nil {
+		return card{
+			Title:   "📡 Device offline",
+			Color:   colorWarning,
+			Summary: "No readings have been received yet.",
+		}.flex("Device offline: no readings yet")
+	}
+
+	lastAt := utils.FormatTimestamp(last.Timestamp)
+	online := h.Now().Sub(last.Timestamp) <= DeviceTimeout
+	c := card{
+		Title: "📡 Device online",
+		Color: colorOK,
+		Fields: []field{
+			{"Last reading", lastAt},
+			{"Inside", fmt.Sprintf("%.1f°C, %.0f%%", last.TempIn, last.HumIn)},
+			{"Outside", fmt.Sprintf("%.1f°C, %.0f%%", last.TempOut, last.HumOut)},
+			{"Light", fmt.Sprintf("%.0f lux", last.Light)},
+		},
+		Buttons: []string{"estimate", "rain"},
+	}
+	if !online {
+		c.Title, c.Color = "📡 Device offline", colorWarning
+		c.Summary = fmt.Sprintf("No reading in the last %d minutes.", int(DeviceTimeout.Minutes()))
+	}
+	if active, err := h.Sessions.Active(); err == nil && active != nil {
+		c.Fields = append(c.Fields, field{"Session", fmt.Sprintf("#%d since %s", active.TestID, clock(active.StartedAt))})
+	} else {
+		c.Fields = append(c.Fields, field{"Session", "none"})
+	}
+
+	state := "online"
+	if !online {
+		state = "offline"
+	}
+	return c.flex(fmt.Sprintf("Device %s, last reading %s", state, lastAt))
+}
+
+func (h *Handler) estimate(context.Context, request) *linebot.FlexMessage {
+	last, err := h.LastReading()
+	if err != nil {
+		return errorCard("Estimate", err)
+	}
+	if last == nil {
+		return card{
+			Title:   "⏱️ Estimate",
+			Color:   colorWarning,
+			Summary: "No readings yet, so there is nothing to estimate from.",
+		}.flex("No readings to estimate from")
+	}
+	model, err := h.Model()
+	if err != nil {
+		return errorCard("Estimate", err)
+	}
+
+	minutes := math.Round(estimator.CoefficientsOf(model).Estimate(last.DiffTemp, last.DiffHum, last.Light))
+	c := card{
+		Title:   "⏱️ Estimated drying time",
+		Color:   colorInfo,
+		Summary: fmt.Sprintf("About %s in the current conditions.", duration(minutes)),
+		Fields: []field{
+			{"Based on", utils.FormatTimestamp(last.Timestamp)},
+			{"Humidity diff", fmt.Sprintf("%.1f%%", last.DiffHum)},
+			{"Temperature diff", fmt.Sprintf("%.1f°C", last.DiffTemp)},
+			{"Model", fmt.Sprintf("v%d", model.Version)},
+		},
+		Buttons: []string{"status", "rain"},
+	}
+
+	if active, err := h.Sessions.Active(); err == nil && active != nil {
+		elapsed := h.Now().Sub(active.StartedAt).Minutes()
+		remaining := math.Max(0, minutes-elapsed)
+		c.Fields = append(c.Fields,
+			field{"Drying for", duration(math.Round(elapsed))},
+			field{"Remaining", duration(math.Round(remaining))},
+		)
+	}
+	return c.flex(fmt.Sprintf("Estimated drying time: %s", duration(minutes)))
+}
+
+func (h *Handler) rain(ctx context.Context, _ request) *linebot.FlexMessage {
+	now := h.Now()
+	o, err := h.Outlook(ctx, now)
+	if err != nil {
+		return errorCard("Rain forecast", err)
+	}
+
+	c := card{Title: "🌤️ No rain expected", Color: colorOK, Buttons: []string{"status", "estimate"}}
+	var alt string
+	switch {
+	case o.RainingNow:
+		c.Title, c.Color = "☔ Raining now", colorError
+		c.Summary = "Take your clothes inside or don't dry them now!"
+		alt = "It is raining now"
+	case o.RainStartsAt != nil:
+		starts := o.RainStartsAt.In(time.Local).Format("15:04")
+		c.Title, c.Color = "🌦️ Rain expected", colorWarning
+		c.Summary = fmt.Sprintf("Rain is expected around %s.", starts)
+		alt = "Rain expected around " + starts
+	case o.Partial:
+		c.Title, c.Color = "🌤️ Not raining now", colorWarning
+		c.Summary = "The hourly forecast is unavailable, so rain later on is unknown."
+		alt = "Not raining now, forecast unavailable"
+	default:
+		c.Summary = "Safe to hang laundry for the whole forecast window."
+		alt = "No rain expected"
+	}
+	// Not every provider describes the current weather.
+	if o.Current != nil && o.Current.Description != "" {
+		c.Fields = append(c.Fields, field{"Now", o.Current.Description})
+	}
+	if !o.RainingNow && !o.Partial {
+		c.Fields = append(c.Fields, field{"Safe until", o.SafeUntil.In(time.Local).Format("15:04")})
+	}
+	for _, hour := range o.Hours {
+		if len(c.Fields) >= 8 {
+			break
+		}
+		c.Fields = append(c.Fields, field{
+			hour.Time.In(time.Local).Format("15:04"),
+			fmt.Sprintf("%.0f%%, %.1f mm", hour.PrecipProbability*100, hour.Precipitation),
+		})
+	}
+	return c.flex(alt)
+}
+
+func (h *Handler) start(context.Context, request) *linebot.FlexMessage {
+	s, err := h.Sessions.Start()
+	if errors.Is(err, sessions.ErrSessionActive) {
+		return card{
+			Title:   "🧺 Already drying",
+			Color:   colorWarning,
+			Summary: fmt.Sprintf("Session #%d has been running since %s.", s.TestID, clock(s.StartedAt)),
+			Buttons: []string{"stop", "estimate"},
+		}.flex(fmt.Sprintf("Session #%d is already running", s.TestID))
+	}
+	if err != nil {
+		return errorCard("Start", err)
+	}
+	return card{
+		Title:   "🧺 Drying started",
+		Color:   colorOK,
+		Summary: fmt.Sprintf("Session #%d started at %s.", s.TestID, clock(s.StartedAt)),
+		Buttons: []string{"estimate", "stop"},
+	}.flex(fmt.Sprintf("Drying session #%d started", s.TestID))
+}
+
+func (h *Handler) stop(context.Context, request) *linebot.FlexMessage {
+	active, err := h.Sessions.Active()
+	if err != nil {
+		return errorCard("Stop", err)
+	}
+	if active == nil {
+		return card{
+			Title:   "🧺 Nothing to stop",
+			Color:   colorWarning,
+			Summary: "There is no drying session running.",
+			Buttons: []string{"start"},
+		}.flex("No drying session is running")
+	}
+	s, err := h.Sessions.Stop(active.TestID, models.EndReasonManual)
+	if err != nil {
+		return errorCard("Stop", err)
+	}
+
+	c := card{
+		Title:   "🧺 Drying stopped",
+		Color:   colorInfo,
+		Summary: fmt.Sprintf("Session #%d stopped.", s.TestID),
+		Fields:  []field{{"Started", clock(s.StartedAt)}},
+	}
+	if s.EndedAt != nil {
+		c.Fields = append(c.Fields, field{"Stopped", clock(*s.EndedAt)})
+	}
+	return c.flex(fmt.Sprintf("Drying session #%d stopped", s.TestID))
+}
+
+func (h *Handler) subscribe(_ context.Context, req request) *linebot.FlexMessage {
+	return h.setAlerts(req, true)
+}
+
+func (h *Handler) unsubscribe(_ context.Context, req request) *linebot.FlexMessage {
+	return h.setAlerts(req, false)
+}
+
+func (h *Handler) setAlerts(req request, on bool) *linebot.FlexMessage {
+	topic := subscribers.TopicAll
+	if len(req.Args) > 0 {
+		topic = req.Args[0]
+	}
+	s, err := h.Subscribers.SetAlerts(req.Source.ID, req.Source.Type, topic, on)
+	if err != nil {
+		return errorCard("Alerts", err)
+	}
+	c := subscriptionCard(s)
+	if on {
+		c.Summary = "Subscribed. " + c.Summary
+	} else {
+		c.Summary = "Unsubscribed. " + c.Summary
+	}
+	return c.flex(c.Summary)
+}
+
+func (h *Handler) alerts(_ context.Context, req request) *linebot.FlexMessage {
+	s, err := h.Subscribers.Get(req.Source.ID)
+	if errors.Is(err, subscribers.ErrNotFound) {
+		s, err = &models.LineSubscriber{}, nil
+	}
+	if err != nil {
+		return errorCard("Alerts", err)
+	}
+	c := subscriptionCard(s)
+	return c.flex(c.Summary)
+}
+
+func subscriptionCard(s *models.LineSubscriber) card {
+	onOff := func(on bool) string {
+		if on && s.Active {
+			return "on"
+		}
+		return "off"
+	}
+	c := card{
+		Title: "🔔 Alerts",
+		Color: colorInfo,
+		Fields: []field{
+			{"Rain alerts", onOff(s.RainAlerts)},
+			{"Laundry dry", onOff(s.DryingAlerts)},
+		},
+		Buttons: []string{"subscribe", "unsubscribe"},
+	}
+	switch {
+	case s.Wants(models.AlertRain) && s.Wants(models.AlertDryingComplete):
+		c.Summary = "This chat gets rain and laundry dry alerts."
+	case s.Wants(models.AlertRain):
+		c.Summary = "This chat gets rain alerts only."
+	case s.Wants(models.AlertDryingComplete):
+		c.Summary = "This chat gets laundry dry alerts only."
+	default:
+		c.Color = colorWarning
+		c.Summary = "This chat gets no alerts."
+	}
+	return c
+}
+
+func (h *Handler) welcome() *linebot.FlexMessage {
+	c := helpCard("Hi! You will get rain and laundry dry alerts here. Send \"unsubscribe\" to stop them.")
+	return c.flex("Welcome to Time to Dry")
+}
+
+func (h *Handler) help(context.Context, request) *linebot.FlexMessage {
+	return helpCard("").flex("Commands: status, estimate, rain, start, stop, subscribe, unsubscribe, alerts, help")
+}
+
+func (h *Handler) unknown(text string) *linebot.FlexMessage {
+	return helpCard(fmt.Sprintf("Sorry, I don't know %q.", strings.TrimSpace(text))).
+		flex("Unknown command. Try: status, estimate, rain, start, stop, subscribe, unsubscribe, alerts, help")
+}
+
+func helpCard(summary string) card {
+	c := card{Title: "👕 Time to Dry", Color: colorInfo, Summary: summary}
+	if c.Summary == "" {
+		c.Summary = "Send one of these commands:"
+	}
+	for _, cmd := range commands {
+		c.Fields = append(c.Fields, field{cmd.name, cmd.description})
+	}
+	c.Buttons = []string{"status", "estimate", "rain"}
+	return c
+}
+
+func errorCard(title string, err error) *linebot.FlexMessage {
+	return card{
+		Title:   "⚠️ " + title,
+		Color:   colorError,
+		Summary: "Something went wrong: " + err.Error(),
+	}.flex(title + " failed")
+}
+
+// duration formats minutes as "1 h 25 min".
+func duration(minutes float64) string {
+	m := int(minutes)
+	if m < 60 {
+		return fmt.Sprintf("%d min", m)
+	}
+	return fmt.Sprintf("%d h %d min", m/60, m%60)
+}
+
+// clock formats a time as a time of day in the display zone.
+func clock(t time.Time) string {
+	return t.In(time.Local).Format("15:04")
+}
+
+func lastReading() (*models.TimeToDry, error) {
+	var last models.TimeToDry
+	err := database.DB.Order("timestamp desc").Limit(1).Find(&last).Error
+	if err != nil || last.ID == 0 {
+		return nil, err
+	}
+	return &last, nil
+}
+
+func outlookFromEnv(ctx context.Context, now time.Time) (*weather.RainOutlook, error) {
+	provider, err := weather.FromEnv()
+	if err != nil {
+		return nil, err
+	}
+	loc, err := weather.LocationFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	return weather.FetchRainOutlook(ctx, provider, loc, 12, weather.ThresholdsFromEnv(), now)
+}
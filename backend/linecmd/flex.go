@@ -0,0 +1,124 @@
+package linecmd
+
+import (
+	"github.com/line/line-bot-sdk-go/v7/linebot"
+)
+
+// Header colours of the reply cards.
+const (
+	colorInfo    = "#1E88E5"
+	colorOK      = "#43A047"
+	colorWarning = "#FB8C00"
+	colorError   = "#E53935"
+)
+
+// field is one label/value line of a card.
+type field struct {
+	Label string
+	Value string
+}
+
+// orDash returns s, or a dash when it is empty: LINE rejects text
+// components without text.
+func orDash(s string) string {
+	if s == "" {
+		return "–"
+	}
+	return s
+}
+
+// card is the content of a Flex bubble reply: a coloured header, an optional
+// summary line, label/value fields and quick command buttons.
+type card struct {
+	Title   string
+	Color   string
+	Summary string
+	Fields  []field
+	Buttons []string
+}
+
+// flex renders c as a Flex message. altText is what LINE shows in
+// notifications and on clients without Flex support.
+func (c card) flex(altText string) *linebot.FlexMessage {
+	body := []linebot.FlexComponent{}
+	if c.Summary != "" {
+		body = append(body, &linebot.TextComponent{
+			Type: linebot.FlexComponentTypeText,
+			Text: c.Summary,
+			Wrap: true,
+		})
+	}
+	if len(c.Summary) > 0 && len(c.Fields) > 0 {
+		body = append(body, &linebot.SeparatorComponent{
+			Type:   linebot.FlexComponentTypeSeparator,
+			Margin: linebot.FlexComponentMarginTypeMd,
+		})
+	}
+	for _, f := range c.Fields {
+		body = append(body, &linebot.BoxComponent{
+			Type:   linebot.FlexComponentTypeBox,
+			Layout: linebot.FlexBoxLayoutTypeBaseline,
+			Margin: linebot.FlexComponentMarginTypeMd,
+			Contents: []linebot.FlexComponent{
+				&linebot.TextComponent{
+					Type:  linebot.FlexComponentTypeText,
+					Text:  orDash(f.Label),
+					Size:  linebot.FlexTextSizeTypeSm,
+					Color: "#8C8C8C",
+					Flex:  linebot.IntPtr(2),
+				},
+				&linebot.TextComponent{
+					Type:  linebot.FlexComponentTypeText,
+					Text:  orDash(f.Value),
+					Size:  linebot.FlexTextSizeTypeSm,
+					Wrap:  true,
+					Align: linebot.FlexComponentAlignTypeEnd,
+					Flex:  linebot.IntPtr(3),
+				},
+			},
+		})
+	}
+
+	bubble := &linebot.BubbleContainer{
+		Type: linebot.FlexContainerTypeBubble,
+		Header: &linebot.BoxComponent{
+			Type:            linebot.FlexComponentTypeBox,
+			Layout:          linebot.FlexBoxLayoutTypeVertical,
+			BackgroundColor: c.Color,
+			Contents: []linebot.FlexComponent{
+				&linebot.TextComponent{
+					Type:   linebot.FlexComponentTypeText,
+					Text:   c.Title,
+					Size:   linebot.FlexTextSizeTypeXl,
+					Weight: linebot.FlexTextWeightTypeBold,
+					Color:  "#FFFFFF",
+				},
+			},
+		},
+		Body: &linebot.BoxComponent{
+			Type:     linebot.FlexComponentTypeBox,
+			Layout:   linebot.FlexBoxLayoutTypeVertical,
+			Contents: body,
+		},
+	}
+
+	if len(c.Buttons) > 0 {
+		buttons := make([]linebot.FlexComponent, len(c.Buttons))
+		for i, command := range c.Buttons {
+			buttons[i] = &linebot.ButtonComponent{
+				Type:   linebot.FlexComponentTypeButton,
+				Action: linebot.NewMessageAction(command, command),
+				Height: linebot.FlexButtonHeightTypeSm,
+				Style:  linebot.FlexButtonStyleTypeLink,
+			}
+		}
+		bubble.Footer = &linebot.BoxComponent{
+			Type:     linebot.FlexComponentTypeBox,
+			Layout:   linebot.FlexBoxLayoutTypeHorizontal,
+			Spacing:  linebot.FlexComponentSpacingTypeSm,
+			Contents: buttons,
+		}
+	}
+
+	return linebot.NewFlexMessage(altText, bubble)
+}
@@ -0,0 +1,311 @@
+// Package listing implements the time range, test_id, cursor pagination,
+// sort order and field selection query parameters shared by the endpoints
+// that list sensor and weather rows.
+package listing
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"backend/utils"
+
+	"gorm.io/gorm"
+)
+
+// Page size limits.
+const (
+	DefaultLimit = 100
+	MaxLimit     = 1000
+)
+
+// Sort orders.
+const (
+	Asc  = "asc"
+	Desc = "desc"
+)
+
+// Options describe the table being listed.
+type Options struct {
+	// Fields are the columns that may be selected with the fields parameter.
+	Fields []string
+	// TestID is whether the table has a test_id column to filter on.
+	TestID bool
+}
+
+// FieldsOf returns the JSON field names of a model struct, which are also
+// its column names.
+func FieldsOf(model any) []string {
+	var fields []string
+	t := reflect.TypeOf(model)
+	for i := range t.NumField() {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name != "" && name != "-" {
+			fields = append(fields, name)
+		}
+	}
+	return fields
+}
+
+// Params are the parsed query parameters of a list request.
+type Params struct {
+	// From and To bound timestamps, From inclusive and To exclusive. They
+	// are zero when unset.
+	From, To time.Time
+	TestID   int
+	Limit    int
+	Order    string
+	Cursor   *Cursor
+	// Fields are the selected fields, nil for all of them.
+	Fields []string
+}
+
+// Cursor marks the last row of a page; the next page continues after it in
+// the same order.
+type Cursor struct {
+	Timestamp time.Time `json:"ts"`
+	ID        uint      `json:"id"`
+}
+
+// Encode returns the opaque form of c used in next_cursor and cursor.
+func (c Cursor) Encode() string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// DecodeCursor parses a cursor returned in next_cursor.
+func DecodeCursor(s string) (*Cursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	var c Cursor
+	if err := json.Unmarshal(b, &c); err != nil {
+		return nil, err
+	}
+	if c.ID == 0 {
+		return nil, fmt.Errorf("cursor without id")
+	}
+	return &c, nil
+}
+
+// Errors maps invalid query parameters to what is wrong with them.
+type Errors map[string]string
+
+// Parse reads from, to, test_id, fields, limit, order and cursor. All
+// invalid parameters are reported at once.
+func Parse(q url.Values, opts Options) (Params, Errors) {
+	p, errs := ParseFilter(q, opts)
+	if errs == nil {
+		errs = Errors{}
+	}
+	p.Limit, p.Order = DefaultLimit, Asc
+
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > MaxLimit {
+			errs["limit"] = fmt.Sprintf("must be an integer between 1 and %d", MaxLimit)
+		} else {
+			p.Limit = n
+		}
+	}
+
+	if v := q.Get("order"); v != "" {
+		if v != Asc && v != Desc {
+			errs["order"] = "must be asc or desc"
+		} else {
+			p.Order = v
+		}
+	}
+
+	if v := q.Get("cursor"); v != "" {
+		c, err := DecodeCursor(v)
+		if err != nil {
+			errs["cursor"] = "is not a cursor returned by this endpoint"
+		} else {
+			p.Cursor = c
+		}
+	}
+
+	if len(errs) > 0 {
+		return p, errs
+	}
+	return p, nil
+}
+
+// ParseFilter reads only from, to, test_id and fields, for endpoints that
+// do not page through rows.
+func ParseFilter(q url.Values, opts Options) (Params, Errors) {
+	var p Params
+	errs := Errors{}
+
+	for _, name := range []string{"from", "to"} {
+		v := q.Get(name)
+		if v == "" {
+			continue
+		}
+		t, err := parseTime(v)
+		if err != nil {
+			errs[name] = "must be a date (2006-01-02), a timestamp (2006-01-02 15:04:05) or RFC 3339"
+			continue
+		}
+		if name == "from" {
+			p.From = t
+		} else {
+			p.To = t
+		}
+	}
+	if !p.From.IsZero() && !p.To.IsZero() && !p.From.Before(p.To) {
+		errs["to"] = "must be after from"
+	}
+
+	if v := q.Get("test_id"); v != "" {
+		id, err := strconv.Atoi(v)
+		switch {
+		case !opts.TestID:
+			errs["test_id"] = "is not supported by this endpoint"
+		case err != nil || id <= 0:
+			errs["test_id"] = "must be a positive integer"
+		default:
+			p.TestID = id
+		}
+	}
+
+	if v := q.Get("fields"); v != "" && len(opts.Fields) == 0 {
+		errs["fields"] = "is not supported by this endpoint"
+	} else if v != "" {
+		var unknown []string
+		for _, f := range strings.Split(v, ",") {
+			f = strings.TrimSpace(f)
+			switch {
+			case !slices.Contains(opts.Fields, f):
+				unknown = append(unknown, f)
+			case !slices.Contains(p.Fields, f):
+				p.Fields = append(p.Fields, f)
+			}
+		}
+		if len(unknown) > 0 {
+			errs["fields"] = fmt.Sprintf("unknown %s, expected any of %s", strings.Join(unknown, ", "), strings.Join(opts.Fields, ", "))
+		}
+	}
+
+	if len(errs) > 0 {
+		return p, errs
+	}
+	return p, nil
+}
+
+// parseTime accepts a date, a "2006-01-02 15:04:05" timestamp or RFC 3339,
+// in local time unless an offset is given.
+func parseTime(v string) (time.Time, error) {
+	if t, err := time.ParseInLocation("2006-01-02", v, time.Local); err == nil {
+		return t, nil
+	}
+	return utils.ParseLocalTimestamp(v)
+}
+
+// Filter applies the time range and test_id to db, for the page and for counting the total.
+func (p Params) Filter(db *gorm.DB) *gorm.DB {
+	if !p.From.IsZero() {
+		db = db.Where("timestamp >= ?", p.From.UTC())
+	}
+	if !p.To.IsZero() {
+		db = db.Where("timestamp < ?", p.To.UTC())
+	}
+	if p.TestID != 0 {
+		db = db.Where("test_id = ?", p.TestID)
+	}
+	return db
+}
+
+// Page applies the filter, cursor, order and limit to db. One row more than
+// the limit is requested to tell whether another page follows.
+func (p Params) Page(db *gorm.DB) *gorm.DB {
+	db = p.Filter(db)
+	op := ">"
+	if p.Order == Desc {
+		op = "<"
+	}
+	if c := p.Cursor; c != nil {
+		ts := c.Timestamp.UTC()
+		db = db.Where("(timestamp "+op+" ?) OR (timestamp = ? AND id "+op+" ?)", ts, ts, c.ID)
+	}
+	if p.Fields != nil {
+		// id and timestamp are needed for the next cursor.
+		columns := slices.Clone(p.Fields)
+		for _, key := range []string{"id", "timestamp"} {
+			if !slices.Contains(columns, key) {
+				columns = append(columns, key)
+			}
+		}
+		db = db.Select(columns)
+	}
+	return db.Order("timestamp " + p.Order).Order("id " + p.Order).Limit(p.Limit + 1)
+}
+
+// Pagination describes a page of results.
+type Pagination struct {
+	Limit int    `json:"limit"`
+	Order string `json:"order"`
+	// Count is the number of rows on this page.
+	Count int `json:"count"`
+	// Total is the number of rows matching the filters across all pages.
+	Total   int64 `json:"total"`
+	HasMore bool  `json:"has_more"`
+	// NextCursor is passed as cursor to fetch the next page.
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// Response is the body of a list endpoint.
+type Response struct {
+	Data       any        `json:"data"`
+	Pagination Pagination `json:"pagination"`
+}
+
+// Respond builds the response for rows fetched with Page, which returns up
+// to one row more than the limit. key returns the cursor of a row.
+func Respond[T any](p Params, rows []T, total int64, key func(T) Cursor) (Response, error) {
+	page := Pagination{Limit: p.Limit, Order: p.Order, Total: total}
+	if len(rows) > p.Limit {
+		rows = rows[:p.Limit]
+		page.HasMore = true
+		page.NextCursor = key(rows[len(rows)-1]).Encode()
+	}
+	page.Count = len(rows)
+
+	if p.Fields == nil {
+		return Response{Data: rows, Pagination: page}, nil
+	}
+	data, err := Project(rows, p.Fields)
+	if err != nil {
+		return Response{}, err
+	}
+	return Response{Data: data, Pagination: page}, nil
+}
+
+// Project keeps only the given JSON fields of each row.
+func Project[T any](rows []T, fields []string) ([]map[string]json.RawMessage, error) {
+	out := make([]map[string]json.RawMessage, 0, len(rows))
+	for _, row := range rows {
+		b, err := json.Marshal(row)
+		if err != nil {
+			return nil, err
+		}
+		var all map[string]json.RawMessage
+		if err := json.Unmarshal(b, &all); err != nil {
+			return nil, err
+		}
+		picked := make(map[string]json.RawMessage, len(fields))
+		for _, f := range fields {
+			picked[f] = all[f]
+		}
+		out = append(out, picked)
+	}
+	return out, nil
+}
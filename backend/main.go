@@ -7,24 +7,109 @@
 package main
 
 import (
+	"context"
 	"log"
 	"net/http"
+	"os"
 	"time"
 
+	"backend/combine"
 	"backend/config"
+	"backend/controllers"
 	"backend/database"
+	"backend/ingest"
 	"backend/routes"
+	"backend/sessions"
 	"backend/middleware"
+	"backend/rainwatch"
+	"backend/weather"
+	"backend/alerts"
+	"backend/notify"
+	"backend/events"
+	"backend/estimator"
+	"backend/importer"
+	"backend/migrations"
+	"backend/retention"
 
 	"github.com/gorilla/mux"
 )
 
 func main() {
 	config.LoadEnvVariables()
+	config.LoadDisplayZone()
+	// Package defaults are built before .env is loaded.
+	ingest.Default = ingest.NewIngestorFromEnv()
+	combine.Default = combine.NewFromEnv()
+	retention.Default = retention.NewFromEnv()
 	database.Connect()
+	migrator, err := migrations.New(database.DB)
+	if err != nil {
+		log.Fatalf("Failed to load migrations: %v", err)
+	}
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := migrator.RunCommand(os.Args[2:], os.Stdout); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	if migrations.AutoFromEnv(database.DB.Dialector.Name()) {
+		if _, err := migrator.Up(); err != nil {
+			log.Fatalf("Failed to migrate database: %v", err)
+		}
+	} else if pending, err := migrator.Pending(); err != nil {
+		log.Fatalf("Failed to check migrations: %v", err)
+	} else if len(pending) > 0 {
+		log.Fatalf("%d migrations pending, from %04d_%s: run `go run main.go migrate` or set DB_AUTO_MIGRATE=true", len(pending), pending[0].Version, pending[0].Name)
+	}
+	database.Migrate()
+
+	if len(os.Args) > 1 && os.Args[1] == "import" {
+		if err := importer.Default.RunCommand(os.Args[2:], os.Stdout); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "retention" {
+		if err := retention.Default.RunCommand(os.Args[2:], os.Stdout); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	if _, err := sessions.BackfillFromReadings(sessions.Default.Gap); err != nil {
+		log.Printf("Failed to backfill drying sessions: %v", err)
+	}
+	notifier, err := notify.FromEnv()
+	if err != nil {
+		log.Fatalf("Failed to configure notifications: %v", err)
+	}
+	log.Printf("Notifications go to: %s", notifier.Name())
+	dryingComplete := &alerts.DryingComplete{Notifier: notifier, Store: alerts.Default}
+	sessions.Default.OnEnd = dryingComplete.SessionEnded
+	go estimator.PublishETAs(context.Background(), events.Default, sessions.Default.Active)
+
+	go sessions.Default.Run(context.Background(), time.Minute)
+
+	mqttCfg := ingest.MQTTConfigFromEnv()
+	if mqttCfg.Broker != "" {
+		subscriber := ingest.NewMQTTSubscriber(mqttCfg, ingest.Default)
+		if err := subscriber.Start(); err != nil {
+			log.Fatalf("Failed to start MQTT ingestion: %v", err)
+		}
+		log.Printf("MQTT ingestion enabled for topic %s", mqttCfg.Topic)
+	} else {
+		log.Println("MQTT_BROKER not set, MQTT ingestion disabled")
+	}
+
+	if config.GetEnvBool("RAINWATCH_ENABLED", true) {
+		startRainWatch(notifier)
+	}
+	if config.GetEnvBool("RETENTION_ENABLED", false) {
+		go retention.Default.Run(context.Background())
+	}
 
 	r := mux.NewRouter()
-	routes.RegisterRoutes(r)
+	routes.RegisterRoutes(r, controllers.NewDataHandler(database.DB))
 	handler := middleware.CORS(r) 
 
 	srv := &http.Server{
@@ -39,4 +124,20 @@ func main() {
 	log.Println("Swagger started on http://localhost:8080/swagger/index.html")
 	log.Fatal(srv.ListenAndServe())
 	
-}
\ No newline at end of file
+}
+
+// startRainWatch polls the forecast in the background when a weather
+// provider and location are configured.
+func startRainWatch(notifier notify.Notifier) {
+	provider, err := weather.FromEnv()
+	if err != nil {
+		log.Println("Rain watch disabled:", err)
+		return
+	}
+	loc, err := weather.LocationFromEnv()
+	if err != nil {
+		log.Println("Rain watch disabled:", err)
+		return
+	}
+	go rainwatch.NewWatcherFromEnv(provider, loc, notifier).Run(context.Background())
+}
@@ -0,0 +1,72 @@
+package migrations
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// RunCommand runs the migrate subcommand:
+//
+//	go run main.go migrate [up]         apply the pending migrations
+//	go run main.go migrate down [n]     revert the last n migrations (default 1)
+//	go run main.go migrate status       list the migrations and when they were applied
+//	go run main.go migrate force <v>    record migrations up to v as applied without running them
+func (m *Migrator) RunCommand(args []string, out io.Writer) error {
+	cmd := "up"
+	if len(args) > 0 {
+		cmd, args = args[0], args[1:]
+	}
+	switch cmd {
+	case "up":
+		done, err := m.Up()
+		for _, mig := range done {
+			fmt.Fprintf(out, "applied %04d_%s\n", mig.Version, mig.Name)
+		}
+		if err == nil && len(done) == 0 {
+			fmt.Fprintln(out, "no pending migrations")
+		}
+		return err
+	case "down":
+		n := 1
+		if len(args) > 0 {
+			var err error
+			if n, err = strconv.Atoi(args[0]); err != nil || n < 1 {
+				return fmt.Errorf("invalid number of migrations %q", args[0])
+			}
+		}
+		done, err := m.Down(n)
+		for _, mig := range done {
+			fmt.Fprintf(out, "reverted %04d_%s\n", mig.Version, mig.Name)
+		}
+		return err
+	case "status":
+		statuses, err := m.Status()
+		if err != nil {
+			return err
+		}
+		for _, s := range statuses {
+			applied := "pending"
+			if s.AppliedAt != "" {
+				applied = "applied " + s.AppliedAt
+			}
+			fmt.Fprintf(out, "%04d_%-30s %s\n", s.Version, s.Name, applied)
+		}
+		return nil
+	case "force":
+		if len(args) == 0 {
+			return errors.New("force needs a version")
+		}
+		version, err := strconv.Atoi(args[0])
+		if err != nil {
+			return fmt.Errorf("invalid version %q", args[0])
+		}
+		if err := m.Force(version); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "schema version set to %04d\n", version)
+		return nil
+	}
+	return fmt.Errorf("unknown migrate command %q, expected up, down, status or force", cmd)
+}
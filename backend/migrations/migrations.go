@@ -0,0 +1,298 @@
+// Package migrations versions the schema of the sensor tables (time_to_dry,
+// tmd and combined_data) and of their rollups. Each migration is a pair of
+// up and down SQL scripts per database driver, embedded in the binary, or a
+// pair of Go functions when it converts data. The versions applied are
+// recorded in the schema_migrations table.
+//
+// The tables owned by the backend itself are still created by
+// database.Migrate; migration 0006 only converts the times they held as text.
+package migrations
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"backend/config"
+	"backend/utils"
+
+	"gorm.io/gorm"
+)
+
+//go:embed sql
+var scripts embed.FS
+
+// Table records the applied versions.
+const Table = "schema_migrations"
+
+// Migration is one step of the schema. Its scripts run first, then its
+// functions, in the same transaction.
+type Migration struct {
+	Version  int
+	Name     string
+	Up       string
+	Down     string
+	UpFunc   func(tx *gorm.DB) error
+	DownFunc func(tx *gorm.DB) error
+}
+
+// goMigrations are written in Go, for every driver.
+var goMigrations = []Migration{
+	{Version: 4, Name: "utc_timestamps", UpFunc: utcTimestampsUp, DownFunc: utcTimestampsDown},
+	{Version: 6, Name: "utc_app_timestamps", UpFunc: utcAppTimestampsUp, DownFunc: utcAppTimestampsDown},
+}
+
+// Load returns the migrations of a driver (mysql or sqlite) in version order.
+func Load(driver string) ([]Migration, error) {
+	dir := path.Join("sql", driver)
+	entries, err := fs.ReadDir(scripts, dir)
+	if err != nil {
+		return nil, fmt.Errorf("no migrations for driver %q", driver)
+	}
+	byVersion := map[int]*Migration{}
+	for _, e := range entries {
+		// 0001_create_sensor_tables.up.sql
+		base, direction, ok := strings.Cut(strings.TrimSuffix(e.Name(), ".sql"), ".")
+		prefix, name, ok2 := strings.Cut(base, "_")
+		version, err := strconv.Atoi(prefix)
+		if !ok || !ok2 || err != nil || (direction != "up" && direction != "down") {
+			return nil, fmt.Errorf("unexpected migration file %s", e.Name())
+		}
+		body, err := fs.ReadFile(scripts, path.Join(dir, e.Name()))
+		if err != nil {
+			return nil, err
+		}
+		m := byVersion[version]
+		if m == nil {
+			m = &Migration{Version: version, Name: name}
+			byVersion[version] = m
+		}
+		if direction == "up" {
+			m.Up = string(body)
+		} else {
+			m.Down = string(body)
+		}
+	}
+
+	for _, m := range goMigrations {
+		if byVersion[m.Version] != nil {
+			return nil, fmt.Errorf("migration %04d_%s is both a script and a function", m.Version, m.Name)
+		}
+		byVersion[m.Version] = &m
+	}
+
+	var migrations []Migration
+	for _, m := range byVersion {
+		if (m.Up == "") == (m.UpFunc == nil) || (m.Down == "") == (m.DownFunc == nil) {
+			return nil, fmt.Errorf("migration %04d_%s needs both an up and a down script or function", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	slices.SortFunc(migrations, func(a, b Migration) int { return a.Version - b.Version })
+	return migrations, nil
+}
+
+// Status is a migration and when it was applied, empty if it is pending.
+type Status struct {
+	Version   int    `json:"version"`
+	Name      string `json:"name"`
+	AppliedAt string `json:"applied_at,omitempty"`
+}
+
+// appliedMigration is a row of the schema_migrations table.
+type appliedMigration struct {
+	Version   int    `gorm:"primaryKey;autoIncrement:false"`
+	Name      string `gorm:"size:255"`
+	AppliedAt string `gorm:"size:19"`
+}
+
+func (appliedMigration) TableName() string {
+	return Table
+}
+
+// Migrator applies the migrations of its driver to DB.
+type Migrator struct {
+	DB         *gorm.DB
+	Migrations []Migration
+	Now        func() time.Time
+}
+
+// New returns a Migrator for db, with the migrations of its driver.
+func New(db *gorm.DB) (*Migrator, error) {
+	migrations, err := Load(db.Dialector.Name())
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{DB: db, Migrations: migrations, Now: time.Now}, nil
+}
+
+// AutoFromEnv reports whether pending migrations are applied on startup,
+// from DB_AUTO_MIGRATE. By default they are for SQLite, and not for MySQL
+// databases, whose schema used to be managed by hand. When they are not,
+// the server refuses to start until they are applied.
+func AutoFromEnv(driver string) bool {
+	return config.GetEnvBool("DB_AUTO_MIGRATE", driver == "sqlite")
+}
+
+func (m *Migrator) applied() (map[int]appliedMigration, error) {
+	if err := m.DB.AutoMigrate(&appliedMigration{}); err != nil {
+		return nil, err
+	}
+	var rows []appliedMigration
+	if err := m.DB.Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	applied := make(map[int]appliedMigration, len(rows))
+	for _, r := range rows {
+		applied[r.Version] = r
+	}
+	return applied, nil
+}
+
+// Status lists every migration with when it was applied.
+func (m *Migrator) Status() ([]Status, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+	statuses := make([]Status, len(m.Migrations))
+	for i, mig := range m.Migrations {
+		statuses[i] = Status{Version: mig.Version, Name: mig.Name, AppliedAt: applied[mig.Version].AppliedAt}
+	}
+	return statuses, nil
+}
+
+// Pending returns the migrations not applied yet, in version order.
+func (m *Migrator) Pending() ([]Migration, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+	var pending []Migration
+	for _, mig := range m.Migrations {
+		if _, ok := applied[mig.Version]; !ok {
+			pending = append(pending, mig)
+		}
+	}
+	return pending, nil
+}
+
+// Up applies the pending migrations in version order and returns them. It
+// stops at the first one that fails.
+func (m *Migrator) Up() ([]Migration, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+	var done []Migration
+	for _, mig := range m.Migrations {
+		if _, ok := applied[mig.Version]; ok {
+			continue
+		}
+		err := m.run(mig.Up, mig.UpFunc, func(tx *gorm.DB) error {
+			return tx.Create(&appliedMigration{Version: mig.Version, Name: mig.Name, AppliedAt: utils.FormatTimestamp(m.Now())}).Error
+		})
+		if err != nil {
+			return done, fmt.Errorf("migration %04d_%s: %w", mig.Version, mig.Name, err)
+		}
+		done = append(done, mig)
+	}
+	return done, nil
+}
+
+// Down reverts the last n applied migrations, newest first, and returns them.
+func (m *Migrator) Down(n int) ([]Migration, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+	var done []Migration
+	for i := len(m.Migrations) - 1; i >= 0 && len(done) < n; i-- {
+		mig := m.Migrations[i]
+		if _, ok := applied[mig.Version]; !ok {
+			continue
+		}
+		err := m.run(mig.Down, mig.DownFunc, func(tx *gorm.DB) error {
+			return tx.Delete(&appliedMigration{Version: mig.Version}).Error
+		})
+		if err != nil {
+			return done, fmt.Errorf("reverting migration %04d_%s: %w", mig.Version, mig.Name, err)
+		}
+		done = append(done, mig)
+	}
+	return done, nil
+}
+
+// Force records that exactly the migrations up to version are applied,
+// without running any script. It is meant for databases whose schema was
+// created by hand, or left half migrated by a failed script.
+func (m *Migrator) Force(version int) error {
+	if version != 0 && !slices.ContainsFunc(m.Migrations, func(mig Migration) bool { return mig.Version == version }) {
+		return fmt.Errorf("unknown migration version %d", version)
+	}
+	applied, err := m.applied()
+	if err != nil {
+		return err
+	}
+	return m.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("version > ?", version).Delete(&appliedMigration{}).Error; err != nil {
+			return err
+		}
+		for _, mig := range m.Migrations {
+			if _, ok := applied[mig.Version]; ok || mig.Version > version {
+				continue
+			}
+			if err := tx.Create(&appliedMigration{Version: mig.Version, Name: mig.Name, AppliedAt: utils.FormatTimestamp(m.Now())}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// run executes the statements of a script, fn when it is set, and then
+// record in a transaction. MySQL commits schema changes immediately, so a
+// failing MySQL migration can leave its earlier statements applied; see Force.
+func (m *Migrator) run(script string, fn, record func(tx *gorm.DB) error) error {
+	return m.DB.Transaction(func(tx *gorm.DB) error {
+		for _, stmt := range statements(script) {
+			if err := tx.Exec(stmt).Error; err != nil {
+				return err
+			}
+		}
+		if fn != nil {
+			if err := fn(tx); err != nil {
+				return err
+			}
+		}
+		return record(tx)
+	})
+}
+
+// statements splits a script on the semicolons that end a line, dropping
+// comment lines.
+func statements(script string) []string {
+	var stmts []string
+	var current strings.Builder
+	for _, line := range strings.Split(script, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "--") {
+			continue
+		}
+		current.WriteString(line)
+		current.WriteString("\n")
+		if strings.HasSuffix(trimmed, ";") {
+			stmts = append(stmts, strings.TrimSuffix(strings.TrimSpace(current.String()), ";"))
+			current.Reset()
+		}
+	}
+	if s := strings.TrimSpace(current.String()); s != "" {
+		stmts = append(stmts, s)
+	}
+	return stmts
+}
@@ -0,0 +1,44 @@
+-- The sensor tables, as they used to be imported through phpMyAdmin.
+CREATE TABLE IF NOT EXISTS time_to_dry (
+    id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    timestamp VARCHAR(19) NOT NULL,
+    lat DOUBLE NOT NULL DEFAULT 0,
+    lon DOUBLE NOT NULL DEFAULT 0,
+    light DOUBLE NOT NULL DEFAULT 0,
+    temp_in DOUBLE NOT NULL DEFAULT 0,
+    temp_out DOUBLE NOT NULL DEFAULT 0,
+    hum_in DOUBLE NOT NULL DEFAULT 0,
+    hum_out DOUBLE NOT NULL DEFAULT 0,
+    diff_temp DOUBLE NOT NULL DEFAULT 0,
+    diff_hum DOUBLE NOT NULL DEFAULT 0,
+    test_id BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS tmd (
+    id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    timestamp VARCHAR(19) NOT NULL,
+    temperature DOUBLE NOT NULL DEFAULT 0,
+    humidity DOUBLE NOT NULL DEFAULT 0,
+    rainfall DOUBLE NOT NULL DEFAULT 0,
+    PRIMARY KEY (id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS combined_data (
+    id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    timestamp VARCHAR(19) NOT NULL,
+    lat DOUBLE NOT NULL DEFAULT 0,
+    lon DOUBLE NOT NULL DEFAULT 0,
+    temp_in DOUBLE NOT NULL DEFAULT 0,
+    temp_out DOUBLE NOT NULL DEFAULT 0,
+    hum_in DOUBLE NOT NULL DEFAULT 0,
+    hum_out DOUBLE NOT NULL DEFAULT 0,
+    diff_temp DOUBLE NOT NULL DEFAULT 0,
+    diff_hum DOUBLE NOT NULL DEFAULT 0,
+    test_id BIGINT NOT NULL DEFAULT 0,
+    api_temp DOUBLE NOT NULL DEFAULT 0,
+    api_humidity DOUBLE NOT NULL DEFAULT 0,
+    rainfall DOUBLE NOT NULL DEFAULT 0,
+    created_at VARCHAR(19) NOT NULL DEFAULT '',
+    PRIMARY KEY (id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
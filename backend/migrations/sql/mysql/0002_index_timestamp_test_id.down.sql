@@ -0,0 +1,5 @@
+DROP INDEX idx_combined_data_test_id ON combined_data;
+DROP INDEX idx_combined_data_timestamp_test_id ON combined_data;
+DROP INDEX idx_tmd_timestamp ON tmd;
+DROP INDEX idx_time_to_dry_test_id ON time_to_dry;
+DROP INDEX idx_time_to_dry_timestamp ON time_to_dry;
@@ -0,0 +1,38 @@
+-- Time ranges are read by timestamp, drying sessions by test_id in time order.
+-- MySQL has no IF NOT EXISTS for indexes, and the tables may already have
+-- them when GORM created the schema, so each one is created only when missing.
+SET @ddl = IF((SELECT COUNT(*) FROM information_schema.statistics
+    WHERE table_schema = DATABASE() AND table_name = 'time_to_dry' AND index_name = 'idx_time_to_dry_timestamp') = 0,
+    'CREATE INDEX idx_time_to_dry_timestamp ON time_to_dry (timestamp)', 'DO 0');
+PREPARE ddl FROM @ddl;
+EXECUTE ddl;
+DEALLOCATE PREPARE ddl;
+SET @ddl = IF((SELECT COUNT(*) FROM information_schema.statistics
+    WHERE table_schema = DATABASE() AND table_name = 'time_to_dry' AND index_name = 'idx_time_to_dry_test_id') = 0,
+    'CREATE INDEX idx_time_to_dry_test_id ON time_to_dry (test_id, timestamp)', 'DO 0');
+PREPARE ddl FROM @ddl;
+EXECUTE ddl;
+DEALLOCATE PREPARE ddl;
+SET @ddl = IF((SELECT COUNT(*) FROM information_schema.statistics
+    WHERE table_schema = DATABASE() AND table_name = 'tmd' AND index_name = 'idx_tmd_timestamp') = 0,
+    'CREATE INDEX idx_tmd_timestamp ON tmd (timestamp)', 'DO 0');
+PREPARE ddl FROM @ddl;
+EXECUTE ddl;
+DEALLOCATE PREPARE ddl;
+
+-- Older versions could combine a reading twice; keep the first row.
+DELETE FROM combined_data WHERE id NOT IN (
+    SELECT id FROM (SELECT MIN(id) AS id FROM combined_data GROUP BY timestamp, test_id) AS keep
+);
+SET @ddl = IF((SELECT COUNT(*) FROM information_schema.statistics
+    WHERE table_schema = DATABASE() AND table_name = 'combined_data' AND index_name = 'idx_combined_data_timestamp_test_id') = 0,
+    'CREATE UNIQUE INDEX idx_combined_data_timestamp_test_id ON combined_data (timestamp, test_id)', 'DO 0');
+PREPARE ddl FROM @ddl;
+EXECUTE ddl;
+DEALLOCATE PREPARE ddl;
+SET @ddl = IF((SELECT COUNT(*) FROM information_schema.statistics
+    WHERE table_schema = DATABASE() AND table_name = 'combined_data' AND index_name = 'idx_combined_data_test_id') = 0,
+    'CREATE INDEX idx_combined_data_test_id ON combined_data (test_id, timestamp)', 'DO 0');
+PREPARE ddl FROM @ddl;
+EXECUTE ddl;
+DEALLOCATE PREPARE ddl;
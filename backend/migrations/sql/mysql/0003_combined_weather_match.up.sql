@@ -0,0 +1,14 @@
+-- The weather observation each combined row was matched with. The columns
+-- are added only when missing, as GORM may have created them already.
+SET @ddl = IF((SELECT COUNT(*) FROM information_schema.columns
+    WHERE table_schema = DATABASE() AND table_name = 'combined_data' AND column_name = 'weather_timestamp') = 0,
+    'ALTER TABLE combined_data ADD COLUMN weather_timestamp VARCHAR(19) NOT NULL DEFAULT ''''', 'DO 0');
+PREPARE ddl FROM @ddl;
+EXECUTE ddl;
+DEALLOCATE PREPARE ddl;
+SET @ddl = IF((SELECT COUNT(*) FROM information_schema.columns
+    WHERE table_schema = DATABASE() AND table_name = 'combined_data' AND column_name = 'weather_gap_seconds') = 0,
+    'ALTER TABLE combined_data ADD COLUMN weather_gap_seconds BIGINT NOT NULL DEFAULT 0', 'DO 0');
+PREPARE ddl FROM @ddl;
+EXECUTE ddl;
+DEALLOCATE PREPARE ddl;
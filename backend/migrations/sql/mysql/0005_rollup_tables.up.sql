@@ -0,0 +1,29 @@
+-- Per-minute and per-hour summaries of sensor rows removed by the retention
+-- job, one row per table, bucket, drying session and field.
+CREATE TABLE IF NOT EXISTS rollups_1m (
+    id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    source VARCHAR(32) NOT NULL,
+    bucket_start DATETIME NOT NULL,
+    test_id BIGINT NOT NULL DEFAULT 0,
+    field VARCHAR(32) NOT NULL,
+    count BIGINT NOT NULL DEFAULT 0,
+    min DOUBLE NOT NULL DEFAULT 0,
+    max DOUBLE NOT NULL DEFAULT 0,
+    avg DOUBLE NOT NULL DEFAULT 0,
+    PRIMARY KEY (id),
+    UNIQUE KEY idx_rollups_1m_bucket (source, bucket_start, test_id, field)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS rollups_1h (
+    id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    source VARCHAR(32) NOT NULL,
+    bucket_start DATETIME NOT NULL,
+    test_id BIGINT NOT NULL DEFAULT 0,
+    field VARCHAR(32) NOT NULL,
+    count BIGINT NOT NULL DEFAULT 0,
+    min DOUBLE NOT NULL DEFAULT 0,
+    max DOUBLE NOT NULL DEFAULT 0,
+    avg DOUBLE NOT NULL DEFAULT 0,
+    PRIMARY KEY (id),
+    UNIQUE KEY idx_rollups_1h_bucket (source, bucket_start, test_id, field)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS combined_data;
+DROP TABLE IF EXISTS tmd;
+DROP TABLE IF EXISTS time_to_dry;
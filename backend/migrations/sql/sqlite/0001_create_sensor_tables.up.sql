@@ -0,0 +1,41 @@
+-- The sensor tables, as they used to be imported through phpMyAdmin.
+CREATE TABLE IF NOT EXISTS time_to_dry (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    timestamp TEXT NOT NULL,
+    lat REAL NOT NULL DEFAULT 0,
+    lon REAL NOT NULL DEFAULT 0,
+    light REAL NOT NULL DEFAULT 0,
+    temp_in REAL NOT NULL DEFAULT 0,
+    temp_out REAL NOT NULL DEFAULT 0,
+    hum_in REAL NOT NULL DEFAULT 0,
+    hum_out REAL NOT NULL DEFAULT 0,
+    diff_temp REAL NOT NULL DEFAULT 0,
+    diff_hum REAL NOT NULL DEFAULT 0,
+    test_id INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE TABLE IF NOT EXISTS tmd (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    timestamp TEXT NOT NULL,
+    temperature REAL NOT NULL DEFAULT 0,
+    humidity REAL NOT NULL DEFAULT 0,
+    rainfall REAL NOT NULL DEFAULT 0
+);
+
+CREATE TABLE IF NOT EXISTS combined_data (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    timestamp TEXT NOT NULL,
+    lat REAL NOT NULL DEFAULT 0,
+    lon REAL NOT NULL DEFAULT 0,
+    temp_in REAL NOT NULL DEFAULT 0,
+    temp_out REAL NOT NULL DEFAULT 0,
+    hum_in REAL NOT NULL DEFAULT 0,
+    hum_out REAL NOT NULL DEFAULT 0,
+    diff_temp REAL NOT NULL DEFAULT 0,
+    diff_hum REAL NOT NULL DEFAULT 0,
+    test_id INTEGER NOT NULL DEFAULT 0,
+    api_temp REAL NOT NULL DEFAULT 0,
+    api_humidity REAL NOT NULL DEFAULT 0,
+    rainfall REAL NOT NULL DEFAULT 0,
+    created_at TEXT NOT NULL DEFAULT ''
+);
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_combined_data_test_id;
+DROP INDEX IF EXISTS idx_combined_data_timestamp_test_id;
+DROP INDEX IF EXISTS idx_tmd_timestamp;
+DROP INDEX IF EXISTS idx_time_to_dry_test_id;
+DROP INDEX IF EXISTS idx_time_to_dry_timestamp;
@@ -0,0 +1,11 @@
+-- Time ranges are read by timestamp, drying sessions by test_id in time order.
+CREATE INDEX IF NOT EXISTS idx_time_to_dry_timestamp ON time_to_dry (timestamp);
+CREATE INDEX IF NOT EXISTS idx_time_to_dry_test_id ON time_to_dry (test_id, timestamp);
+CREATE INDEX IF NOT EXISTS idx_tmd_timestamp ON tmd (timestamp);
+
+-- Older versions could combine a reading twice; keep the first row.
+DELETE FROM combined_data WHERE id NOT IN (
+    SELECT id FROM (SELECT MIN(id) AS id FROM combined_data GROUP BY timestamp, test_id) AS keep
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_combined_data_timestamp_test_id ON combined_data (timestamp, test_id);
+CREATE INDEX IF NOT EXISTS idx_combined_data_test_id ON combined_data (test_id, timestamp);
@@ -0,0 +1,2 @@
+ALTER TABLE combined_data DROP COLUMN weather_gap_seconds;
+ALTER TABLE combined_data DROP COLUMN weather_timestamp;
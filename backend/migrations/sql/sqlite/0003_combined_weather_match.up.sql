@@ -0,0 +1,3 @@
+-- The weather observation each combined row was matched with.
+ALTER TABLE combined_data ADD COLUMN weather_timestamp TEXT NOT NULL DEFAULT '';
+ALTER TABLE combined_data ADD COLUMN weather_gap_seconds INTEGER NOT NULL DEFAULT 0;
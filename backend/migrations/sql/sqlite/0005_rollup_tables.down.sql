@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS rollups_1h;
+DROP TABLE IF EXISTS rollups_1m;
@@ -0,0 +1,27 @@
+-- Per-minute and per-hour summaries of sensor rows removed by the retention
+-- job, one row per table, bucket, drying session and field.
+CREATE TABLE IF NOT EXISTS rollups_1m (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    source TEXT NOT NULL,
+    bucket_start DATETIME NOT NULL,
+    test_id INTEGER NOT NULL DEFAULT 0,
+    field TEXT NOT NULL,
+    count INTEGER NOT NULL DEFAULT 0,
+    min REAL NOT NULL DEFAULT 0,
+    max REAL NOT NULL DEFAULT 0,
+    avg REAL NOT NULL DEFAULT 0
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_rollups_1m_bucket ON rollups_1m (source, bucket_start, test_id, field);
+
+CREATE TABLE IF NOT EXISTS rollups_1h (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    source TEXT NOT NULL,
+    bucket_start DATETIME NOT NULL,
+    test_id INTEGER NOT NULL DEFAULT 0,
+    field TEXT NOT NULL,
+    count INTEGER NOT NULL DEFAULT 0,
+    min REAL NOT NULL DEFAULT 0,
+    max REAL NOT NULL DEFAULT 0,
+    avg REAL NOT NULL DEFAULT 0
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_rollups_1h_bucket ON rollups_1h (source, bucket_start, test_id, field);
@@ -0,0 +1,327 @@
+package migrations
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"backend/database"
+	"backend/utils"
+
+	"gorm.io/gorm"
+)
+
+// timeTables are timestamp columns, by table, and the indexes on them.
+type timeTables struct {
+	columns map[string][]string
+	indexes []timeIndex
+}
+
+type timeIndex struct{ table, name, create string }
+
+// sensorTimes are the timestamp columns of the sensor tables, and the
+// indexes from 0002_index_timestamp_test_id.
+var sensorTimes = timeTables{
+	columns: map[string][]string{
+		"time_to_dry":   {"timestamp"},
+		"tmd":           {"timestamp"},
+		"combined_data": {"timestamp", "created_at", "weather_timestamp"},
+	},
+	indexes: []timeIndex{
+		{"time_to_dry", "idx_time_to_dry_timestamp", "CREATE INDEX idx_time_to_dry_timestamp ON time_to_dry (timestamp)"},
+		{"time_to_dry", "idx_time_to_dry_test_id", "CREATE INDEX idx_time_to_dry_test_id ON time_to_dry (test_id, timestamp)"},
+		{"tmd", "idx_tmd_timestamp", "CREATE INDEX idx_tmd_timestamp ON tmd (timestamp)"},
+		{"combined_data", "idx_combined_data_timestamp_test_id", "CREATE UNIQUE INDEX idx_combined_data_timestamp_test_id ON combined_data (timestamp, test_id)"},
+		{"combined_data", "idx_combined_data_test_id", "CREATE INDEX idx_combined_data_test_id ON combined_data (test_id, timestamp)"},
+	},
+}
+
+// appTimes are the time columns of the tables created by database.Migrate.
+// A table that does not exist yet is skipped; database.Migrate creates it
+// with DATETIME columns.
+var appTimes = timeTables{
+	columns: map[string][]string{
+		"drying_sessions":  {"started_at", "last_reading_at", "ended_at"},
+		"alerts":           {"sent_at", "rain_starts_at", "acknowledged_at"},
+		"dry_time_models":  {"trained_at"},
+		"line_subscribers": {"subscribed_at", "updated_at"},
+		"watermarks":       {"updated_at"},
+	},
+	indexes: []timeIndex{
+		{"alerts", "idx_alerts_sent_at", "CREATE INDEX idx_alerts_sent_at ON alerts (sent_at)"},
+	},
+}
+
+// timeConversion converts a timestamp column with one UPDATE per UTC offset
+// of the local zone, so that daylight saving time is honoured.
+type timeConversion struct {
+	// columnType is the type of the converted column.
+	columnType string
+	// expr is the SQL converting %[1]s, and args its parameters for an
+	// offset of the local zone, in seconds east of UTC.
+	expr string
+	args func(offset int) []any
+	// filter selects the values expr can convert.
+	filter string
+	// parse reads a value selected by filter, and bound writes the start of
+	// a zone period as such a value.
+	parse func(string) (time.Time, error)
+	bound func(time.Time) any
+	// offsetExpr converts the values selected by offsetFilter, which carry
+	// their own offset, with a single UPDATE.
+	offsetExpr   string
+	offsetFilter string
+	// invalid selects the values that could not be converted, if any can
+	// be. The conversion fails when there are some.
+	invalid string
+}
+
+// utcTimestampsUp turns the timestamp text columns of the sensor tables, in
+// server local time, into DATETIME columns in UTC.
+func utcTimestampsUp(tx *gorm.DB) error {
+	return replaceTimeColumns(tx, toUTC(tx), sensorTimes)
+}
+
+// utcTimestampsDown turns the columns back into text in server local time.
+func utcTimestampsDown(tx *gorm.DB) error {
+	c := toLocalText(tx)
+	c.columnType = "VARCHAR(19) NOT NULL DEFAULT ''"
+	if tx.Dialector.Name() == database.SQLite {
+		c.columnType = "TEXT NOT NULL DEFAULT ''"
+	}
+	return replaceTimeColumns(tx, c, sensorTimes)
+}
+
+// utcAppTimestampsUp does the same for the drying sessions, alerts,
+// estimator models, LINE subscribers and watermarks.
+func utcAppTimestampsUp(tx *gorm.DB) error {
+	return replaceTimeColumns(tx, toUTC(tx), appTimes)
+}
+
+// utcAppTimestampsDown turns those columns back into text, as they were
+// created from string fields; a time that is not set stays NULL.
+func utcAppTimestampsDown(tx *gorm.DB) error {
+	c := toLocalText(tx)
+	c.columnType = "LONGTEXT"
+	if tx.Dialector.Name() == database.SQLite {
+		c.columnType = "TEXT"
+	}
+	return replaceTimeColumns(tx, c, appTimes)
+}
+
+// toUTC converts text in server local time into DATETIME in UTC. Existing
+// values are read in the local zone, that is DISPLAY_TIMEZONE when it is
+// set, or by their offset when they are RFC 3339. Empty values become NULL;
+// the conversion fails when a value is in neither format, so that it is not
+// lost.
+func toUTC(tx *gorm.DB) timeConversion {
+	c := timeConversion{
+		columnType: "DATETIME",
+		filter:     "%[1]s LIKE '____-__-__ __:__:__'",
+		parse: func(s string) (time.Time, error) {
+			return time.ParseInLocation(utils.TimestampLayout, s, time.Local)
+		},
+		bound:        func(t time.Time) any { return t.In(time.Local).Format(utils.TimestampLayout) },
+		offsetFilter: "%[1]s LIKE '____-__-__T__:__:__%%'",
+		invalid:      "%[1]s <> '' AND %[1]s_new IS NULL",
+	}
+	if tx.Dialector.Name() == database.SQLite {
+		// Stored like the times written by the driver.
+		c.expr = "strftime('%%Y-%%m-%%d %%H:%%M:%%S+00:00', %[1]s, ?)"
+		c.args = func(offset int) []any { return []any{fmt.Sprintf("%+d seconds", -offset)} }
+		c.offsetExpr = "strftime('%%Y-%%m-%%d %%H:%%M:%%S+00:00', %[1]s)"
+	} else {
+		c.expr = "CONVERT_TZ(STR_TO_DATE(%[1]s, '%%Y-%%m-%%d %%H:%%i:%%s'), ?, '+00:00')"
+		c.args = func(offset int) []any { return []any{mysqlOffset(offset)} }
+		// Fractions of a second are dropped, as the column keeps none.
+		c.offsetExpr = "CONVERT_TZ(STR_TO_DATE(LEFT(%[1]s, 19), '%%Y-%%m-%%dT%%H:%%i:%%s'), IF(RIGHT(%[1]s, 1) = 'Z', '+00:00', RIGHT(%[1]s, 6)), '+00:00')"
+	}
+	return c
+}
+
+// toLocalText converts DATETIME in UTC back into text in server local time.
+// The caller sets the column type.
+func toLocalText(tx *gorm.DB) timeConversion {
+	c := timeConversion{
+		filter: "%[1]s IS NOT NULL",
+		parse:  database.ParseTime,
+		bound:  func(t time.Time) any { return t.UTC() },
+	}
+	if tx.Dialector.Name() == database.SQLite {
+		c.expr = "strftime('%%Y-%%m-%%d %%H:%%M:%%S', %[1]s, ?)"
+		c.args = func(offset int) []any { return []any{fmt.Sprintf("%+d seconds", offset)} }
+	} else {
+		c.expr = "DATE_FORMAT(CONVERT_TZ(%[1]s, '+00:00', ?), '%%Y-%%m-%%d %%H:%%i:%%s')"
+		c.args = func(offset int) []any { return []any{mysqlOffset(offset)} }
+	}
+	return c
+}
+
+// mysqlOffset formats an offset for CONVERT_TZ, which needs no time zone
+// tables for offsets.
+func mysqlOffset(seconds int) string {
+	sign := '+'
+	if seconds < 0 {
+		sign, seconds = '-', -seconds
+	}
+	return fmt.Sprintf("%c%02d:%02d", sign, seconds/3600, seconds%3600/60)
+}
+
+// replaceTimeColumns replaces every column of tables by one of the type of
+// c, with the values converted by c. MySQL commits every schema change, so
+// each step is skipped when an earlier, failed run already did it.
+func replaceTimeColumns(tx *gorm.DB, c timeConversion, tables timeTables) error {
+	for _, idx := range tables.indexes {
+		if tx.Migrator().HasIndex(idx.table, idx.name) {
+			if err := tx.Migrator().DropIndex(idx.table, idx.name); err != nil {
+				return err
+			}
+		}
+	}
+	for table, columns := range tables.columns {
+		if !tx.Migrator().HasTable(table) {
+			continue
+		}
+		for _, column := range columns {
+			if err := replaceTimeColumn(tx, table, column, c); err != nil {
+				return fmt.Errorf("%s.%s: %w", table, column, err)
+			}
+		}
+	}
+	for _, idx := range tables.indexes {
+		if tx.Migrator().HasTable(idx.table) && !tx.Migrator().HasIndex(idx.table, idx.name) {
+			if err := tx.Exec(idx.create).Error; err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// replaceTimeColumn adds column_new, converts column into it, drops column
+// and renames column_new.
+func replaceTimeColumn(tx *gorm.DB, table, column string, c timeConversion) error {
+	hasOld := tx.Migrator().HasColumn(table, column)
+	if hasOld && !tx.Migrator().HasColumn(table, column+"_new") {
+		done, err := hasType(tx, table, column, c.columnType)
+		if err != nil || done {
+			return err
+		}
+		if err := tx.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s_new %s", table, column, c.columnType)).Error; err != nil {
+			return err
+		}
+	}
+	if hasOld {
+		if err := convertTimeColumn(tx, table, column, c); err != nil {
+			return err
+		}
+		if err := tx.Exec(fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s", table, column)).Error; err != nil {
+			return err
+		}
+	}
+	return tx.Exec(fmt.Sprintf("ALTER TABLE %s RENAME COLUMN %s_new TO %s", table, column, column)).Error
+}
+
+// hasType reports whether column already has the type of columnType.
+func hasType(tx *gorm.DB, table, column, columnType string) (bool, error) {
+	types, err := tx.Migrator().ColumnTypes(table)
+	if err != nil {
+		return false, err
+	}
+	name, _, _ := strings.Cut(columnType, " ")
+	name, _, _ = strings.Cut(name, "(")
+	for _, t := range types {
+		if t.Name() == column {
+			return strings.EqualFold(t.DatabaseTypeName(), name), nil
+		}
+	}
+	return false, nil
+}
+
+// convertTimeColumn sets column_new to column converted by c, with one
+// UPDATE per offset the local zone had between the oldest and newest value,
+// and fails when some values could not be converted.
+func convertTimeColumn(tx *gorm.DB, table, column string, c timeConversion) error {
+	if c.offsetExpr != "" {
+		update := fmt.Sprintf("UPDATE %s SET %s_new = %s WHERE %s", table, column, fmt.Sprintf(c.offsetExpr, column), fmt.Sprintf(c.offsetFilter, column))
+		if err := tx.Exec(update).Error; err != nil {
+			return err
+		}
+	}
+	if err := convertLocalTimes(tx, table, column, c); err != nil {
+		return err
+	}
+
+	if c.invalid != "" {
+		var invalid []string
+		err := tx.Table(table).Where(fmt.Sprintf(c.invalid, column)).Limit(3).Pluck(column, &invalid).Error
+		if err != nil {
+			return err
+		}
+		if len(invalid) > 0 {
+			return fmt.Errorf("values such as %q are neither %s nor RFC 3339; correct or delete them and migrate again", invalid, utils.TimestampLayout)
+		}
+	}
+	return nil
+}
+
+// convertLocalTimes converts the values selected by c.filter, one UPDATE per
+// offset of the local zone.
+func convertLocalTimes(tx *gorm.DB, table, column string, c timeConversion) error {
+	filter := fmt.Sprintf(c.filter, column)
+	var first, last sql.NullString
+	err := tx.Raw(fmt.Sprintf("SELECT MIN(%s), MAX(%s) FROM %s WHERE %s", column, column, table, filter)).Row().Scan(&first, &last)
+	if err != nil || !first.Valid {
+		return err
+	}
+	from, err := c.parse(first.String)
+	if err != nil {
+		return err
+	}
+	to, err := c.parse(last.String)
+	if err != nil {
+		return err
+	}
+
+	update := fmt.Sprintf("UPDATE %s SET %s_new = %s WHERE %s", table, column, fmt.Sprintf(c.expr, column), filter)
+	for _, p := range zonePeriods(from, to) {
+		query, args := update, c.args(p.offset)
+		if !p.start.IsZero() {
+			query += fmt.Sprintf(" AND %s >= ?", column)
+			args = append(args, c.bound(p.start))
+		}
+		if !p.end.IsZero() {
+			query += fmt.Sprintf(" AND %s < ?", column)
+			args = append(args, c.bound(p.end))
+		}
+		if err := tx.Exec(query, args...).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// zonePeriod is a time during which the local zone had the same offset. A
+// zero start or end leaves the period open.
+type zonePeriod struct {
+	start, end time.Time
+	offset     int
+}
+
+// zonePeriods splits the time from from to to by the offset of the local
+// zone. The first and last periods are open.
+func zonePeriods(from, to time.Time) []zonePeriod {
+	var periods []zonePeriod
+	t := from.In(time.Local)
+	start := time.Time{}
+	for {
+		_, offset := t.Zone()
+		_, end := t.ZoneBounds()
+		if end.IsZero() || !end.Before(to) {
+			return append(periods, zonePeriod{start: start, offset: offset})
+		}
+		periods = append(periods, zonePeriod{start: start, end: end, offset: offset})
+		start, t = end, end
+	}
+}
@@ -0,0 +1,39 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Alert kinds.
+const (
+	AlertRain     = "rain"
+	AlertAllClear = "all_clear"
+	// AlertDryingComplete is sent when a session ends because the laundry is dry.
+	AlertDryingComplete = "drying_complete"
+)
+
+// Alert is a notification that has been sent, kept so alerts are not
+// repeated after a restart and can be listed later.
+type Alert struct {
+	ID           uint       `gorm:"primaryKey" json:"id"`
+	Kind         string     `gorm:"size:32;index" json:"kind"`
+	Message      string     `gorm:"size:512" json:"message"`
+	RainStartsAt *time.Time `json:"rain_starts_at"`
+	SentAt       time.Time  `gorm:"index" json:"sent_at"`
+	// AcknowledgedAt is when a user confirmed seeing the alert, nil until then.
+	AcknowledgedAt *time.Time `json:"acknowledged_at"`
+}
+
+func (Alert) TableName() string {
+	return "alerts"
+}
+
+// BeforeSave stores the times in UTC.
+func (a *Alert) BeforeSave(*gorm.DB) error {
+	a.SentAt = StoredTime(a.SentAt)
+	a.RainStartsAt = storedTimePtr(a.RainStartsAt)
+	a.AcknowledgedAt = storedTimePtr(a.AcknowledgedAt)
+	return nil
+}
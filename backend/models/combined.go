@@ -1,23 +1,49 @@
 package models
 
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// CombinedData is a time_to_dry reading joined with the tmd weather at its
+// time. There is at most one row per reading timestamp and test_id. Times
+// are stored in UTC.
 type CombinedData struct {
-	ID          uint    `gorm:"primaryKey" json:"id"`
-	Timestamp   string  `json:"timestamp"`
-	Lat         float64 `json:"lat"`
-	Lon         float64 `json:"lon"`
-	TempIn      float64 `json:"temp_in"`
-	TempOut     float64 `json:"temp_out"`
-	HumIn       float64 `json:"hum_in"`
-	HumOut      float64 `json:"hum_out"`
-	DiffTemp    float64 `json:"diff_temp"`
-	DiffHum     float64 `json:"diff_hum"`
-	TestID      int     `json:"test_id"`
-	APITemp     float64 `json:"api_temp"`
-	APIHumidity float64 `json:"api_humidity"`
-	Rainfall    float64 `json:"rainfall"`
-	CreatedAt   string  `json:"created_at"`
+	ID          uint      `gorm:"primaryKey" json:"id" parquet:"id"`
+	Timestamp   time.Time `json:"timestamp" parquet:"timestamp"`
+	Lat         float64   `json:"lat" parquet:"lat"`
+	Lon         float64   `json:"lon" parquet:"lon"`
+	TempIn      float64   `json:"temp_in" parquet:"temp_in"`
+	TempOut     float64   `json:"temp_out" parquet:"temp_out"`
+	HumIn       float64   `json:"hum_in" parquet:"hum_in"`
+	HumOut      float64   `json:"hum_out" parquet:"hum_out"`
+	DiffTemp    float64   `json:"diff_temp" parquet:"diff_temp"`
+	DiffHum     float64   `json:"diff_hum" parquet:"diff_hum"`
+	TestID      int       `json:"test_id" parquet:"test_id"`
+	APITemp     float64   `json:"api_temp" parquet:"api_temp"`
+	APIHumidity float64   `json:"api_humidity" parquet:"api_humidity"`
+	Rainfall    float64   `json:"rainfall" parquet:"rainfall"`
+	CreatedAt   time.Time `json:"created_at" parquet:"created_at"`
+	// WeatherTimestamp is the tmd observation the weather fields come from,
+	// the nearer one when they were interpolated. It is nil on rows
+	// combined before it was recorded.
+	WeatherTimestamp *time.Time `json:"weather_timestamp" parquet:"weather_timestamp,optional"`
+	// WeatherGapSeconds is how far from the reading the farthest observation used is.
+	WeatherGapSeconds int64 `json:"weather_gap_seconds" parquet:"weather_gap_seconds"`
 }
 
 func (CombinedData) TableName() string {
 	return "combined_data"
-}
\ No newline at end of file
+}
+
+// BeforeSave stores the times in UTC.
+func (c *CombinedData) BeforeSave(*gorm.DB) error {
+	c.Timestamp = StoredTime(c.Timestamp)
+	c.CreatedAt = StoredTime(c.CreatedAt)
+	if c.WeatherTimestamp != nil {
+		at := StoredTime(*c.WeatherTimestamp)
+		c.WeatherTimestamp = &at
+	}
+	return nil
+}
@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// DryTimeModel is a versioned set of coefficients for the drying time
+// estimate: minutes = Intercept + CoefDiffTemp*diff_temp + CoefDiffHum*diff_hum + CoefLight*light.
+type DryTimeModel struct {
+	ID           uint      `gorm:"primaryKey" json:"id"`
+	Version      int       `gorm:"uniqueIndex" json:"version"`
+	Intercept    float64   `json:"intercept"`
+	CoefDiffTemp float64   `json:"coef_diff_temp"`
+	CoefDiffHum  float64   `json:"coef_diff_hum"`
+	CoefLight    float64   `json:"coef_light"`
+	Samples      int       `json:"samples"`
+	RMSE         float64   `json:"rmse"`
+	R2           float64   `json:"r2"`
+	Active       bool      `gorm:"index" json:"active"`
+	TrainedAt    time.Time `json:"trained_at"`
+}
+
+func (DryTimeModel) TableName() string {
+	return "dry_time_models"
+}
+
+// BeforeSave stores the time in UTC.
+func (m *DryTimeModel) BeforeSave(*gorm.DB) error {
+	m.TrainedAt = StoredTime(m.TrainedAt)
+	return nil
+}
@@ -0,0 +1,62 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Drying session states.
+const (
+	SessionActive    = "active"
+	SessionCompleted = "completed"
+)
+
+// How a drying session was started.
+const (
+	SessionOriginAuto   = "auto"
+	SessionOriginManual = "manual"
+	SessionOriginLegacy = "legacy"
+)
+
+// Why a drying session ended.
+const (
+	EndReasonManual      = "manual"
+	EndReasonDryDetected = "dry_detected"
+	EndReasonTimeout     = "timeout"
+	EndReasonRain        = "rain"
+)
+
+// DryingSession is one load of laundry on the line. Its TestID is the
+// test_id stamped on every time_to_dry reading taken during the session.
+type DryingSession struct {
+	ID            uint       `gorm:"primaryKey" json:"id"`
+	TestID        int        `gorm:"uniqueIndex" json:"test_id"`
+	Status        string     `gorm:"size:16;index" json:"status"`
+	Origin        string     `gorm:"size:16" json:"origin"`
+	StartedAt     time.Time  `json:"started_at"`
+	LastReadingAt time.Time  `json:"last_reading_at"`
+	EndedAt       *time.Time `json:"ended_at"`
+	EndReason     string     `gorm:"size:16" json:"end_reason,omitempty"`
+}
+
+func (DryingSession) TableName() string {
+	return "drying_sessions"
+}
+
+// BeforeSave stores the times in UTC.
+func (s *DryingSession) BeforeSave(*gorm.DB) error {
+	s.StartedAt = StoredTime(s.StartedAt)
+	s.LastReadingAt = StoredTime(s.LastReadingAt)
+	s.EndedAt = storedTimePtr(s.EndedAt)
+	return nil
+}
+
+// IsValidEndReason reports whether reason is one of the known end reasons.
+func IsValidEndReason(reason string) bool {
+	switch reason {
+	case EndReasonManual, EndReasonDryDetected, EndReasonTimeout, EndReasonRain:
+		return true
+	}
+	return false
+}
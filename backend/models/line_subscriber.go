@@ -0,0 +1,52 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// LINE chat types that can subscribe to alerts.
+const (
+	LineSourceUser  = "user"
+	LineSourceGroup = "group"
+	LineSourceRoom  = "room"
+)
+
+// LineSubscriber is a LINE user, group or room that receives alerts. Each
+// kind of alert can be switched off separately.
+type LineSubscriber struct {
+	ID           uint      `gorm:"primaryKey" json:"id"`
+	LineID       string    `gorm:"size:64;uniqueIndex" json:"line_id"`
+	SourceType   string    `gorm:"size:16" json:"source_type"`
+	Active       bool      `gorm:"index" json:"active"`
+	RainAlerts   bool      `json:"rain_alerts"`
+	DryingAlerts bool      `json:"drying_alerts"`
+	SubscribedAt time.Time `json:"subscribed_at"`
+	UpdatedAt    time.Time `gorm:"autoUpdateTime:false" json:"updated_at"`
+}
+
+func (LineSubscriber) TableName() string {
+	return "line_subscribers"
+}
+
+// BeforeSave stores the times in UTC.
+func (s *LineSubscriber) BeforeSave(*gorm.DB) error {
+	s.SubscribedAt = StoredTime(s.SubscribedAt)
+	s.UpdatedAt = StoredTime(s.UpdatedAt)
+	return nil
+}
+
+// Wants reports whether the subscriber receives alerts of the given kind.
+func (s LineSubscriber) Wants(kind string) bool {
+	if !s.Active {
+		return false
+	}
+	switch kind {
+	case AlertRain, AlertAllClear:
+		return s.RainAlerts
+	case AlertDryingComplete:
+		return s.DryingAlerts
+	}
+	return true
+}
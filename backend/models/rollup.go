@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Rollup tables, by bucket size.
+const (
+	RollupMinuteTable = "rollups_1m"
+	RollupHourTable   = "rollups_1h"
+)
+
+// Rollup summarises one field of the rows of a sensor table (Source) in a
+// minute or an hour starting at BucketStart, in UTC, for one drying session.
+// Rollups replace the rows removed by the retention job; they are stored in
+// RollupMinuteTable or RollupHourTable.
+type Rollup struct {
+	ID          uint      `gorm:"primaryKey" json:"-"`
+	Source      string    `json:"source"`
+	BucketStart time.Time `json:"bucket_start"`
+	TestID      int       `json:"test_id"`
+	Field       string    `json:"field"`
+	Count       int64     `json:"count"`
+	Min         float64   `json:"min"`
+	Max         float64   `json:"max"`
+	Avg         float64   `json:"avg"`
+}
+
+// BeforeSave stores the bucket start in UTC.
+func (r *Rollup) BeforeSave(*gorm.DB) error {
+	r.BucketStart = StoredTime(r.BucketStart)
+	return nil
+}
@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// StoredTime is t as the tables store it: in UTC, to the second.
+func StoredTime(t time.Time) time.Time {
+	return t.UTC().Truncate(time.Second)
+}
+
+// storedTimePtr is StoredTime for an optional time.
+func storedTimePtr(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	at := StoredTime(*t)
+	return &at
+}
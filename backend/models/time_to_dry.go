@@ -1,20 +1,33 @@
 package models
 
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// TimeToDry is a reading of the sensors on the line, stamped in UTC.
 type TimeToDry struct {
-	ID       uint    `gorm:"primaryKey" json:"id"`
-	Timestamp string  `json:"timestamp"`
-	Lat      float64 `json:"lat"`
-	Lon      float64 `json:"lon"`
-	Light    float64 `json:"light"`
-	TempIn   float64 `json:"temp_in"`
-	TempOut  float64 `json:"temp_out"`
-	HumIn    float64 `json:"hum_in"`
-	HumOut   float64 `json:"hum_out"`
-	DiffTemp float64 `json:"diff_temp"`
-	DiffHum  float64 `json:"diff_hum"`
-	TestID   int     `json:"test_id"`
+	ID        uint      `gorm:"primaryKey" json:"id" parquet:"id"`
+	Timestamp time.Time `json:"timestamp" parquet:"timestamp"`
+	Lat       float64   `json:"lat" parquet:"lat"`
+	Lon       float64   `json:"lon" parquet:"lon"`
+	Light     float64   `json:"light" parquet:"light"`
+	TempIn    float64   `json:"temp_in" parquet:"temp_in"`
+	TempOut   float64   `json:"temp_out" parquet:"temp_out"`
+	HumIn     float64   `json:"hum_in" parquet:"hum_in"`
+	HumOut    float64   `json:"hum_out" parquet:"hum_out"`
+	DiffTemp  float64   `json:"diff_temp" parquet:"diff_temp"`
+	DiffHum   float64   `json:"diff_hum" parquet:"diff_hum"`
+	TestID    int       `json:"test_id" parquet:"test_id"`
 }
 
 func (TimeToDry) TableName() string {
 	return "time_to_dry"
 }
+
+// BeforeSave stores the timestamp in UTC.
+func (r *TimeToDry) BeforeSave(*gorm.DB) error {
+	r.Timestamp = StoredTime(r.Timestamp)
+	return nil
+}
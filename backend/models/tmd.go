@@ -1,13 +1,26 @@
 package models
 
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// TMD is a weather observation, stamped in UTC.
 type TMD struct {
-	ID         uint    `gorm:"primaryKey" json:"id"`
-	Timestamp  string  `json:"timestamp"`
-	Temperature float64 `json:"temperature"`
-	Humidity    float64 `json:"humidity"`
-	Rainfall    float64 `json:"rainfall"`
+	ID          uint      `gorm:"primaryKey" json:"id" parquet:"id"`
+	Timestamp   time.Time `json:"timestamp" parquet:"timestamp"`
+	Temperature float64   `json:"temperature" parquet:"temperature"`
+	Humidity    float64   `json:"humidity" parquet:"humidity"`
+	Rainfall    float64   `json:"rainfall" parquet:"rainfall"`
 }
 
 func (TMD) TableName() string {
 	return "tmd"
-}
\ No newline at end of file
+}
+
+// BeforeSave stores the timestamp in UTC.
+func (r *TMD) BeforeSave(*gorm.DB) error {
+	r.Timestamp = StoredTime(r.Timestamp)
+	return nil
+}
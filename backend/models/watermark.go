@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Watermark records how far an incremental job has processed a table, by
+// the highest row id it has handled.
+type Watermark struct {
+	Name      string    `gorm:"primaryKey;size:64" json:"name"`
+	LastID    uint      `json:"last_id"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime:false" json:"updated_at"`
+}
+
+func (Watermark) TableName() string {
+	return "watermarks"
+}
+
+// BeforeSave stores the time in UTC.
+func (w *Watermark) BeforeSave(*gorm.DB) error {
+	w.UpdatedAt = StoredTime(w.UpdatedAt)
+	return nil
+}
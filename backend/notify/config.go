@@ -0,0 +1,69 @@
+package notify
+
+import (
+	"fmt"
+	"strings"
+
+	"backend/config"
+	"backend/subscribers"
+)
+
+// FromEnv builds the notifier for the channels listed in NOTIFY_CHANNELS
+// (comma separated: line, webhook, email, log; default "line"). Several
+// channels are combined into a Multi.
+func FromEnv() (Notifier, error) {
+	var channels Multi
+	for _, name := range splitList(config.GetEnv("NOTIFY_CHANNELS", "line")) {
+		n, err := channelFromEnv(name)
+		if err != nil {
+			return nil, err
+		}
+		channels = append(channels, n)
+	}
+	switch len(channels) {
+	case 0:
+		return &Log{}, nil
+	case 1:
+		return channels[0], nil
+	}
+	return channels, nil
+}
+
+func channelFromEnv(name string) (Notifier, error) {
+	switch name {
+	case "line":
+		return NewLINE(subscribers.Default, config.GetEnv("LINE_USER_ID", "")), nil
+	case "webhook":
+		url := config.GetEnv("NOTIFY_WEBHOOK_URL", "")
+		if url == "" {
+			return nil, fmt.Errorf("NOTIFY_WEBHOOK_URL is required for the webhook channel")
+		}
+		return &Webhook{URL: url, Secret: config.GetEnv("NOTIFY_WEBHOOK_SECRET", "")}, nil
+	case "email":
+		e := &Email{
+			Host:     config.GetEnv("SMTP_HOST", ""),
+			Port:     config.GetEnv("SMTP_PORT", "587"),
+			Username: config.GetEnv("SMTP_USER", ""),
+			Password: config.GetEnv("SMTP_PASS", ""),
+			From:     config.GetEnv("SMTP_FROM", ""),
+			To:       splitList(config.GetEnv("NOTIFY_EMAIL_TO", "")),
+		}
+		if e.Host == "" || e.From == "" || len(e.To) == 0 {
+			return nil, fmt.Errorf("SMTP_HOST, SMTP_FROM and NOTIFY_EMAIL_TO are required for the email channel")
+		}
+		return e, nil
+	case "log":
+		return &Log{}, nil
+	}
+	return nil, fmt.Errorf("unknown notify channel %q", name)
+}
+
+func splitList(s string) []string {
+	var out []string
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, strings.ToLower(part))
+		}
+	}
+	return out
+}
@@ -0,0 +1,75 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"backend/models"
+	"backend/subscribers"
+	"backend/utils"
+)
+
+// LINE sends messages to the LINE chats subscribed to the message kind.
+// Users are reached with one multicast, groups and rooms with a push each.
+// Until anyone has subscribed, messages go to FallbackUserID. When some of
+// the pushes or the multicast fail, Notify returns a *FanoutError.
+type LINE struct {
+	Subscribers    *subscribers.Registry
+	FallbackUserID string
+	Push           func(message, to string) error
+	Multicast      func(message string, to []string) error
+}
+
+// NewLINE returns a LINE notifier for the subscribers in registry.
+func NewLINE(registry *subscribers.Registry, fallbackUserID string) *LINE {
+	return &LINE{
+		Subscribers:    registry,
+		FallbackUserID: fallbackUserID,
+		Push:           utils.PushLineMessage,
+		Multicast:      utils.MulticastLineMessage,
+	}
+}
+
+func (*LINE) Name() string { return "line" }
+
+func (n *LINE) Notify(_ context.Context, msg Message) error {
+	recipients, known, err := n.Subscribers.Recipients(msg.Kind)
+	if err != nil {
+		return fmt.Errorf("load LINE subscribers: %w", err)
+	}
+	text := msg.Text()
+	if !known {
+		if n.FallbackUserID == "" {
+			return errors.New("no LINE subscribers and no LINE_USER_ID configured")
+		}
+		return n.Push(text, n.FallbackUserID)
+	}
+
+	// Each push and the multicast is a channel of the fan-out.
+	var users []string
+	var sent int
+	errs := map[string]error{}
+	for _, s := range recipients {
+		if s.SourceType == models.LineSourceUser {
+			users = append(users, s.LineID)
+			continue
+		}
+		if err := n.Push(text, s.LineID); err != nil {
+			errs[fmt.Sprintf("line push to %s %s", s.SourceType, s.LineID)] = err
+		} else {
+			sent++
+		}
+	}
+	if len(users) > 0 {
+		if err := n.Multicast(text, users); err != nil {
+			errs[fmt.Sprintf("line multicast to %d users", len(users))] = err
+		} else {
+			sent++
+		}
+	}
+	if len(errs) > 0 {
+		return &FanoutError{Errors: errs, Delivered: sent}
+	}
+	return nil
+}
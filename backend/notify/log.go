@@ -0,0 +1,32 @@
+package notify
+
+import (
+	"context"
+	"log"
+	"sync"
+)
+
+// Log writes messages to the standard logger and keeps them in memory. It
+// never fails, which makes it the notifier to use in tests and when no
+// other channel is configured.
+type Log struct {
+	mu   sync.Mutex
+	sent []Message
+}
+
+func (*Log) Name() string { return "log" }
+
+func (n *Log) Notify(_ context.Context, msg Message) error {
+	log.Printf("Notify [%s] %s", msg.Kind, msg.Text())
+	n.mu.Lock()
+	n.sent = append(n.sent, msg)
+	n.mu.Unlock()
+	return nil
+}
+
+// Sent returns the messages notified so far.
+func (n *Log) Sent() []Message {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return append([]Message(nil), n.sent...)
+}
@@ -0,0 +1,99 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Message is a notification sent to the household.
+type Message struct {
+	// Kind is the alert kind, e.g. models.AlertRain.
+	Kind  string `json:"kind"`
+	Title string `json:"title"`
+	Body  string `json:"body"`
+}
+
+// Text returns the message as plain text, title first.
+func (m Message) Text() string {
+	if m.Title == "" {
+		return m.Body
+	}
+	return m.Title + "\n" + m.Body
+}
+
+// Notifier delivers messages over one channel.
+type Notifier interface {
+	Name() string
+	Notify(ctx context.Context, msg Message) error
+}
+
+// FanoutError reports the channels of a Multi, or the recipients of a
+// notifier, that failed.
+type FanoutError struct {
+	// Errors maps channel names to their error.
+	Errors map[string]error
+	// Delivered is the number of channels that succeeded. A channel that is
+	// itself a fan-out counts when it reached some of its recipients.
+	Delivered int
+}
+
+func (e *FanoutError) Error() string {
+	names := make([]string, 0, len(e.Errors))
+	for name := range e.Errors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = fmt.Sprintf("%s: %v", name, e.Errors[name])
+	}
+	return "notify failed on " + strings.Join(parts, "; ")
+}
+
+// Multi sends every message to all of its notifiers in parallel. One
+// failing channel does not stop the others; failures are collected in a
+// *FanoutError.
+type Multi []Notifier
+
+func (m Multi) Name() string {
+	names := make([]string, len(m))
+	for i, n := range m {
+		names[i] = n.Name()
+	}
+	return strings.Join(names, ",")
+}
+
+func (m Multi) Notify(ctx context.Context, msg Message) error {
+	var (
+		mu        sync.Mutex
+		wg        sync.WaitGroup
+		errs      = map[string]error{}
+		delivered int
+	)
+	for _, n := range m {
+		wg.Add(1)
+		go func(n Notifier) {
+			defer wg.Done()
+			if err := n.Notify(ctx, msg); err != nil {
+				var partial *FanoutError
+				mu.Lock()
+				errs[n.Name()] = err
+				if errors.As(err, &partial) && partial.Delivered > 0 {
+					delivered++
+				}
+				mu.Unlock()
+			}
+		}(n)
+	}
+	wg.Wait()
+
+	if len(errs) > 0 {
+		return &FanoutError{Errors: errs, Delivered: len(m) - len(errs) + delivered}
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// SignatureHeader carries the HMAC-SHA256 of the webhook body, as "sha256=<hex>".
+const SignatureHeader = "X-Signature-256"
+
+// Webhook POSTs messages as JSON to a URL. When Secret is set, the body is
+// signed so the receiver can verify it came from this server.
+type Webhook struct {
+	URL    string
+	Secret string
+	Client *http.Client
+	Now    func() time.Time
+}
+
+type webhookPayload struct {
+	Message
+	SentAt string `json:"sent_at"`
+}
+
+func (*Webhook) Name() string { return "webhook" }
+
+func (n *Webhook) Notify(ctx context.Context, msg Message) error {
+	now := time.Now
+	if n.Now != nil {
+		now = n.Now
+	}
+	body, err := json.Marshal(webhookPayload{Message: msg, SentAt: now().Format(time.RFC3339)})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if n.Secret != "" {
+		req.Header.Set(SignatureHeader, Sign(n.Secret, body))
+	}
+
+	client := n.Client
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
+
+// Sign returns the signature header value for body.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifySignature reports whether signature is a valid signature of body.
+func VerifySignature(secret string, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, body)), []byte(signature))
+}
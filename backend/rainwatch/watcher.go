@@ -0,0 +1,181 @@
+package rainwatch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"backend/alerts"
+	"backend/config"
+	"backend/models"
+	"backend/notify"
+	"backend/sessions"
+	"backend/utils"
+	"backend/weather"
+)
+
+// Watcher polls the rain forecast and sends one alert per rain event,
+// followed by an "all clear" once the forecast is dry again. The last sent
+// alert is read back from the store, so a restart does not repeat it.
+type Watcher struct {
+	Provider   weather.Provider
+	Location   weather.Location
+	Thresholds weather.Thresholds
+	// Interval is how often the forecast is polled.
+	Interval time.Duration
+	// Hours is the forecast window.
+	Hours int
+	// Lead is how far ahead rain triggers an alert.
+	Lead time.Duration
+	// Cooldown is the minimum time between two alerts, so a forecast that
+	// flips between rain and dry does not spam.
+	Cooldown time.Duration
+	Notifier notify.Notifier
+	Store    alerts.Store
+	// Sessions, when set, has its running session ended with the reason
+	// rain by a rain alert, as the laundry is taken in.
+	Sessions Sessions
+	Now      func() time.Time
+}
+
+// Sessions ends drying sessions. sessions.Manager implements it.
+type Sessions interface {
+	Active() (*models.DryingSession, error)
+	Stop(testID int, reason string) (*models.DryingSession, error)
+}
+
+// NewWatcherFromEnv configures a Watcher from RAINWATCH_INTERVAL, RAINWATCH_HOURS,
+// RAINWATCH_LEAD and RAINWATCH_COOLDOWN. Alerts are sent through notifier.
+func NewWatcherFromEnv(provider weather.Provider, loc weather.Location, notifier notify.Notifier) *Watcher {
+	hours, err := strconv.Atoi(config.GetEnv("RAINWATCH_HOURS", "12"))
+	if err != nil || hours < 1 {
+		hours = 12
+	}
+	return &Watcher{
+		Provider:   provider,
+		Location:   loc,
+		Thresholds: weather.ThresholdsFromEnv(),
+		Interval:   config.GetEnvDuration("RAINWATCH_INTERVAL", 15*time.Minute),
+		Hours:      hours,
+		Lead:       config.GetEnvDuration("RAINWATCH_LEAD", 3*time.Hour),
+		Cooldown:   config.GetEnvDuration("RAINWATCH_COOLDOWN", time.Hour),
+		Notifier:   notifier,
+		Store:      alerts.Default,
+		Sessions:   sessions.Default,
+		Now:        time.Now,
+	}
+}
+
+// Run checks the forecast immediately and then every Interval until ctx is cancelled.
+func (w *Watcher) Run(ctx context.Context) {
+	log.Printf("Rain watch started: polling %s every %v", w.Provider.Name(), w.Interval)
+	ticker := time.NewTicker(w.Interval)
+	defer ticker.Stop()
+	for {
+		if _, err := w.Check(ctx); err != nil {
+			log.Println("Rain watch check failed:", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Check polls the forecast once and sends an alert if the rain state
+// changed. It returns the alert that was sent, if any.
+func (w *Watcher) Check(ctx context.Context) (*models.Alert, error) {
+	now := w.Now()
+	outlook, err := weather.FetchRainOutlook(ctx, w.Provider, w.Location, w.Hours, w.Thresholds, now)
+	if err != nil {
+		return nil, fmt.Errorf("fetch forecast: %w", err)
+	}
+
+	last, err := w.Store.Last(models.AlertRain, models.AlertAllClear)
+	if err != nil {
+		return nil, fmt.Errorf("load last alert: %w", err)
+	}
+	inRainEvent := last != nil && last.Kind == models.AlertRain
+
+	var (
+		msg   notify.Message
+		alert *models.Alert
+	)
+	switch rainy := outlook.RainSoon(now, w.Lead); {
+	case rainy && !inRainEvent:
+		msg = notify.Message{Kind: models.AlertRain, Title: "Rain alert", Body: rainMessage(outlook, now)}
+		alert = &models.Alert{Kind: msg.Kind, Message: msg.Body, RainStartsAt: outlook.RainStartsAt}
+	// An all clear needs the hourly forecast: without it, rain to come is unknown.
+	case !rainy && inRainEvent && !outlook.Partial:
+		msg = notify.Message{Kind: models.AlertAllClear, Title: "All clear", Body: allClearMessage(outlook)}
+		alert = &models.Alert{Kind: msg.Kind, Message: msg.Body}
+	default:
+		return nil, nil
+	}
+
+	if last != nil && w.Cooldown > 0 {
+		if now.Sub(last.SentAt) < w.Cooldown {
+			log.Printf("Rain watch: %s alert held back, last alert was sent at %s", alert.Kind, utils.FormatTimestamp(last.SentAt))
+			return nil, nil
+		}
+	}
+
+	// A partial fan-out failure still counts as sent, so the channels that
+	// did deliver are not spammed on the next poll.
+	if err := w.Notifier.Notify(ctx, msg); err != nil {
+		var fanout *notify.FanoutError
+		if !errors.As(err, &fanout) || fanout.Delivered == 0 {
+			return nil, fmt.Errorf("send %s alert: %w", alert.Kind, err)
+		}
+		log.Printf("Rain watch: %v", err)
+	}
+	if alert.Kind == models.AlertRain {
+		w.endSession()
+	}
+	alert.SentAt = now
+	if err := w.Store.Record(alert); err != nil {
+		return alert, fmt.Errorf("record %s alert: %w", alert.Kind, err)
+	}
+	log.Printf("Rain watch: sent %s alert", alert.Kind)
+	return alert, nil
+}
+
+// endSession ends the running drying session, if any, because of rain.
+func (w *Watcher) endSession() {
+	if w.Sessions == nil {
+		return
+	}
+	active, err := w.Sessions.Active()
+	if err == nil && active != nil {
+		_, err = w.Sessions.Stop(active.TestID, models.EndReasonRain)
+		if err == nil {
+			log.Printf("Rain watch: ended drying session %d", active.TestID)
+		}
+	}
+	if err != nil && !errors.Is(err, sessions.ErrSessionEnded) {
+		log.Printf("Rain watch: failed to end the drying session: %v", err)
+	}
+}
+
+func rainMessage(o *weather.RainOutlook, now time.Time) string {
+	if o.RainingNow || o.RainStartsAt == nil || !o.RainStartsAt.After(now) {
+		return "☔ It is raining now. Take your clothes inside or don't dry them now!"
+	}
+	msg := fmt.Sprintf("☔ Rain expected around %s", o.RainStartsAt.In(time.Local).Format("15:04"))
+	for _, h := range o.Hours {
+		if h.Rain {
+			msg += fmt.Sprintf(" (%.0f%% chance, %.1f mm)", h.PrecipProbability*100, h.Precipitation)
+			break
+		}
+	}
+	return msg + ". Take your clothes inside or don't dry them now!"
+}
+
+func allClearMessage(o *weather.RainOutlook) string {
+	return fmt.Sprintf("🌤️ All clear: no rain expected soon. Safe to hang laundry until %s.",
+		o.SafeUntil.In(time.Local).Format("15:04"))
+}
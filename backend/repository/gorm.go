@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"backend/listing"
+	"backend/models"
+
+	"gorm.io/gorm"
+)
+
+// gormPage answers Table.Page for the table of T in db.
+func gormPage[T any](db *gorm.DB, p listing.Params) ([]T, int64, error) {
+	var model T
+	var total int64
+	if err := p.Filter(db.Model(&model)).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	rows := []T{}
+	if err := p.Page(db.Model(&model)).Find(&rows).Error; err != nil {
+		return nil, 0, err
+	}
+	return rows, total, nil
+}
+
+// GormReadings reads the time_to_dry table of DB.
+type GormReadings struct {
+	DB *gorm.DB
+}
+
+func (r GormReadings) Page(p listing.Params) ([]models.TimeToDry, int64, error) {
+	return gormPage[models.TimeToDry](r.DB, p)
+}
+
+func (r GormReadings) ForTest(testID int) ([]models.TimeToDry, error) {
+	rows := []models.TimeToDry{}
+	err := r.DB.Where("test_id = ?", testID).Order("timestamp").Order("id").Find(&rows).Error
+	return rows, err
+}
+
+func (r GormReadings) Last(testID int) (*models.TimeToDry, error) {
+	db := r.DB
+	if testID != 0 {
+		db = db.Where("test_id = ?", testID)
+	}
+	var last models.TimeToDry
+	err := db.Order("timestamp desc").Order("id desc").First(&last).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &last, nil
+}
+
+// GormWeather reads the tmd table of DB.
+type GormWeather struct {
+	DB *gorm.DB
+}
+
+func (r GormWeather) Page(p listing.Params) ([]models.TMD, int64, error) {
+	return gormPage[models.TMD](r.DB, p)
+}
+
+func (r GormWeather) Between(from, to time.Time) ([]models.TMD, error) {
+	rows := []models.TMD{}
+	err := r.DB.Where("timestamp BETWEEN ? AND ?", from.UTC(), to.UTC()).Order("timestamp").Order("id").Find(&rows).Error
+	return rows, err
+}
+
+func (r GormWeather) Recent(since time.Time, limit int) ([]models.TMD, error) {
+	rows := []models.TMD{}
+	err := r.DB.Where("timestamp >= ?", since.UTC()).Order("timestamp desc").Order("id desc").Limit(limit).Find(&rows).Error
+	return rows, err
+}
+
+// GormCombined reads the combined_data table of DB.
+type GormCombined struct {
+	DB *gorm.DB
+}
+
+func (r GormCombined) Page(p listing.Params) ([]models.CombinedData, int64, error) {
+	return gormPage[models.CombinedData](r.DB, p)
+}
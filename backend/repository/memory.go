@@ -0,0 +1,159 @@
+package repository
+
+import (
+	"cmp"
+	"slices"
+	"sync"
+	"time"
+
+	"backend/listing"
+	"backend/models"
+)
+
+// memoryTable keeps the rows of a table in memory, ordered by timestamp and
+// id. key returns the id, timestamp and test_id of a row.
+type memoryTable[T any] struct {
+	mu     sync.Mutex
+	rows   []T
+	nextID uint
+	key    func(r *T) (id *uint, timestamp time.Time, testID int)
+}
+
+func newMemoryTable[T any](key func(r *T) (*uint, time.Time, int)) memoryTable[T] {
+	return memoryTable[T]{nextID: 1, key: key}
+}
+
+// Add stores rows, giving an id to those without one.
+func (m *memoryTable[T]) Add(rows ...T) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, r := range rows {
+		id, _, _ := m.key(&r)
+		if *id == 0 {
+			*id = m.nextID
+		}
+		m.nextID = max(m.nextID, *id+1)
+		m.rows = append(m.rows, r)
+	}
+	slices.SortStableFunc(m.rows, m.compare)
+}
+
+func (m *memoryTable[T]) compare(a, b T) int {
+	idA, tsA, _ := m.key(&a)
+	idB, tsB, _ := m.key(&b)
+	if c := tsA.Compare(tsB); c != 0 {
+		return c
+	}
+	return cmp.Compare(*idA, *idB)
+}
+
+// where returns the rows keep accepts, oldest first.
+func (m *memoryTable[T]) where(keep func(timestamp time.Time, testID int) bool) []T {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	rows := []T{}
+	for _, r := range m.rows {
+		if _, ts, testID := m.key(&r); keep(ts, testID) {
+			rows = append(rows, r)
+		}
+	}
+	return rows
+}
+
+// Page filters, orders and pages the rows like listing.Params.Page. Fields
+// are left to listing.Respond.
+func (m *memoryTable[T]) Page(p listing.Params) ([]T, int64, error) {
+	rows := m.where(func(ts time.Time, testID int) bool {
+		return (p.From.IsZero() || !ts.Before(p.From)) &&
+			(p.To.IsZero() || ts.Before(p.To)) &&
+			(p.TestID == 0 || testID == p.TestID)
+	})
+	total := int64(len(rows))
+	if p.Order == listing.Desc {
+		slices.Reverse(rows)
+	}
+	if c := p.Cursor; c != nil {
+		rows = slices.DeleteFunc(rows, func(r T) bool {
+			id, ts, _ := m.key(&r)
+			order := ts.Compare(c.Timestamp)
+			if order == 0 {
+				order = cmp.Compare(*id, c.ID)
+			}
+			if p.Order == listing.Desc {
+				return order >= 0
+			}
+			return order <= 0
+		})
+	}
+	if len(rows) > p.Limit+1 {
+		rows = rows[:p.Limit+1]
+	}
+	return rows, total, nil
+}
+
+// MemoryReadings keeps readings in memory. It is meant for tests.
+type MemoryReadings struct {
+	memoryTable[models.TimeToDry]
+}
+
+// NewMemoryReadings returns a MemoryReadings holding rows.
+func NewMemoryReadings(rows ...models.TimeToDry) *MemoryReadings {
+	m := &MemoryReadings{newMemoryTable(func(r *models.TimeToDry) (*uint, time.Time, int) {
+		return &r.ID, r.Timestamp, r.TestID
+	})}
+	m.Add(rows...)
+	return m
+}
+
+func (m *MemoryReadings) ForTest(testID int) ([]models.TimeToDry, error) {
+	return m.where(func(_ time.Time, id int) bool { return id == testID }), nil
+}
+
+func (m *MemoryReadings) Last(testID int) (*models.TimeToDry, error) {
+	rows := m.where(func(_ time.Time, id int) bool { return testID == 0 || id == testID })
+	if len(rows) == 0 {
+		return nil, ErrNotFound
+	}
+	return &rows[len(rows)-1], nil
+}
+
+// MemoryWeather keeps weather records in memory. It is meant for tests.
+type MemoryWeather struct {
+	memoryTable[models.TMD]
+}
+
+// NewMemoryWeather returns a MemoryWeather holding rows.
+func NewMemoryWeather(rows ...models.TMD) *MemoryWeather {
+	m := &MemoryWeather{newMemoryTable(func(r *models.TMD) (*uint, time.Time, int) {
+		return &r.ID, r.Timestamp, 0
+	})}
+	m.Add(rows...)
+	return m
+}
+
+func (m *MemoryWeather) Between(from, to time.Time) ([]models.TMD, error) {
+	return m.where(func(ts time.Time, _ int) bool { return !ts.Before(from) && !ts.After(to) }), nil
+}
+
+func (m *MemoryWeather) Recent(since time.Time, limit int) ([]models.TMD, error) {
+	rows := m.where(func(ts time.Time, _ int) bool { return !ts.Before(since) })
+	slices.Reverse(rows)
+	if len(rows) > limit {
+		rows = rows[:limit]
+	}
+	return rows, nil
+}
+
+// MemoryCombined keeps combined rows in memory. It is meant for tests.
+type MemoryCombined struct {
+	memoryTable[models.CombinedData]
+}
+
+// NewMemoryCombined returns a MemoryCombined holding rows.
+func NewMemoryCombined(rows ...models.CombinedData) *MemoryCombined {
+	m := &MemoryCombined{newMemoryTable(func(r *models.CombinedData) (*uint, time.Time, int) {
+		return &r.ID, r.Timestamp, r.TestID
+	})}
+	m.Add(rows...)
+	return m
+}
@@ -0,0 +1,70 @@
+// Package repository reads the sensor tables (time_to_dry, tmd and
+// combined_data) and the drying sessions for the HTTP handlers. Each table
+// has a GORM implementation and an in-memory one for tests. It also names
+// the other services the handlers depend on, so tests can replace them.
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"backend/combine"
+	"backend/listing"
+	"backend/models"
+)
+
+// ErrNotFound is returned when no row matches.
+var ErrNotFound = errors.New("record not found")
+
+// Table lists the rows of a table page by page.
+type Table[T any] interface {
+	// Page returns the rows p selects, up to one more than p.Limit when
+	// another page follows, and the number of rows matching its filters.
+	Page(p listing.Params) ([]T, int64, error)
+}
+
+// Readings are the time_to_dry sensor readings.
+type Readings interface {
+	Table[models.TimeToDry]
+	// ForTest returns the readings of a drying session, oldest first.
+	ForTest(testID int) ([]models.TimeToDry, error)
+	// Last returns the newest reading of a drying session, or of any
+	// session when testID is 0. It fails with ErrNotFound when there is none.
+	Last(testID int) (*models.TimeToDry, error)
+}
+
+// Weather are the tmd weather records.
+type Weather interface {
+	Table[models.TMD]
+	// Between returns the records from from to to, both included.
+	Between(from, to time.Time) ([]models.TMD, error)
+	// Recent returns up to limit records at or after since, newest first.
+	Recent(since time.Time, limit int) ([]models.TMD, error)
+}
+
+// Combined are the combined_data rows.
+type Combined interface {
+	Table[models.CombinedData]
+}
+
+// Sessions reads drying sessions. sessions.Manager implements it, as do
+// sessions.GormStore and sessions.MemoryStore. Latest and Get fail with
+// sessions.ErrNotFound.
+type Sessions interface {
+	// Active returns the running session, or nil when none is running.
+	Active() (*models.DryingSession, error)
+	// Latest returns the session with the highest TestID.
+	Latest() (*models.DryingSession, error)
+	Get(testID int) (*models.DryingSession, error)
+}
+
+// Models reads the drying time models. estimator.GormModels implements it.
+type Models interface {
+	// Active returns the active model, or the builtin one when none is active.
+	Active() (*models.DryTimeModel, error)
+}
+
+// Combiner populates combined_data. combine.Joiner implements it.
+type Combiner interface {
+	Run() (*combine.Result, error)
+}
@@ -0,0 +1,24 @@
+package retention
+
+import (
+	"encoding/json"
+	"flag"
+	"io"
+)
+
+// RunCommand runs the retention subcommand once, printing its report to out:
+//
+//	go run main.go retention [-dry-run]
+func (j *Job) RunCommand(args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("retention", flag.ContinueOnError)
+	fs.SetOutput(out)
+	dryRun := fs.Bool("dry-run", false, "report what would be removed without changing anything")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	report, err := j.Prune(*dryRun)
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+	enc.Encode(report)
+	return err
+}
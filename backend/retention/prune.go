@@ -0,0 +1,242 @@
+package retention
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"backend/aggregate"
+	"backend/database"
+	"backend/models"
+
+	"gorm.io/gorm"
+)
+
+// Prune summarises and deletes the rows of every source older than its
+// retention, then deletes the per-minute rollups older than theirs. A dry
+// run changes nothing and reports what a run would remove. Rows are handled
+// a Window at a time, each in a transaction; on error the report covers the
+// windows done.
+func (j *Job) Prune(dryRun bool) (report *Report, err error) {
+	j.running.Lock()
+	defer j.running.Unlock()
+
+	began := time.Now()
+	now := j.Now()
+	report = &Report{DryRun: dryRun, StartedAt: now}
+	defer func() {
+		report.DurationMS = time.Since(began).Milliseconds()
+		j.record(report, err)
+	}()
+
+	hours := aggregate.New(time.Hour, nil)
+	for _, src := range j.Config.Sources {
+		t := TableReport{Table: src.Table, KeepDays: src.KeepDays}
+		if src.KeepDays > 0 {
+			// Whole hours only, so an hour is never summarised twice.
+			t.Cutoff = hours.Start(now.AddDate(0, 0, -src.KeepDays))
+			err = j.pruneSource(src, t.Cutoff, dryRun, &t)
+		}
+		report.Tables = append(report.Tables, t)
+		if err != nil {
+			return report, fmt.Errorf("%s: %w", src.Table, err)
+		}
+	}
+
+	if j.Config.MinuteRollupDays > 0 {
+		before := now.AddDate(0, 0, -j.Config.MinuteRollupDays).UTC()
+		db := database.DB.Table(models.RollupMinuteTable).Where("bucket_start < ?", before)
+		if dryRun {
+			err = db.Count(&report.PrunedRollups).Error
+		} else {
+			result := db.Delete(&models.Rollup{})
+			report.PrunedRollups, err = result.RowsAffected, result.Error
+		}
+		if err != nil {
+			return report, fmt.Errorf("%s: %w", models.RollupMinuteTable, err)
+		}
+	}
+	return report, nil
+}
+
+// pruneSource handles the rows of src before cutoff, from the hour of the
+// oldest one, adding what was done to t.
+func (j *Job) pruneSource(src Source, cutoff time.Time, dryRun bool, t *TableReport) error {
+	var first database.Time
+	err := database.DB.Table(src.Table).Select("MIN(timestamp)").Where("timestamp < ?", cutoff.UTC()).Row().Scan(&first)
+	if err != nil || first.IsZero() {
+		return err
+	}
+	window := j.Window
+	if window <= 0 {
+		window = DefaultWindow
+	}
+	for start := aggregate.New(time.Hour, nil).Start(first.Time); start.Before(cutoff); start = start.Add(window) {
+		end := start.Add(window)
+		if end.After(cutoff) {
+			end = cutoff
+		}
+		if err := pruneWindow(src, start, end, dryRun, t); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// pruneWindow summarises the rows of src from start to end, merges the
+// summaries into the rollup tables and deletes the rows, in a transaction.
+func pruneWindow(src Source, start, end time.Time, dryRun bool, t *TableReport) error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		where := "timestamp >= ? AND timestamp < ?"
+		minutes, hours, n, err := summarise(tx.Table(src.Table).Where(where, start.UTC(), end.UTC()), src)
+		if err != nil || n == 0 {
+			return err
+		}
+		if dryRun {
+			t.Removed += n
+			t.MinuteRollups += int64(len(minutes))
+			t.HourRollups += int64(len(hours))
+			return nil
+		}
+
+		if err := saveRollups(tx, models.RollupMinuteTable, src.Table, start, end, minutes); err != nil {
+			return err
+		}
+		if err := saveRollups(tx, models.RollupHourTable, src.Table, start, end, hours); err != nil {
+			return err
+		}
+		result := tx.Exec("DELETE FROM "+src.Table+" WHERE "+where, start.UTC(), end.UTC())
+		if result.Error != nil {
+			return result.Error
+		}
+		t.Removed += result.RowsAffected
+		t.MinuteRollups += int64(len(minutes))
+		t.HourRollups += int64(len(hours))
+		return nil
+	})
+}
+
+// summarise streams the rows db selects into per-minute and per-hour
+// rollups of src, per drying session. It returns the rollups and how many
+// rows were read.
+func summarise(db *gorm.DB, src Source) (minutes, hours []models.Rollup, n int64, err error) {
+	columns := []string{"timestamp", "0"}
+	if src.TestID {
+		columns[1] = "test_id"
+	}
+	rows, err := db.Select(strings.Join(append(columns, src.Fields...), ", ")).Order("timestamp").Rows()
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	defer rows.Close()
+
+	type pair struct{ minute, hour *aggregate.Aggregator }
+	sessions := map[int]pair{}
+	var order []int
+	var ts database.Time
+	var testID int
+	nulls := make([]sql.NullFloat64, len(src.Fields))
+	dest := []any{&ts, &testID}
+	for i := range nulls {
+		dest = append(dest, &nulls[i])
+	}
+	values := make([]float64, len(src.Fields))
+	for rows.Next() {
+		if err := rows.Scan(dest...); err != nil {
+			return nil, nil, 0, err
+		}
+		n++
+		for i, v := range nulls {
+			values[i] = math.NaN()
+			if v.Valid {
+				values[i] = v.Float64
+			}
+		}
+		p, ok := sessions[testID]
+		if !ok {
+			p = pair{aggregate.New(time.Minute, src.Fields), aggregate.New(time.Hour, src.Fields)}
+			sessions[testID] = p
+			order = append(order, testID)
+		}
+		if err := p.minute.Add(ts.Time, values); err != nil {
+			return nil, nil, 0, err
+		}
+		if err := p.hour.Add(ts.Time, values); err != nil {
+			return nil, nil, 0, err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, 0, err
+	}
+
+	for _, id := range order {
+		minutes = append(minutes, rollupsOf(src, id, sessions[id].minute.Buckets())...)
+		hours = append(hours, rollupsOf(src, id, sessions[id].hour.Buckets())...)
+	}
+	return minutes, hours, n, nil
+}
+
+// rollupsOf turns buckets into one rollup per field.
+func rollupsOf(src Source, testID int, buckets []aggregate.Bucket) []models.Rollup {
+	var rollups []models.Rollup
+	for _, b := range buckets {
+		for _, field := range src.Fields {
+			if s, ok := b.Fields[field]; ok {
+				rollups = append(rollups, models.Rollup{
+					Source: src.Table, BucketStart: b.Start, TestID: testID, Field: field,
+					Count: int64(s.Count), Min: s.Min, Max: s.Max, Avg: s.Avg,
+				})
+			}
+		}
+	}
+	return rollups
+}
+
+// saveRollups stores rollups of source from start to end in table. Rollups
+// already stored for the same bucket, when older rows were imported after a
+// run, are merged into the new ones and replaced.
+func saveRollups(tx *gorm.DB, table, source string, start, end time.Time, rollups []models.Rollup) error {
+	if len(rollups) == 0 {
+		return nil
+	}
+	var existing []models.Rollup
+	err := tx.Table(table).Where("source = ? AND bucket_start >= ? AND bucket_start < ?", source, start.UTC(), end.UTC()).Find(&existing).Error
+	if err != nil {
+		return err
+	}
+	if len(existing) > 0 {
+		stored := make(map[string]models.Rollup, len(existing))
+		for _, r := range existing {
+			stored[rollupKey(r)] = r
+		}
+		var replaced []uint
+		for i, r := range rollups {
+			if old, ok := stored[rollupKey(r)]; ok {
+				rollups[i] = merge(r, old)
+				replaced = append(replaced, old.ID)
+			}
+		}
+		if len(replaced) > 0 {
+			if err := tx.Table(table).Where("id IN ?", replaced).Delete(&models.Rollup{}).Error; err != nil {
+				return err
+			}
+		}
+	}
+	return tx.Table(table).CreateInBatches(rollups, 500).Error
+}
+
+func rollupKey(r models.Rollup) string {
+	return fmt.Sprintf("%d|%d|%s", r.BucketStart.Unix(), r.TestID, r.Field)
+}
+
+// merge combines two rollups of the same bucket.
+func merge(a, b models.Rollup) models.Rollup {
+	count := a.Count + b.Count
+	a.Avg = math.Round((a.Avg*float64(a.Count)+b.Avg*float64(b.Count))/float64(count)*1000) / 1000
+	a.Min = math.Min(a.Min, b.Min)
+	a.Max = math.Max(a.Max, b.Max)
+	a.Count = count
+	return a
+}
@@ -0,0 +1,225 @@
+// Package retention keeps the sensor tables (time_to_dry, tmd and
+// combined_data) from growing without bound. Rows older than the retention
+// of their table are summarised per minute and per hour into the rollup
+// tables, then deleted. Per-minute rollups are pruned in turn; per-hour
+// rollups are kept.
+package retention
+
+import (
+	"context"
+	"log"
+	"maps"
+	"strconv"
+	"sync"
+	"time"
+
+	"backend/aggregate"
+	"backend/config"
+	"backend/models"
+)
+
+// DefaultWindow is how much time of rows is summarised and deleted together.
+const DefaultWindow = 24 * time.Hour
+
+// DefaultInterval is how often the job runs by default.
+const DefaultInterval = 24 * time.Hour
+
+// Source is a sensor table pruned by the job.
+type Source struct {
+	Table string
+	// Fields are the numeric columns summarised in the rollups.
+	Fields []string
+	// TestID is whether the table has a test_id column; rollups are then
+	// kept per drying session.
+	TestID bool
+	// KeepDays is how many days of rows are kept, 0 to keep them all.
+	KeepDays int
+}
+
+// Config says how long rows and rollups are kept.
+type Config struct {
+	Sources []Source
+	// MinuteRollupDays is how many days of per-minute rollups are kept, 0
+	// to keep them all.
+	MinuteRollupDays int
+	// Interval is how often Run runs the job.
+	Interval time.Duration
+}
+
+// DefaultConfig keeps 90 days of readings, a year of weather, combined
+// rows and per-minute rollups, and runs daily.
+func DefaultConfig() Config {
+	return Config{
+		Sources: []Source{
+			{Table: "time_to_dry", Fields: aggregate.NumericFields(models.TimeToDry{}), TestID: true, KeepDays: 90},
+			{Table: "tmd", Fields: aggregate.NumericFields(models.TMD{}), KeepDays: 365},
+			{Table: "combined_data", Fields: aggregate.NumericFields(models.CombinedData{}), TestID: true, KeepDays: 365},
+		},
+		MinuteRollupDays: 365,
+		Interval:         DefaultInterval,
+	}
+}
+
+// ConfigFromEnv reads RETENTION_TIME_TO_DRY_DAYS, RETENTION_TMD_DAYS,
+// RETENTION_COMBINED_DAYS, RETENTION_MINUTE_ROLLUP_DAYS and
+// RETENTION_INTERVAL, falling back to DefaultConfig.
+func ConfigFromEnv() Config {
+	cfg := DefaultConfig()
+	for i, key := range []string{"RETENTION_TIME_TO_DRY_DAYS", "RETENTION_TMD_DAYS", "RETENTION_COMBINED_DAYS"} {
+		cfg.Sources[i].KeepDays = envDays(key, cfg.Sources[i].KeepDays)
+	}
+	cfg.MinuteRollupDays = envDays("RETENTION_MINUTE_ROLLUP_DAYS", cfg.MinuteRollupDays)
+	cfg.Interval = config.GetEnvDuration("RETENTION_INTERVAL", cfg.Interval)
+	if cfg.Interval <= 0 {
+		log.Printf("Invalid RETENTION_INTERVAL %v, using %v", cfg.Interval, DefaultInterval)
+		cfg.Interval = DefaultInterval
+	}
+	return cfg
+}
+
+// envDays parses a number of days, falling back on empty, negative or invalid input.
+func envDays(key string, fallback int) int {
+	value := config.GetEnv(key, "")
+	if value == "" {
+		return fallback
+	}
+	days, err := strconv.Atoi(value)
+	if err != nil || days < 0 {
+		log.Printf("Invalid number of days for %s: %q, using %d", key, value, fallback)
+		return fallback
+	}
+	return days
+}
+
+// TableReport is what a run did, or would do in a dry run, to one table.
+type TableReport struct {
+	Table    string `json:"table"`
+	KeepDays int    `json:"keep_days"`
+	// Cutoff is the start of the hour before which rows are removed; it is
+	// zero when the table is kept whole.
+	Cutoff time.Time `json:"cutoff"`
+	// Removed is the number of rows deleted.
+	Removed int64 `json:"removed"`
+	// MinuteRollups and HourRollups count the rollups written, merged with
+	// existing ones where they cover the same bucket.
+	MinuteRollups int64 `json:"minute_rollups"`
+	HourRollups   int64 `json:"hour_rollups"`
+}
+
+// Report is the result of a run.
+type Report struct {
+	DryRun     bool          `json:"dry_run"`
+	StartedAt  time.Time     `json:"started_at"`
+	DurationMS int64         `json:"duration_ms"`
+	Tables     []TableReport `json:"tables"`
+	// PrunedRollups is the number of per-minute rollups deleted.
+	PrunedRollups int64 `json:"pruned_rollups"`
+}
+
+// Metrics add up the runs of a Job since it started, dry runs excluded.
+type Metrics struct {
+	Runs     int64 `json:"runs"`
+	Failures int64 `json:"failures"`
+	// RowsRemoved counts the rows deleted by table.
+	RowsRemoved    map[string]int64 `json:"rows_removed"`
+	RollupsWritten int64            `json:"rollups_written"`
+	RollupsPruned  int64            `json:"rollups_pruned"`
+	LastRun        *Report          `json:"last_run,omitempty"`
+	LastError      string           `json:"last_error,omitempty"`
+}
+
+// Job removes the rows older than their retention.
+type Job struct {
+	Config Config
+	// Window is how much time of rows is summarised and deleted in one
+	// transaction.
+	Window time.Duration
+	Now    func() time.Time
+
+	// running keeps two runs from summarising the same rows.
+	running sync.Mutex
+	mu      sync.Mutex
+	metrics Metrics
+}
+
+// New returns a Job with DefaultConfig.
+func New() *Job {
+	return &Job{Config: DefaultConfig(), Window: DefaultWindow, Now: time.Now}
+}
+
+// NewFromEnv is New configured from the environment, which must be loaded first.
+func NewFromEnv() *Job {
+	j := New()
+	j.Config = ConfigFromEnv()
+	return j
+}
+
+// Default is the Job run in the background, by the retention command and
+// by the retention endpoints. main replaces it with NewFromEnv once the
+// environment is loaded.
+var Default = New()
+
+// Metrics returns the totals of the runs so far.
+func (j *Job) Metrics() Metrics {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	m := j.metrics
+	m.RowsRemoved = maps.Clone(m.RowsRemoved)
+	if m.RowsRemoved == nil {
+		m.RowsRemoved = map[string]int64{}
+	}
+	return m
+}
+
+// record adds a run to the metrics.
+func (j *Job) record(report *Report, err error) {
+	if report.DryRun {
+		return
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.metrics.Runs++
+	if j.metrics.RowsRemoved == nil {
+		j.metrics.RowsRemoved = map[string]int64{}
+	}
+	for _, t := range report.Tables {
+		j.metrics.RowsRemoved[t.Table] += t.Removed
+		j.metrics.RollupsWritten += t.MinuteRollups + t.HourRollups
+	}
+	j.metrics.RollupsPruned += report.PrunedRollups
+	j.metrics.LastRun = report
+	j.metrics.LastError = ""
+	if err != nil {
+		j.metrics.Failures++
+		j.metrics.LastError = err.Error()
+	}
+}
+
+// Run runs the job immediately and then every Config.Interval, or
+// DefaultInterval when it is not positive, until ctx is cancelled.
+func (j *Job) Run(ctx context.Context) {
+	interval := j.Config.Interval
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	log.Printf("Retention job started: running every %v", interval)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		report, err := j.Prune(false)
+		if err != nil {
+			log.Println("Retention job failed:", err)
+		} else {
+			for _, t := range report.Tables {
+				if t.Removed > 0 {
+					log.Printf("Retention: removed %d %s rows before %s", t.Removed, t.Table, t.Cutoff.Format(time.RFC3339))
+				}
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
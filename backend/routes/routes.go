@@ -8,23 +8,49 @@ import (
 	_ "backend/docs"
 )
 
-func RegisterRoutes(r *mux.Router) {
-	r.HandleFunc("/api/timetodry", controllers.GetTimeToDry).Methods("GET")
-	r.HandleFunc("/api/tmd", controllers.GetTMD).Methods("GET")
-	r.HandleFunc("/api/tmd/today", controllers.TMDToday).Methods("GET")
-	r.HandleFunc("/api/tmd/recent", controllers.TMDLast24Hours).Methods("GET")
-	r.HandleFunc("/api/combined", controllers.GetCombinedData).Methods("GET")
-	r.HandleFunc("/api/combined/populate", controllers.PopulateCombinedData).Methods("POST")
-
-	r.HandleFunc("/api/ttd/latest", controllers.GetLatestTestID).Methods("GET")
-	r.HandleFunc("/api/ttd/latest/all", controllers.GetAllRowsOfLatestTestID).Methods("GET")
-	r.HandleFunc("/api/ttd/latest/last", controllers.GetLastRowOfLatestTestID).Methods("GET")
-	r.HandleFunc("/api/ttd/status", controllers.CheckDeviceStatus).Methods("GET")
-	r.HandleFunc("/api/ttd/status/check", controllers.CheckTestStatus).Methods("GET")
-
-	r.HandleFunc("/api/drytime/estimate", controllers.EstimateDryTime).Methods("GET")
-
-	r.HandleFunc("/api/forecast/rain", controllers.RainForecast).Methods("GET")
+// RegisterRoutes registers the API and swagger routes on r, serving the
+// data endpoints with data.
+func RegisterRoutes(r *mux.Router, data *controllers.DataHandler) {
+	r.HandleFunc("/api/timetodry", data.GetTimeToDry).Methods("GET")
+	r.HandleFunc("/api/timetodry/aggregate", controllers.GetTimeToDryAggregate).Methods("GET")
+	r.HandleFunc("/api/timetodry/export", controllers.ExportTimeToDry).Methods("GET")
+	r.HandleFunc("/api/tmd", data.GetTMD).Methods("GET")
+	r.HandleFunc("/api/tmd/aggregate", controllers.GetTMDAggregate).Methods("GET")
+	r.HandleFunc("/api/tmd/export", controllers.ExportTMD).Methods("GET")
+	r.HandleFunc("/api/tmd/today", data.TMDToday).Methods("GET")
+	r.HandleFunc("/api/tmd/recent", data.TMDLast24Hours).Methods("GET")
+	r.HandleFunc("/api/combined", data.GetCombinedData).Methods("GET")
+	r.HandleFunc("/api/combined/aggregate", controllers.GetCombinedAggregate).Methods("GET")
+	r.HandleFunc("/api/combined/export", controllers.ExportCombinedData).Methods("GET")
+	r.HandleFunc("/api/combined/populate", data.PopulateCombinedData).Methods("POST")
+	r.HandleFunc("/api/import/{table:time_to_dry|tmd}", controllers.ImportTable).Methods("POST")
+	r.HandleFunc("/api/retention", controllers.GetRetentionStatus).Methods("GET")
+	r.HandleFunc("/api/retention/run", controllers.RunRetention).Methods("POST")
+
+	r.HandleFunc("/api/ttd/latest", data.GetLatestTestID).Methods("GET")
+	r.HandleFunc("/api/ttd/latest/all", data.GetAllRowsOfLatestTestID).Methods("GET")
+	r.HandleFunc("/api/ttd/latest/last", data.GetLastRowOfLatestTestID).Methods("GET")
+	r.HandleFunc("/api/ttd/status", data.CheckDeviceStatus).Methods("GET")
+	r.HandleFunc("/api/ttd/status/check", data.CheckTestStatus).Methods("GET")
+	r.HandleFunc("/api/ttd/readings", controllers.IngestReadings).Methods("POST")
+
+	r.HandleFunc("/api/sessions", controllers.ListSessions).Methods("GET")
+	r.HandleFunc("/api/sessions/active", controllers.GetActiveSession).Methods("GET")
+	r.HandleFunc("/api/sessions/start", controllers.StartSession).Methods("POST")
+	r.HandleFunc("/api/sessions/{test_id:[0-9]+}", controllers.GetSession).Methods("GET")
+	r.HandleFunc("/api/sessions/{test_id:[0-9]+}/stop", controllers.StopSession).Methods("POST")
+	r.HandleFunc("/api/sessions/{test_id:[0-9]+}/eta", controllers.GetSessionETA).Methods("GET")
+
+	r.HandleFunc("/api/drytime/estimate", data.EstimateDryTime).Methods("GET")
+	r.HandleFunc("/api/drytime/train", controllers.TrainDryTimeModel).Methods("POST")
+	r.HandleFunc("/api/drytime/models", controllers.ListDryTimeModels).Methods("GET")
+	r.HandleFunc("/api/drytime/models/{version:[0-9]+}/activate", controllers.ActivateDryTimeModel).Methods("POST")
+
+	r.HandleFunc("/api/forecast/rain", data.RainForecast).Methods("GET")
+	r.HandleFunc("/api/alerts", controllers.ListAlerts).Methods("GET")
+	r.HandleFunc("/api/alerts/{id:[0-9]+}/ack", controllers.AcknowledgeAlert).Methods("POST")
+	r.HandleFunc("/api/stream", controllers.StreamEvents).Methods("GET")
+	r.HandleFunc("/api/ws", controllers.WebSocket).Methods("GET")
 
 	r.HandleFunc("/api/line/webhook", controllers.LineWebhook).Methods("POST")
 
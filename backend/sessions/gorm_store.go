@@ -0,0 +1,124 @@
+package sessions
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"backend/database"
+	"backend/models"
+
+	"gorm.io/gorm"
+)
+
+// GormStore keeps sessions in the drying_sessions table of database.DB.
+type GormStore struct{}
+
+func (GormStore) Active() (*models.DryingSession, error) {
+	var s models.DryingSession
+	err := database.DB.Where("status = ?", models.SessionActive).Order("test_id desc").First(&s).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (GormStore) Latest() (*models.DryingSession, error) {
+	var s models.DryingSession
+	err := database.DB.Order("test_id desc").First(&s).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (GormStore) Get(testID int) (*models.DryingSession, error) {
+	var s models.DryingSession
+	err := database.DB.Where("test_id = ?", testID).First(&s).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (GormStore) List(limit int) ([]models.DryingSession, error) {
+	var list []models.DryingSession
+	err := database.DB.Order("test_id desc").Limit(limit).Find(&list).Error
+	return list, err
+}
+
+func (GormStore) Create(s *models.DryingSession) error {
+	return database.DB.Create(s).Error
+}
+
+func (GormStore) Save(s *models.DryingSession) error {
+	return database.DB.Save(s).Error
+}
+
+func (GormStore) NextTestID() (int, error) {
+	var fromSessions, fromReadings int
+	if err := database.DB.Model(&models.DryingSession{}).Select("COALESCE(MAX(test_id), 0)").Scan(&fromSessions).Error; err != nil {
+		return 0, err
+	}
+	if err := database.DB.Model(&models.TimeToDry{}).Select("COALESCE(MAX(test_id), 0)").Scan(&fromReadings).Error; err != nil {
+		return 0, err
+	}
+	return max(fromSessions, fromReadings) + 1, nil
+}
+
+// BackfillFromReadings creates sessions for test_ids that were recorded in
+// time_to_dry before sessions existed. A test whose last reading is within
+// gap of now is left active so ingestion keeps appending to it.
+func BackfillFromReadings(gap time.Duration) (int, error) {
+	type span struct {
+		TestID int
+		First  database.Time
+		Last   database.Time
+	}
+	var spans []span
+	err := database.DB.Model(&models.TimeToDry{}).
+		Select("test_id, MIN(timestamp) AS first, MAX(timestamp) AS last").
+		Where("test_id NOT IN (?)", database.DB.Model(&models.DryingSession{}).Select("test_id")).
+		Group("test_id").
+		Order("test_id").
+		Scan(&spans).Error
+	if err != nil {
+		return 0, err
+	}
+
+	created := 0
+	for _, sp := range spans {
+		s := models.DryingSession{
+			TestID:        sp.TestID,
+			Status:        models.SessionCompleted,
+			Origin:        models.SessionOriginLegacy,
+			StartedAt:     sp.First.Time,
+			LastReadingAt: sp.Last.Time,
+			EndReason:     models.EndReasonTimeout,
+		}
+		if time.Since(sp.Last.Time) <= gap {
+			s.Status = models.SessionActive
+			s.EndReason = ""
+		} else {
+			ended := s.LastReadingAt
+			s.EndedAt = &ended
+		}
+		if err := database.DB.Create(&s).Error; err != nil {
+			return created, err
+		}
+		created++
+	}
+	if created > 0 {
+		log.Printf("Backfilled %d drying sessions from time_to_dry", created)
+	}
+	return created, nil
+}
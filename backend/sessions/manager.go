@@ -0,0 +1,274 @@
+package sessions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"backend/events"
+	"backend/models"
+)
+
+// DefaultGap matches the 5 minute silence after which a test used to be reported completed.
+const DefaultGap = 5 * time.Minute
+
+// ErrSessionActive is returned by Start while another session is still running.
+var ErrSessionActive = errors.New("a drying session is already active")
+
+// ErrSessionEnded is returned by Stop for a session that has already ended.
+var ErrSessionEnded = errors.New("drying session has already ended")
+
+// Manager drives the drying session lifecycle: sessions are started manually
+// or automatically when the device reports after a gap, and end manually,
+// on timeout, when the laundry is detected dry or when rain is expected.
+type Manager struct {
+	// Gap is how long the device may stay silent before its session times out.
+	Gap time.Duration
+	// Now returns the current time.
+	Now func() time.Time
+	// OnEnd, if set, is called after a session has ended, outside the manager's lock.
+	OnEnd func(s models.DryingSession)
+	// Events, if set, receives a session event whenever a session starts or ends.
+	Events *events.Bus
+
+	store   Store
+	mu      sync.Mutex
+	changed []models.DryingSession
+}
+
+// NewManager returns a Manager backed by store.
+func NewManager(store Store) *Manager {
+	return &Manager{Gap: DefaultGap, Now: time.Now, store: store}
+}
+
+// Default is the Manager used by the HTTP handlers and ingestion.
+var Default = newDefault()
+
+func newDefault() *Manager {
+	m := NewManager(GormStore{})
+	m.Events = events.Default
+	return m
+}
+
+// Start begins a manual session. It fails with ErrSessionActive if one is already running.
+func (m *Manager) Start() (*models.DryingSession, error) {
+	m.mu.Lock()
+	defer m.unlock()
+
+	active, err := m.store.Active()
+	if err != nil {
+		return nil, err
+	}
+	if active != nil {
+		return active, ErrSessionActive
+	}
+	return m.createLocked(&models.DryingSession{
+		Origin:    models.SessionOriginManual,
+		StartedAt: m.Now(),
+	})
+}
+
+// Stop ends the session with the given TestID for reason.
+func (m *Manager) Stop(testID int, reason string) (*models.DryingSession, error) {
+	return m.StopAt(testID, reason, m.Now())
+}
+
+// StopAt ends the session with the given TestID for reason, recording at as
+// its end time. It is used when the end is detected after the fact, such as
+// the time the laundry became dry.
+func (m *Manager) StopAt(testID int, reason string, at time.Time) (*models.DryingSession, error) {
+	if !models.IsValidEndReason(reason) {
+		return nil, fmt.Errorf("unknown end reason %q", reason)
+	}
+
+	m.mu.Lock()
+	defer m.unlock()
+
+	s, err := m.store.Get(testID)
+	if err != nil {
+		return nil, err
+	}
+	if s.Status != models.SessionActive {
+		return s, ErrSessionEnded
+	}
+	if err := m.endLocked(s, reason, at); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Record attaches a reading taken at the given time to the active session.
+// If the active session has been silent for longer than Gap it is ended with
+// a timeout and a new automatic session is started for the reading.
+//
+// The device keeps reporting after the laundry was detected dry, until it is
+// taken down. Those readings stay with the dry session rather than starting
+// a new one, as long as they keep coming within Gap.
+func (m *Manager) Record(at time.Time) (*models.DryingSession, error) {
+	m.mu.Lock()
+	defer m.unlock()
+
+	active, err := m.store.Active()
+	if err != nil {
+		return nil, err
+	}
+	if active != nil {
+		last := lastActivity(active)
+		if at.Sub(last) <= m.Gap {
+			if at.After(last) {
+				active.LastReadingAt = at
+				if err := m.store.Save(active); err != nil {
+					return nil, err
+				}
+			}
+			return active, nil
+		}
+		if err := m.endLocked(active, models.EndReasonTimeout, last); err != nil {
+			return nil, err
+		}
+	} else if dried, err := m.dryWithinGapLocked(at); err != nil || dried != nil {
+		return dried, err
+	}
+
+	return m.createLocked(&models.DryingSession{
+		Origin:        models.SessionOriginAuto,
+		StartedAt:     at,
+		LastReadingAt: at,
+	})
+}
+
+// ExpireIdle ends the active session with a timeout once it has been silent for longer than Gap.
+func (m *Manager) ExpireIdle() (*models.DryingSession, error) {
+	m.mu.Lock()
+	defer m.unlock()
+
+	active, err := m.store.Active()
+	if err != nil || active == nil {
+		return nil, err
+	}
+	last := lastActivity(active)
+	if m.Now().Sub(last) <= m.Gap {
+		return nil, nil
+	}
+	if err := m.endLocked(active, models.EndReasonTimeout, last); err != nil {
+		return nil, err
+	}
+	return active, nil
+}
+
+// Run calls ExpireIdle every interval until ctx is cancelled.
+func (m *Manager) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s, err := m.ExpireIdle()
+			if err != nil {
+				log.Println("Failed to expire idle drying session:", err)
+			} else if s != nil {
+				log.Printf("Drying session %d timed out", s.TestID)
+			}
+		}
+	}
+}
+
+// Active returns the running session, or nil when none is running.
+func (m *Manager) Active() (*models.DryingSession, error) {
+	return m.store.Active()
+}
+
+// Latest returns the most recent session.
+func (m *Manager) Latest() (*models.DryingSession, error) {
+	return m.store.Latest()
+}
+
+// Get returns the session with the given TestID.
+func (m *Manager) Get(testID int) (*models.DryingSession, error) {
+	return m.store.Get(testID)
+}
+
+// List returns up to limit sessions, newest first.
+func (m *Manager) List(limit int) ([]models.DryingSession, error) {
+	return m.store.List(limit)
+}
+
+// dryWithinGapLocked returns the latest session, with at recorded as its last
+// reading, if it ended dry less than Gap before at.
+func (m *Manager) dryWithinGapLocked(at time.Time) (*models.DryingSession, error) {
+	latest, err := m.store.Latest()
+	if errors.Is(err, ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	last := lastActivity(latest)
+	if latest.EndReason != models.EndReasonDryDetected || at.Sub(last) > m.Gap {
+		return nil, nil
+	}
+	if at.After(last) {
+		latest.LastReadingAt = at
+		if err := m.store.Save(latest); err != nil {
+			return nil, err
+		}
+	}
+	return latest, nil
+}
+
+func (m *Manager) createLocked(s *models.DryingSession) (*models.DryingSession, error) {
+	testID, err := m.store.NextTestID()
+	if err != nil {
+		return nil, err
+	}
+	s.TestID = testID
+	s.Status = models.SessionActive
+	if err := m.store.Create(s); err != nil {
+		return nil, err
+	}
+	m.changed = append(m.changed, *s)
+	log.Printf("Started %s drying session %d", s.Origin, testID)
+	return s, nil
+}
+
+func (m *Manager) endLocked(s *models.DryingSession, reason string, at time.Time) error {
+	s.Status = models.SessionCompleted
+	s.EndReason = reason
+	s.EndedAt = &at
+	if err := m.store.Save(s); err != nil {
+		return err
+	}
+	m.changed = append(m.changed, *s)
+	return nil
+}
+
+// unlock releases the lock and then publishes the sessions started or ended
+// while it was held and runs OnEnd for the ended ones, so the hook may call
+// back into the manager.
+func (m *Manager) unlock() {
+	changed := m.changed
+	m.changed = nil
+	m.mu.Unlock()
+	for _, s := range changed {
+		if m.Events != nil {
+			m.Events.Publish(events.TypeSession, "", s.TestID, s)
+		}
+		if s.Status != models.SessionActive && m.OnEnd != nil {
+			m.OnEnd(s)
+		}
+	}
+}
+
+// lastActivity is the time of the last reading, or the start time for a
+// manually started session that has not received any reading yet.
+func lastActivity(s *models.DryingSession) time.Time {
+	if s.LastReadingAt.IsZero() {
+		return s.StartedAt
+	}
+	return s.LastReadingAt
+}
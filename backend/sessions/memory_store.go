@@ -0,0 +1,103 @@
+package sessions
+
+import (
+	"sort"
+	"sync"
+
+	"backend/models"
+)
+
+// MemoryStore keeps sessions in memory. It is meant for tests.
+type MemoryStore struct {
+	mu       sync.Mutex
+	sessions []models.DryingSession
+	nextID   uint
+}
+
+// NewMemoryStore returns an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{nextID: 1}
+}
+
+func (m *MemoryStore) Active() (*models.DryingSession, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := len(m.sessions) - 1; i >= 0; i-- {
+		if m.sessions[i].Status == models.SessionActive {
+			s := m.sessions[i]
+			return &s, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *MemoryStore) Latest() (*models.DryingSession, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.sessions) == 0 {
+		return nil, ErrNotFound
+	}
+	s := m.sortedLocked()[0]
+	return &s, nil
+}
+
+func (m *MemoryStore) Get(testID int) (*models.DryingSession, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, s := range m.sessions {
+		if s.TestID == testID {
+			return &s, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (m *MemoryStore) List(limit int) ([]models.DryingSession, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	list := m.sortedLocked()
+	if limit > 0 && len(list) > limit {
+		list = list[:limit]
+	}
+	return list, nil
+}
+
+func (m *MemoryStore) Create(s *models.DryingSession) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s.ID = m.nextID
+	m.nextID++
+	m.sessions = append(m.sessions, *s)
+	return nil
+}
+
+func (m *MemoryStore) Save(s *models.DryingSession) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := range m.sessions {
+		if m.sessions[i].ID == s.ID {
+			m.sessions[i] = *s
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+func (m *MemoryStore) NextTestID() (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	next := 1
+	for _, s := range m.sessions {
+		if s.TestID >= next {
+			next = s.TestID + 1
+		}
+	}
+	return next, nil
+}
+
+// sortedLocked returns a copy of the sessions ordered by TestID, newest first.
+func (m *MemoryStore) sortedLocked() []models.DryingSession {
+	list := append([]models.DryingSession(nil), m.sessions...)
+	sort.Slice(list, func(i, j int) bool { return list[i].TestID > list[j].TestID })
+	return list
+}
@@ -0,0 +1,25 @@
+package sessions
+
+import (
+	"errors"
+
+	"backend/models"
+)
+
+// ErrNotFound is returned when a session does not exist.
+var ErrNotFound = errors.New("session not found")
+
+// Store persists drying sessions.
+type Store interface {
+	// Active returns the running session, or nil when none is running.
+	Active() (*models.DryingSession, error)
+	// Latest returns the session with the highest TestID.
+	Latest() (*models.DryingSession, error)
+	Get(testID int) (*models.DryingSession, error)
+	// List returns up to limit sessions, newest first.
+	List(limit int) ([]models.DryingSession, error)
+	Create(s *models.DryingSession) error
+	Save(s *models.DryingSession) error
+	// NextTestID returns a TestID not used by any session or reading.
+	NextTestID() (int, error)
+}
@@ -0,0 +1,51 @@
+package subscribers
+
+import (
+	"sync"
+
+	"backend/models"
+)
+
+// MemoryStore keeps subscribers in memory. It is meant for tests.
+type MemoryStore struct {
+	mu   sync.Mutex
+	subs []models.LineSubscriber
+}
+
+// NewMemoryStore returns an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{}
+}
+
+func (m *MemoryStore) Get(lineID string) (*models.LineSubscriber, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, s := range m.subs {
+		if s.LineID == lineID {
+			return &s, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (m *MemoryStore) Save(s *models.LineSubscriber) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if s.ID != 0 {
+		for i := range m.subs {
+			if m.subs[i].ID == s.ID {
+				m.subs[i] = *s
+				return nil
+			}
+		}
+	}
+	s.ID = uint(len(m.subs) + 1)
+	m.subs = append(m.subs, *s)
+	return nil
+}
+
+func (m *MemoryStore) List() ([]models.LineSubscriber, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]models.LineSubscriber{}, m.subs...), nil
+}
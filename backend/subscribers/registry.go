@@ -0,0 +1,120 @@
+package subscribers
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"backend/models"
+)
+
+// Alert topics a subscriber can switch on and off.
+const (
+	TopicRain = "rain"
+	TopicDry  = "dry"
+	TopicAll  = "all"
+)
+
+// Registry manages who receives alerts over LINE.
+type Registry struct {
+	Now func() time.Time
+
+	store Store
+}
+
+// NewRegistry returns a Registry backed by store.
+func NewRegistry(store Store) *Registry {
+	return &Registry{Now: time.Now, store: store}
+}
+
+// Default is the Registry used by the LINE webhook and the LINE notifier.
+var Default = NewRegistry(GormStore{})
+
+// Follow subscribes a LINE chat, for example when a user adds the bot as a
+// friend or the bot joins a group. New subscribers receive every alert; a
+// chat that follows again keeps the preferences it had.
+func (r *Registry) Follow(lineID, sourceType string) (*models.LineSubscriber, error) {
+	now := r.Now()
+	s, err := r.store.Get(lineID)
+	if errors.Is(err, ErrNotFound) {
+		s = &models.LineSubscriber{
+			LineID:       lineID,
+			SourceType:   sourceType,
+			RainAlerts:   true,
+			DryingAlerts: true,
+			SubscribedAt: now,
+		}
+	} else if err != nil {
+		return nil, err
+	} else if !s.Active {
+		s.SubscribedAt = now
+	}
+	s.Active = true
+	s.UpdatedAt = now
+	return s, r.store.Save(s)
+}
+
+// Unfollow stops all alerts to a LINE chat, for example when the user
+// blocks the bot or the bot leaves a group.
+func (r *Registry) Unfollow(lineID string) error {
+	s, err := r.store.Get(lineID)
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	s.Active = false
+	s.UpdatedAt = r.Now()
+	return r.store.Save(s)
+}
+
+// SetAlerts switches a topic on or off for a LINE chat. Opting in to a
+// topic also subscribes a chat that was not subscribed yet.
+func (r *Registry) SetAlerts(lineID, sourceType, topic string, on bool) (*models.LineSubscriber, error) {
+	if topic != TopicRain && topic != TopicDry && topic != TopicAll {
+		return nil, fmt.Errorf("unknown alert topic %q", topic)
+	}
+
+	s, err := r.store.Get(lineID)
+	if errors.Is(err, ErrNotFound) {
+		if s, err = r.Follow(lineID, sourceType); err != nil {
+			return nil, err
+		}
+	} else if err != nil {
+		return nil, err
+	}
+
+	if topic == TopicRain || topic == TopicAll {
+		s.RainAlerts = on
+	}
+	if topic == TopicDry || topic == TopicAll {
+		s.DryingAlerts = on
+	}
+	if on {
+		s.Active = true
+	}
+	s.UpdatedAt = r.Now()
+	return s, r.store.Save(s)
+}
+
+// Get returns the subscription of a LINE chat.
+func (r *Registry) Get(lineID string) (*models.LineSubscriber, error) {
+	return r.store.Get(lineID)
+}
+
+// Recipients returns the active subscribers that want alerts of the given
+// kind. known is false when nobody has ever subscribed, so the caller can
+// fall back to a configured recipient.
+func (r *Registry) Recipients(kind string) (recipients []models.LineSubscriber, known bool, err error) {
+	all, err := r.store.List()
+	if err != nil {
+		return nil, false, err
+	}
+	for _, s := range all {
+		if s.Wants(kind) {
+			recipients = append(recipients, s)
+		}
+	}
+	return recipients, len(all) > 0, nil
+}
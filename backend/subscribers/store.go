@@ -0,0 +1,47 @@
+package subscribers
+
+import (
+	"errors"
+
+	"backend/database"
+	"backend/models"
+
+	"gorm.io/gorm"
+)
+
+// ErrNotFound is returned when a LINE chat has never subscribed.
+var ErrNotFound = errors.New("subscriber not found")
+
+// Store persists LINE subscribers.
+type Store interface {
+	Get(lineID string) (*models.LineSubscriber, error)
+	// Save creates s, or updates it when it already has an ID.
+	Save(s *models.LineSubscriber) error
+	// List returns all subscribers, active or not, oldest first.
+	List() ([]models.LineSubscriber, error)
+}
+
+// GormStore keeps subscribers in the line_subscribers table of database.DB.
+type GormStore struct{}
+
+func (GormStore) Get(lineID string) (*models.LineSubscriber, error) {
+	var s models.LineSubscriber
+	err := database.DB.Where("line_id = ?", lineID).First(&s).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (GormStore) Save(s *models.LineSubscriber) error {
+	return database.DB.Save(s).Error
+}
+
+func (GormStore) List() ([]models.LineSubscriber, error) {
+	list := []models.LineSubscriber{}
+	err := database.DB.Order("id").Find(&list).Error
+	return list, err
+}
@@ -0,0 +1,66 @@
+package tests
+
+import (
+	"errors"
+	"math"
+	"slices"
+	"testing"
+	"time"
+
+	"backend/aggregate"
+	"backend/models"
+)
+
+// TestAggregateBuckets checks readings are grouped into aligned buckets with per-field min, max and average.
+func TestAggregateBuckets(t *testing.T) {
+	a := aggregate.New(5*time.Minute, []string{"hum_in", "temp_in"})
+	at := func(h, m int) time.Time { return time.Date(2025, 5, 1, h, m, 30, 0, time.Local) }
+
+	// Out of order, as the aggregator does not depend on it.
+	a.Add(at(9, 7), []float64{60, 30})
+	a.Add(at(9, 1), []float64{70, 29})
+	a.Add(at(9, 4), []float64{66, math.NaN()})
+	a.Add(at(9, 0), []float64{74, 28})
+
+	buckets := a.Buckets()
+	if len(buckets) != 2 {
+		t.Fatalf("expected 2 buckets, got %+v", buckets)
+	}
+	first := buckets[0]
+	if !first.Start.Equal(localTime("2025-05-01 09:00:00")) || first.Count != 3 {
+		t.Errorf("unexpected first bucket %+v", first)
+	}
+	if s := first.Fields["hum_in"]; s.Min != 66 || s.Max != 74 || s.Avg != 70 {
+		t.Errorf("unexpected hum_in stats %+v", s)
+	}
+	// The missing temperature is not counted in the average.
+	if s := first.Fields["temp_in"]; s.Avg != 28.5 || s.Count != 2 {
+		t.Errorf("expected temp_in average 28.5 of 2 values, got %+v", s)
+	}
+	if !buckets[1].Start.Equal(localTime("2025-05-01 09:05:00")) || buckets[1].Count != 1 {
+		t.Errorf("unexpected second bucket %+v", buckets[1])
+	}
+
+	day := aggregate.New(aggregate.Intervals["1d"], nil)
+	if got := day.Start(at(23, 59)); !got.Equal(time.Date(2025, 5, 1, 0, 0, 0, 0, time.Local)) {
+		t.Errorf("expected 1d buckets to start at local midnight, got %v", got)
+	}
+}
+
+// TestAggregateLimits checks the numeric fields of a model and the bucket limit.
+func TestAggregateLimits(t *testing.T) {
+	fields := aggregate.NumericFields(models.TimeToDry{})
+	if !slices.Contains(fields, "hum_in") || slices.Contains(fields, "test_id") || slices.Contains(fields, "timestamp") {
+		t.Errorf("unexpected numeric fields %v", fields)
+	}
+
+	a := aggregate.New(time.Minute, nil)
+	start := time.Date(2025, 5, 1, 0, 0, 0, 0, time.Local)
+	var err error
+	for i := 0; i <= aggregate.MaxBuckets && err == nil; i++ {
+		err = a.Add(start.Add(time.Duration(i)*time.Minute), nil)
+	}
+	if !errors.Is(err, aggregate.ErrTooManyBuckets) {
+		t.Errorf("expected ErrTooManyBuckets, got %v", err)
+	}
+}
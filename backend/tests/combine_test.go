@@ -0,0 +1,285 @@
+package tests
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"backend/combine"
+	"backend/database"
+	"backend/models"
+)
+
+// memoryCombineStore keeps readings, weather and combined rows in memory.
+type memoryCombineStore struct {
+	readings  []models.TimeToDry
+	weather   []models.TMD
+	combined  []models.CombinedData
+	keys      map[string]bool
+	watermark uint
+	// weatherQueries counts Weather calls, one per batch.
+	weatherQueries int
+}
+
+func (s *memoryCombineStore) Watermark() (uint, error) { return s.watermark, nil }
+
+func (s *memoryCombineStore) Readings(afterID uint, limit int) ([]models.TimeToDry, error) {
+	var out []models.TimeToDry
+	for _, r := range s.readings {
+		if r.ID > afterID && len(out) < limit {
+			out = append(out, r)
+		}
+	}
+	return out, nil
+}
+
+func (s *memoryCombineStore) Unjoined(afterID, throughID uint, from, to time.Time, limit int) ([]models.TimeToDry, error) {
+	var out []models.TimeToDry
+	for _, r := range s.readings {
+		joined := s.keys[fmt.Sprintf("%d|%d", r.Timestamp.Unix(), r.TestID)]
+		if r.ID > afterID && r.ID <= throughID && !r.Timestamp.Before(from) && !r.Timestamp.After(to) && !joined && len(out) < limit {
+			out = append(out, r)
+		}
+	}
+	return out, nil
+}
+
+func (s *memoryCombineStore) CountReadings(afterID uint) (int64, error) {
+	var n int64
+	for _, r := range s.readings {
+		if r.ID > afterID {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (s *memoryCombineStore) Weather(from, to time.Time) ([]models.TMD, error) {
+	s.weatherQueries++
+	var out []models.TMD
+	for _, w := range s.weather {
+		if !w.Timestamp.Before(from) && !w.Timestamp.After(to) {
+			out = append(out, w)
+		}
+	}
+	return out, nil
+}
+
+func (s *memoryCombineStore) LatestWeather() (time.Time, error) {
+	var latest time.Time
+	for _, w := range s.weather {
+		if w.Timestamp.After(latest) {
+			latest = w.Timestamp
+		}
+	}
+	return latest, nil
+}
+
+func (s *memoryCombineStore) Save(rows []models.CombinedData, lastID uint) (int, error) {
+	if s.keys == nil {
+		s.keys = map[string]bool{}
+	}
+	var inserted int
+	for _, r := range rows {
+		key := fmt.Sprintf("%d|%d", r.Timestamp.Unix(), r.TestID)
+		if !s.keys[key] {
+			s.keys[key] = true
+			s.combined = append(s.combined, r)
+			inserted++
+		}
+	}
+	s.watermark = lastID
+	return inserted, nil
+}
+
+// TestCombineIncremental checks readings are joined with the nearest weather, once, and that
+// readings newer than the weather wait for a later run.
+func TestCombineIncremental(t *testing.T) {
+	store := &memoryCombineStore{
+		weather: []models.TMD{
+			{ID: 1, Timestamp: localTime("2025-05-01 09:00:00"), Temperature: 30, Humidity: 70},
+			{ID: 2, Timestamp: localTime("2025-05-01 10:00:00"), Temperature: 31, Humidity: 66},
+			{ID: 3, Timestamp: localTime("2025-05-01 12:00:00"), Temperature: 33, Humidity: 60, Rainfall: 1.5},
+		},
+		readings: []models.TimeToDry{
+			{ID: 1, Timestamp: localTime("2025-05-01 09:20:00"), HumIn: 80, TestID: 1},
+			// Halfway between two records, the earlier one is used.
+			{ID: 2, Timestamp: localTime("2025-05-01 09:30:00"), HumIn: 78, TestID: 1},
+			{ID: 3, Timestamp: localTime("2025-05-01 11:50:00"), HumIn: 70, TestID: 1},
+			// A reading left without a timestamp by the UTC migration.
+			{ID: 4, TestID: 1},
+			// An imported reading far from any weather.
+			{ID: 5, Timestamp: localTime("2025-05-01 05:00:00"), TestID: 1},
+			{ID: 6, Timestamp: localTime("2025-05-01 12:30:00"), HumIn: 65, TestID: 1},
+			// The same reading as the first one.
+			{ID: 7, Timestamp: localTime("2025-05-01 09:20:00"), HumIn: 80, TestID: 1},
+		},
+	}
+	now := time.Date(2025, 5, 1, 12, 45, 0, 0, time.Local)
+	j := &combine.Joiner{Store: store, Config: combine.DefaultConfig, BatchSize: 2, Now: func() time.Time { return now }}
+
+	res, err := j.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := combine.Result{Processed: 5, Inserted: 3, Unmatched: 1, Invalid: 1, Pending: 2, Watermark: 5}
+	if *res != want {
+		t.Errorf("first run: expected %+v, got %+v", want, *res)
+	}
+	if store.weatherQueries != 3 {
+		t.Errorf("expected one weather query per batch, got %d", store.weatherQueries)
+	}
+	if len(store.combined) != 3 {
+		t.Fatalf("expected 3 combined rows, got %+v", store.combined)
+	}
+	if c := store.combined[1]; !c.Timestamp.Equal(localTime("2025-05-01 09:30:00")) || c.APITemp != 30 || c.HumIn != 78 || !c.CreatedAt.Equal(now) ||
+		!c.WeatherTimestamp.Equal(localTime("2025-05-01 09:00:00")) || c.WeatherGapSeconds != 1800 {
+		t.Errorf("unexpected combined row %+v", c)
+	}
+	if c := store.combined[2]; c.APITemp != 33 || c.Rainfall != 1.5 {
+		t.Errorf("expected 11:50 to match the 12:00 weather, got %+v", c)
+	}
+
+	// Nothing new is processed until weather after 12:30 arrives.
+	if res, err := j.Run(); err != nil || res.Processed != 0 || res.Pending != 2 {
+		t.Errorf("expected the run to wait, got %+v (%v)", res, err)
+	}
+
+	store.weather = append(store.weather, models.TMD{ID: 4, Timestamp: localTime("2025-05-01 12:40:00"), Temperature: 34})
+	res, err = j.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = combine.Result{Processed: 2, Inserted: 1, Watermark: 7}
+	if *res != want {
+		t.Errorf("second run: expected %+v, got %+v", want, *res)
+	}
+	if c := store.combined[3]; !c.Timestamp.Equal(localTime("2025-05-01 12:30:00")) || c.APITemp != 34 {
+		t.Errorf("unexpected combined row %+v", c)
+	}
+}
+
+// TestCombineStrategies checks the weather derived for a reading by each matching strategy.
+func TestCombineStrategies(t *testing.T) {
+	weather := []models.TMD{
+		{ID: 1, Timestamp: localTime("2025-05-01 09:00:00"), Temperature: 30, Humidity: 70, Rainfall: 0},
+		{ID: 2, Timestamp: localTime("2025-05-01 10:00:00"), Temperature: 32, Humidity: 60, Rainfall: 2},
+	}
+	cases := []struct {
+		strategy  string
+		maxGap    time.Duration
+		reading   string
+		matched   bool
+		temp, hum float64
+		weatherTS string
+		gap       int64
+	}{
+		{combine.Nearest, time.Hour, "2025-05-01 09:40:00", true, 32, 60, "2025-05-01 10:00:00", 1200},
+		{combine.CarryForward, time.Hour, "2025-05-01 09:40:00", true, 30, 70, "2025-05-01 09:00:00", 2400},
+		{combine.CarryForward, time.Hour, "2025-05-01 08:50:00", false, 0, 0, "", 0},
+		{combine.Interpolate, time.Hour, "2025-05-01 09:15:00", true, 30.5, 67.5, "2025-05-01 09:00:00", 2700},
+		{combine.Interpolate, time.Hour, "2025-05-01 10:00:00", true, 32, 60, "2025-05-01 10:00:00", 0},
+		// Only one side is within the gap.
+		{combine.Interpolate, 30 * time.Minute, "2025-05-01 09:15:00", false, 0, 0, "", 0},
+		{combine.Nearest, 30 * time.Minute, "2025-05-01 10:40:00", false, 0, 0, "", 0},
+	}
+	for _, c := range cases {
+		store := &memoryCombineStore{weather: weather, readings: []models.TimeToDry{{ID: 1, Timestamp: localTime(c.reading), TestID: 1}}}
+		now := time.Date(2025, 5, 2, 0, 0, 0, 0, time.Local)
+		j := &combine.Joiner{Store: store, Config: combine.Config{Strategy: c.strategy, MaxGap: c.maxGap}, Now: func() time.Time { return now }}
+		res, err := j.Run()
+		if err != nil {
+			t.Fatal(err)
+		}
+		name := c.strategy + " " + c.reading
+		if !c.matched {
+			if res.Unmatched != 1 {
+				t.Errorf("%s: expected no match, got %+v", name, store.combined)
+			}
+			continue
+		}
+		if len(store.combined) != 1 {
+			t.Errorf("%s: expected a match, got %+v", name, res)
+			continue
+		}
+		got := store.combined[0]
+		if got.APITemp != c.temp || got.APIHumidity != c.hum || !got.WeatherTimestamp.Equal(localTime(c.weatherTS)) || got.WeatherGapSeconds != c.gap {
+			t.Errorf("%s: unexpected row %+v", name, got)
+		}
+	}
+
+	t.Setenv("COMBINE_STRATEGY", "best")
+	t.Setenv("COMBINE_MAX_GAP", "90m")
+	if cfg := combine.ConfigFromEnv(); cfg.Strategy != combine.Nearest || cfg.MaxGap != 90*time.Minute {
+		t.Errorf("expected an unknown strategy to fall back to nearest, got %+v", cfg)
+	}
+}
+
+// TestCombineGormStore joins readings stored in the database twice; the second run adds nothing.
+func TestCombineGormStore(t *testing.T) {
+	useTestDB(t)
+	database.DB.Create(&[]models.TMD{
+		{Timestamp: localTime("2025-05-01 09:00:00"), Temperature: 30, Humidity: 70},
+		{Timestamp: localTime("2025-05-01 10:00:00"), Temperature: 32, Humidity: 60},
+	})
+	database.DB.Create(&[]models.TimeToDry{
+		{Timestamp: localTime("2025-05-01 09:10:00"), TestID: 1},
+		{Timestamp: localTime("2025-05-01 09:50:00"), TestID: 1},
+		{Timestamp: localTime("2025-05-01 09:50:00"), TestID: 1},
+	})
+	now := time.Date(2025, 5, 2, 0, 0, 0, 0, time.Local)
+	j := &combine.Joiner{Store: combine.GormStore{}, Config: combine.DefaultConfig, Now: func() time.Time { return now }}
+
+	res, err := j.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Processed != 3 || res.Inserted != 2 || res.Watermark != 3 || res.Pending != 0 {
+		t.Errorf("unexpected result %+v", res)
+	}
+	database.DB.Create(&models.TimeToDry{Timestamp: localTime("2025-05-01 09:10:00"), TestID: 1})
+	if res, err := j.Run(); err != nil || res.Processed != 1 || res.Inserted != 0 || res.Watermark != 4 {
+		t.Errorf("expected the repeated reading to be skipped, got %+v (%v)", res, err)
+	}
+
+	var rows []models.CombinedData
+	database.DB.Order("timestamp").Find(&rows)
+	if len(rows) != 2 || rows[1].APITemp != 32 || rows[1].WeatherTimestamp == nil || !rows[1].WeatherTimestamp.Equal(localTime("2025-05-01 10:00:00")) {
+		t.Errorf("unexpected combined rows %+v", rows)
+	}
+}
+
+// TestCombineRejoin joins readings left unmatched once weather for their time arrives, without moving the watermark.
+func TestCombineRejoin(t *testing.T) {
+	useTestDB(t)
+	database.DB.Create(&models.TMD{Timestamp: localTime("2025-05-01 12:00:00"), Temperature: 30, Humidity: 70})
+	database.DB.Create(&[]models.TimeToDry{
+		{Timestamp: localTime("2025-05-01 06:00:00"), TestID: 1},
+		{Timestamp: localTime("2025-05-01 06:30:00"), TestID: 1},
+		{Timestamp: localTime("2025-05-01 12:00:00"), TestID: 1},
+	})
+	now := time.Date(2025, 5, 2, 0, 0, 0, 0, time.Local)
+	j := &combine.Joiner{Store: combine.GormStore{}, Config: combine.DefaultConfig, BatchSize: 1, Now: func() time.Time { return now }}
+	if res, err := j.Run(); err != nil || res.Unmatched != 2 || res.Inserted != 1 || res.Watermark != 3 {
+		t.Fatalf("expected the morning readings unmatched, got %+v (%v)", res, err)
+	}
+
+	// Weather of the morning is imported later.
+	weather := models.TMD{Timestamp: localTime("2025-05-01 06:15:00"), Temperature: 25, Humidity: 80}
+	database.DB.Create(&weather)
+	res, err := j.Rejoin(weather.Timestamp, weather.Timestamp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Processed != 2 || res.Inserted != 2 || res.Unmatched != 0 || res.Watermark != 3 {
+		t.Errorf("expected the morning readings joined, got %+v", res)
+	}
+	var n int64
+	database.DB.Model(&models.CombinedData{}).Where("api_temp = ?", 25).Count(&n)
+	if n != 2 {
+		t.Errorf("expected 2 rows with the morning weather, got %d", n)
+	}
+	if res, _ := j.Rejoin(weather.Timestamp, weather.Timestamp); res.Processed != 0 {
+		t.Errorf("expected nothing left to rejoin, got %+v", res)
+	}
+}
@@ -8,22 +8,14 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
-
-	"github.com/joho/godotenv"
+	"time"
 )
 
-// setupTestDB loads .env and connects to DB
+// setupTestDB uses an in-memory database holding a running session with a few readings
 func setupTestDB(t *testing.T) {
-	err := godotenv.Load("../.env") // adjust path based on your folder
-	if err != nil {
-		t.Fatalf("Failed to load .env file: %v", err)
-	}
-	database.Connect()
-
-	t.Cleanup(func() {
-		sqlDB, _ := database.DB.DB()
-		sqlDB.Close()
-	})
+	useTestDB(t)
+	seedReadings(t, 3, 5)
+	database.DB.Create(&models.TMD{Timestamp: time.Now(), Temperature: 31, Humidity: 65})
 }
 
 // TestGetLatestTestID verifies that the latest test_id is correctly retrieved.
@@ -31,7 +23,7 @@ func TestGetLatestTestID(t *testing.T) {
 	setupTestDB(t)
 	req := httptest.NewRequest("GET", "/api/test/latest", nil)
 	w := httptest.NewRecorder()
-	controllers.GetLatestTestID(w, req)
+	controllers.NewDataHandler(database.DB).GetLatestTestID(w, req)
 
 	if w.Code != http.StatusOK {
 		t.Errorf("expected status 200, got %d", w.Code)
@@ -53,7 +45,7 @@ func TestCheckDeviceStatus(t *testing.T) {
 	setupTestDB(t)
 	req := httptest.NewRequest("GET", "/api/test/status", nil)
 	w := httptest.NewRecorder()
-	controllers.CheckDeviceStatus(w, req)
+	controllers.NewDataHandler(database.DB).CheckDeviceStatus(w, req)
 
 	if w.Code != http.StatusOK {
 		t.Errorf("expected status 200, got %d", w.Code)
@@ -75,7 +67,7 @@ func TestGetAllRowsOfLatestTestID(t *testing.T) {
 	setupTestDB(t)
 	req := httptest.NewRequest("GET", "/api/test/latest/all", nil)
 	w := httptest.NewRecorder()
-	controllers.GetAllRowsOfLatestTestID(w, req)
+	controllers.NewDataHandler(database.DB).GetAllRowsOfLatestTestID(w, req)
 
 	if w.Code != http.StatusOK {
 		t.Errorf("expected status 200, got %d", w.Code)
@@ -93,7 +85,7 @@ func TestGetLastRowOfLatestTestID(t *testing.T) {
 	setupTestDB(t)
 	req := httptest.NewRequest("GET", "/api/test/latest/last", nil)
 	w := httptest.NewRecorder()
-	controllers.GetLastRowOfLatestTestID(w, req)
+	controllers.NewDataHandler(database.DB).GetLastRowOfLatestTestID(w, req)
 
 	if w.Code != http.StatusOK {
 		t.Errorf("expected status 200, got %d", w.Code)
@@ -105,7 +97,7 @@ func TestGetLastRowOfLatestTestID(t *testing.T) {
 		t.Fatal("response not a valid single row JSON")
 	}
 
-	if row.Timestamp == "" {
+	if row.Timestamp.IsZero() {
 		t.Error("timestamp is empty in returned row")
 	}
 }
@@ -115,7 +107,7 @@ func TestGetTimeToDry(t *testing.T) {
 	setupTestDB(t)
 	req := httptest.NewRequest("GET", "/api/timetodry", nil)
 	w := httptest.NewRecorder()
-	controllers.GetTimeToDry(w, req)
+	controllers.NewDataHandler(database.DB).GetTimeToDry(w, req)
 
 	if w.Code != http.StatusOK {
 		t.Errorf("expected status 200, got %d", w.Code)
@@ -127,7 +119,7 @@ func TestGetTMD(t *testing.T) {
 	setupTestDB(t)
 	req := httptest.NewRequest("GET", "/api/tmd", nil)
 	w := httptest.NewRecorder()
-	controllers.GetTMD(w, req)
+	controllers.NewDataHandler(database.DB).GetTMD(w, req)
 
 	if w.Code != http.StatusOK {
 		t.Errorf("expected status 200, got %d", w.Code)
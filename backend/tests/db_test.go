@@ -0,0 +1,74 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"backend/database"
+	"backend/migrations"
+	"backend/models"
+	"backend/utils"
+)
+
+// useTestDB points database.DB at a new in-memory SQLite database with
+// every migration applied, and restores the previous DB afterwards.
+func useTestDB(t *testing.T) {
+	t.Helper()
+	db, err := database.Open(database.Config{Driver: database.SQLite, DSN: ":memory:"})
+	if err != nil {
+		t.Fatalf("Failed to open test database: %v", err)
+	}
+	migrator, err := migrations.New(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrator.Up(); err != nil {
+		t.Fatalf("Failed to migrate test database: %v", err)
+	}
+	previous := database.DB
+	database.DB = db
+	database.Migrate()
+
+	t.Cleanup(func() {
+		sqlDB, _ := db.DB()
+		sqlDB.Close()
+		database.DB = previous
+	})
+}
+
+// seedReadings stores a drying session with a reading every minute for the
+// last n minutes, the latest one just now.
+func seedReadings(t *testing.T, testID, n int) {
+	t.Helper()
+	now := time.Now()
+	for i := n - 1; i >= 0; i-- {
+		r := models.TimeToDry{
+			Timestamp: now.Add(-time.Duration(i) * time.Minute),
+			TempIn:    30, TempOut: 31, HumIn: 60 + float64(i), HumOut: 55,
+			DiffTemp: -1, DiffHum: 5 + float64(i),
+			TestID: testID,
+		}
+		if err := database.DB.Create(&r).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	err := database.DB.Create(&models.DryingSession{
+		TestID:        testID,
+		Status:        models.SessionActive,
+		Origin:        models.SessionOriginAuto,
+		StartedAt:     now.Add(-time.Duration(n-1) * time.Minute),
+		LastReadingAt: now,
+	}).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// localTime parses a "2006-01-02 15:04:05" timestamp in local time.
+func localTime(s string) time.Time {
+	t, err := utils.ParseLocalTimestamp(s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
@@ -0,0 +1,102 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"backend/alerts"
+	"backend/detector"
+	"backend/ingest"
+	"backend/models"
+	"backend/notify"
+	"backend/sessions"
+)
+
+// dryingCurve returns DiffTemp and DiffHum for a load of laundry that is wet at first and
+// reaches the outside humidity after about 60 minutes.
+func dryingCurve(minute int) (diffTemp, diffHum float64) {
+	if minute >= 60 {
+		return 0.4, 1.2
+	}
+	return -2 + float64(minute)/30, 18 - 0.25*float64(minute)
+}
+
+// TestDetectorWaitsForSustainedPlateau checks dryness is only reported once the plateau has held for the window.
+func TestDetectorWaitsForSustainedPlateau(t *testing.T) {
+	d := detector.New(detector.DefaultConfig)
+	start := time.Date(2025, 5, 1, 9, 0, 0, 0, time.Local)
+
+	var dryAt time.Time
+	detectedAfter := -1
+	for minute := 0; minute <= 120 && detectedAfter < 0; minute++ {
+		dT, dH := dryingCurve(minute)
+		if at, dry := d.Observe(1, start, detector.Reading{At: start.Add(time.Duration(minute) * time.Minute), DiffTemp: dT, DiffHum: dH}); dry {
+			dryAt, detectedAfter = at, minute
+		}
+	}
+	if detectedAfter < 0 {
+		t.Fatal("laundry was never detected dry")
+	}
+	if want := start.Add(60 * time.Minute); !dryAt.Equal(want) {
+		t.Errorf("expected dry at %v, got %v", want, dryAt)
+	}
+	if detectedAfter != 75 {
+		t.Errorf("expected detection after the 15 minute window at minute 75, got minute %d", detectedAfter)
+	}
+
+	// A brief dip below the threshold that does not hold is not dry.
+	d = detector.New(detector.DefaultConfig)
+	for minute := 0; minute < 60; minute++ {
+		dH := 1.0
+		if minute%10 == 9 {
+			dH = 8
+		}
+		if _, dry := d.Observe(2, start, detector.Reading{At: start.Add(time.Duration(minute) * time.Minute), DiffHum: dH}); dry {
+			t.Fatalf("detected dry at minute %d despite humidity spikes", minute)
+		}
+	}
+}
+
+// TestIngestEndsSessionWhenDry feeds a drying curve through ingestion and expects one dry_detected session and alert.
+func TestIngestEndsSessionWhenDry(t *testing.T) {
+	clock := time.Date(2025, 5, 1, 9, 0, 0, 0, time.Local)
+	now := func() time.Time { return clock }
+
+	in := ingest.NewIngestor()
+	in.Now = now
+	in.Sessions = sessions.NewManager(sessions.NewMemoryStore())
+	in.Sessions.Now = now
+	in.Detector = detector.New(detector.DefaultConfig)
+	in.Save = func(*models.TimeToDry) error { return nil }
+
+	sent := &notify.Log{}
+	hook := &alerts.DryingComplete{Notifier: sent, Store: &memoryAlertStore{}, Now: now}
+	in.Sessions.OnEnd = hook.SessionEnded
+
+	var testIDs []int
+	for minute := 0; minute <= 120; minute++ {
+		clock = time.Date(2025, 5, 1, 9, minute, 0, 0, time.Local)
+		dT, dH := dryingCurve(minute)
+		reading := &models.TimeToDry{TempIn: 30 + dT, TempOut: 30, HumIn: 50 + dH, HumOut: 50}
+		if err := in.Ingest(reading); err != nil {
+			t.Fatalf("minute %d: %v", minute, err)
+		}
+		if len(testIDs) == 0 || testIDs[len(testIDs)-1] != reading.TestID {
+			testIDs = append(testIDs, reading.TestID)
+		}
+	}
+
+	if len(testIDs) != 1 {
+		t.Fatalf("readings after the laundry was dry started new sessions: %v", testIDs)
+	}
+	s, err := in.Sessions.Get(testIDs[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.EndReason != models.EndReasonDryDetected || s.EndedAt == nil || !s.EndedAt.Equal(localTime("2025-05-01 10:00:00")) {
+		t.Errorf("expected session to end dry at 10:00, got %+v", s)
+	}
+	if len(sent.Sent()) != 1 {
+		t.Errorf("expected one laundry dry notification, got %d", len(sent.Sent()))
+	}
+}
@@ -0,0 +1,199 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"backend/database"
+	"backend/estimator"
+	"backend/events"
+	"backend/models"
+)
+
+// TestDefaultCoefficientsMatchLegacyFormula guards the builtin model against drifting from the original formula.
+func TestDefaultCoefficientsMatchLegacyFormula(t *testing.T) {
+	diffTemp, diffHum, light := -2.0, 15.0, 20000.0
+	legacy := (180 - 5*diffTemp - 1.5*diffHum - 0.0015*light) * 3.5
+
+	got := estimator.DefaultCoefficients.Estimate(diffTemp, diffHum, light)
+	if math.Abs(got-legacy) > 1e-9 {
+		t.Errorf("expected %v, got %v", legacy, got)
+	}
+	if got := estimator.DefaultCoefficients.Estimate(10, 50, 200000); got != estimator.MinimumDryTime {
+		t.Errorf("expected minimum dry time, got %v", got)
+	}
+}
+
+// TestFitRecoversCoefficients fits noiseless samples generated from known coefficients.
+func TestFitRecoversCoefficients(t *testing.T) {
+	want := estimator.Coefficients{Intercept: 240, DiffTemp: -8, DiffHum: 2.5, Light: -0.004}
+	var samples []estimator.Sample
+	for i := 0; i < 12; i++ {
+		s := estimator.Sample{
+			DiffTemp: float64(i%5) - 2,
+			DiffHum:  float64(i*7%11) + 3,
+			Light:    float64(5000 + i*i*400),
+		}
+		s.Minutes = want.Intercept + want.DiffTemp*s.DiffTemp + want.DiffHum*s.DiffHum + want.Light*s.Light
+		samples = append(samples, s)
+	}
+
+	got, stats, err := estimator.Fit(samples)
+	if err != nil {
+		t.Fatal(err)
+	}
+	near := func(a, b float64) bool { return math.Abs(a-b) <= 1e-6*math.Max(1, math.Abs(b)) }
+	if !near(got.Intercept, want.Intercept) || !near(got.DiffTemp, want.DiffTemp) ||
+		!near(got.DiffHum, want.DiffHum) || !near(got.Light, want.Light) {
+		t.Errorf("expected %+v, got %+v", want, got)
+	}
+	if stats.RMSE > 1e-6 || stats.R2 < 0.999999 {
+		t.Errorf("expected a perfect fit, got %+v", stats)
+	}
+}
+
+// TestFitRejectsTooFewSamples checks training refuses to fit on too little data.
+func TestFitRejectsTooFewSamples(t *testing.T) {
+	_, _, err := estimator.Fit([]estimator.Sample{{Minutes: 100}, {Minutes: 120}})
+	if !errors.Is(err, estimator.ErrNotEnoughSamples) {
+		t.Errorf("expected ErrNotEnoughSamples, got %v", err)
+	}
+}
+
+// TestPredictETANarrowsAsSessionProgresses follows a session drying at a steady rate and expects the interval to tighten.
+func TestPredictETANarrowsAsSessionProgresses(t *testing.T) {
+	start := time.Date(2025, 5, 1, 9, 0, 0, 0, time.Local)
+	// DiffHum falls from 18 to the dry level of 3 over 75 minutes, with a little noise.
+	readingsUntil := func(minutes int) []estimator.Point {
+		var points []estimator.Point
+		for m := 0; m <= minutes; m++ {
+			noise := 0.3 * math.Sin(float64(m))
+			points = append(points, estimator.Point{
+				At:      start.Add(time.Duration(m) * time.Minute),
+				DiffHum: 18 - 0.2*float64(m) + noise,
+				Light:   20000,
+			})
+		}
+		return points
+	}
+	predict := func(minutes int) estimator.ETA {
+		eta, err := estimator.PredictETA(estimator.ETAInput{
+			Readings:   readingsUntil(minutes),
+			Started:    start,
+			Now:        start.Add(time.Duration(minutes) * time.Minute),
+			Model:      estimator.DefaultCoefficients,
+			DryDiffHum: 3,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return eta
+	}
+
+	early, late := predict(15), predict(60)
+	if early.PercentDry >= late.PercentDry || late.PercentDry < 70 || late.PercentDry > 90 {
+		t.Errorf("expected progress to grow to about 80%%, got %v then %v", early.PercentDry, late.PercentDry)
+	}
+	if early.HighMinutes-early.LowMinutes <= late.HighMinutes-late.LowMinutes {
+		t.Errorf("expected the interval to narrow: [%v, %v] then [%v, %v]",
+			early.LowMinutes, early.HighMinutes, late.LowMinutes, late.HighMinutes)
+	}
+	if late.TrendMinutes == nil || late.TrendWeight <= early.TrendWeight {
+		t.Fatalf("expected the humidity trend to dominate late in the session, got %+v", late)
+	}
+	// 15 minutes of drying are left at minute 60.
+	if math.Abs(late.RemainingMinutes-15) > 10 || late.LowMinutes > 15 || late.HighMinutes < 15 {
+		t.Errorf("expected about 15 minutes remaining within the interval, got %v [%v, %v]",
+			late.RemainingMinutes, late.LowMinutes, late.HighMinutes)
+	}
+	if want := start.Add(60*time.Minute + time.Duration(late.RemainingMinutes)*time.Minute); late.CompletesAt.Sub(want).Abs() > time.Minute {
+		t.Errorf("expected completion around %v, got %v", want, late.CompletesAt)
+	}
+
+	if _, err := estimator.PredictETA(estimator.ETAInput{Started: start, Now: start}); !errors.Is(err, estimator.ErrNoReadings) {
+		t.Errorf("expected ErrNoReadings, got %v", err)
+	}
+}
+
+// TestLoadTrainingSamplesUsesDrySessions trains on sessions that ended dry only, timed from their start to their end.
+func TestLoadTrainingSamplesUsesDrySessions(t *testing.T) {
+	useTestDB(t)
+	for _, s := range []struct {
+		testID         int
+		reason, endsAt string
+	}{
+		{1, models.EndReasonDryDetected, "2025-05-01 12:00:00"},
+		{2, models.EndReasonManual, "2025-05-01 13:00:00"},
+		{3, models.EndReasonRain, "2025-05-01 10:30:00"},
+	} {
+		ended := localTime(s.endsAt)
+		database.DB.Create(&models.DryingSession{TestID: s.testID, Status: models.SessionCompleted, StartedAt: localTime("2025-05-01 09:00:00"), EndedAt: &ended, EndReason: s.reason})
+		// The combined rows cover only part of the session.
+		for i := range 3 {
+			database.DB.Create(&models.CombinedData{Timestamp: localTime("2025-05-01 10:00:00").Add(time.Duration(i) * time.Minute), TestID: s.testID, DiffTemp: 2, DiffHum: 10})
+		}
+	}
+
+	samples, err := estimator.LoadTrainingSamples()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(samples) != 1 || samples[0].TestID != 1 || samples[0].Minutes != 180 || samples[0].DiffHum != 10 {
+		t.Errorf("expected one 180 minute sample of session 1, got %+v", samples)
+	}
+}
+
+// TestPublishETAsThrottlesPerSession checks a burst of readings of a session publishes one ETA.
+func TestPublishETAsThrottlesPerSession(t *testing.T) {
+	useTestDB(t)
+	seedReadings(t, 1, 10)
+	seedReadings(t, 2, 10)
+	var active atomic.Int64
+	active.Store(1)
+	current := func() (*models.DryingSession, error) {
+		return &models.DryingSession{TestID: int(active.Load()), Status: models.SessionActive, StartedAt: time.Now().Add(-9 * time.Minute)}, nil
+	}
+
+	bus := events.NewBus(64)
+	etas, _, _ := bus.Subscribe(events.Filter{Types: []string{events.TypeETA}}, 0)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go estimator.PublishETAs(ctx, bus, current)
+
+	// Until the publisher has subscribed, readings go unnoticed.
+	var first events.Event
+	for first.ID == 0 {
+		bus.Publish(events.TypeReading, "dev-a", 1, nil)
+		select {
+		case first = <-etas.C:
+		case <-time.After(20 * time.Millisecond):
+		}
+	}
+	if eta, ok := first.Data.(*estimator.SessionETA); !ok || eta.PredictedCompletion.Location() != time.UTC || eta.ConfidenceInterval.Latest.Before(eta.ConfidenceInterval.Earliest) {
+		t.Errorf("expected completion times in UTC, got %+v", first.Data)
+	}
+	for range 5 {
+		bus.Publish(events.TypeReading, "dev-a", 1, nil)
+	}
+	active.Store(2)
+	bus.Publish(events.TypeReading, "dev-a", 2, nil)
+
+	// Readings are handled in order, so every reading of session 1 was
+	// handled once the ETA of session 2 arrives.
+	counts := map[int]int{first.TestID: 1}
+	for counts[2] == 0 {
+		select {
+		case e := <-etas.C:
+			counts[e.TestID]++
+		case <-time.After(5 * time.Second):
+			t.Fatalf("no ETA for session 2, got %v", counts)
+		}
+	}
+	if counts[1] != 1 || counts[2] != 1 {
+		t.Errorf("expected one ETA per session, got %v", counts)
+	}
+}
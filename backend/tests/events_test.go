@@ -0,0 +1,145 @@
+package tests
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"backend/controllers"
+	"backend/events"
+	"backend/models"
+)
+
+// TestEventBusResume checks filtering, backlog replay and detection of events lost from the ring buffer.
+func TestEventBusResume(t *testing.T) {
+	bus := events.NewBus(4)
+	bus.Publish(events.TypeReading, "dev-a", 1, nil)
+	bus.Publish(events.TypeReading, "dev-b", 1, nil)
+	bus.Publish(events.TypeAlert, "", 0, nil)
+
+	_, backlog, complete := bus.Subscribe(events.Filter{Device: "dev-a"}, 1)
+	if !complete || len(backlog) != 1 || backlog[0].Type != events.TypeAlert {
+		t.Fatalf("expected only the alert after event 1, got %+v (complete %v)", backlog, complete)
+	}
+
+	sub, _, _ := bus.Subscribe(events.Filter{Types: []string{events.TypeSession}, TestID: 2}, 0)
+	bus.Publish(events.TypeSession, "", 1, nil)
+	bus.Publish(events.TypeSession, "", 2, nil)
+	if e := <-sub.C; e.TestID != 2 {
+		t.Errorf("expected the session 2 event, got %+v", e)
+	}
+
+	// Events 1 and 2 get pushed out of the 4 event ring.
+	bus.Publish(events.TypeReading, "dev-a", 2, nil)
+	if _, backlog, complete := bus.Subscribe(events.Filter{}, 1); complete || len(backlog) != 4 {
+		t.Errorf("expected an incomplete backlog of 4 events, got %d (complete %v)", len(backlog), complete)
+	}
+	if _, _, complete := bus.Subscribe(events.Filter{}, 2); !complete {
+		t.Error("expected resuming right before the oldest stored event to be complete")
+	}
+}
+
+// TestSessionEventsPublished checks starts and ends of sessions reach the bus.
+func TestSessionEventsPublished(t *testing.T) {
+	m, _ := newTestManager()
+	m.Events = events.NewBus(16)
+	sub, _, _ := m.Events.Subscribe(events.Filter{}, 0)
+
+	s, _ := m.Start()
+	m.Stop(s.TestID, models.EndReasonManual)
+	for _, want := range []string{models.SessionActive, models.SessionCompleted} {
+		e := <-sub.C
+		if got := e.Data.(models.DryingSession); e.Type != events.TypeSession || got.Status != want {
+			t.Errorf("expected %s session event, got %+v", want, e)
+		}
+	}
+}
+
+// sseEvent is one event read from a Server-Sent Events stream.
+type sseEvent struct {
+	id, event, data string
+}
+
+// readSSE returns the next event of an SSE stream, skipping comments and retry hints.
+func readSSE(t *testing.T, r *bufio.Reader) sseEvent {
+	t.Helper()
+	var e sseEvent
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatalf("stream ended: %v", err)
+		}
+		line = strings.TrimRight(line, "\n")
+		switch {
+		case line == "" && e.event != "":
+			return e
+		case strings.HasPrefix(line, "id: "):
+			e.id = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "event: "):
+			e.event = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			e.data = strings.TrimPrefix(line, "data: ")
+		}
+	}
+}
+
+// TestStreamEvents follows the SSE endpoint live and resumes it with Last-Event-ID.
+func TestStreamEvents(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(controllers.StreamEvents))
+	defer srv.Close()
+
+	// A session ID no other test uses keeps events of other tests out.
+	const testID = 90001
+	connect := func(lastEventID string) (*bufio.Reader, func()) {
+		req, _ := http.NewRequest("GET", srv.URL+"?types=reading,session&device=dev-a&test_id=90001", nil)
+		if lastEventID != "" {
+			req.Header.Set("Last-Event-ID", lastEventID)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+			t.Fatalf("unexpected content type %q", ct)
+		}
+		return bufio.NewReader(resp.Body), func() { resp.Body.Close() }
+	}
+
+	// The handler subscribes before it sends the headers connect waits for,
+	// so it receives everything published from now on.
+	stream, disconnect := connect("")
+	events.Default.Publish(events.TypeReading, "dev-b", testID, models.TimeToDry{TestID: testID})
+	events.Default.Publish(events.TypeAlert, "", 0, models.Alert{Kind: models.AlertRain})
+	first := events.Default.Publish(events.TypeReading, "dev-a", testID, models.TimeToDry{TestID: testID, HumIn: 61})
+
+	e := readSSE(t, stream)
+	var got events.Event
+	if err := json.Unmarshal([]byte(e.data), &got); err != nil {
+		t.Fatal(err)
+	}
+	if e.event != events.TypeReading || got.ID != first.ID || got.Device != "dev-a" {
+		t.Fatalf("expected the dev-a reading, got %+v", e)
+	}
+	disconnect()
+
+	// Missed while disconnected.
+	missed := events.Default.Publish(events.TypeSession, "", testID, models.DryingSession{TestID: testID})
+	stream, disconnect = connect(e.id)
+	defer disconnect()
+	if e := readSSE(t, stream); e.event != events.TypeSession || e.id != strconv.FormatUint(missed.ID, 10) {
+		t.Errorf("expected to resume with the missed session event %d, got %+v", missed.ID, e)
+	}
+
+	resp, err := http.Get(srv.URL + "?types=weather")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected 400 for unknown type, got %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,98 @@
+package tests
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"backend/export"
+	"backend/models"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+var exportRows = []models.TimeToDry{
+	{ID: 1, Timestamp: localTime("2025-05-01 09:00:00"), TempIn: 28.5, HumIn: 71, DiffHum: 12.25, TestID: 3},
+	{ID: 2, Timestamp: localTime("2025-05-01 09:01:00"), TempIn: 28.75, HumIn: 70.5, DiffHum: 11.5, TestID: 3},
+}
+
+// sameReading compares readings with their timestamps as instants, whatever
+// the zone they were read back in.
+func sameReading(a, b models.TimeToDry) bool {
+	if !a.Timestamp.Equal(b.Timestamp) {
+		return false
+	}
+	a.Timestamp = b.Timestamp
+	return a == b
+}
+
+func writeExport(t *testing.T, format string, rows []models.TimeToDry) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w, err := export.NewWriter[models.TimeToDry](&buf, format)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, row := range rows {
+		if err := w.Write(row); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// TestExportFormats writes rows in every format and reads them back.
+func TestExportFormats(t *testing.T) {
+	records, err := csv.NewReader(bytes.NewReader(writeExport(t, export.CSV, exportRows))).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 3 || records[0][0] != "id" || records[0][5] != "temp_in" {
+		t.Fatalf("unexpected CSV %v", records)
+	}
+	if got := records[1]; got[1] != exportRows[0].Timestamp.Format(time.RFC3339) || got[5] != "28.5" || got[10] != "12.25" || got[11] != "3" {
+		t.Errorf("unexpected CSV row %v", got)
+	}
+	if empty := writeExport(t, export.CSV, nil); !bytes.HasPrefix(empty, []byte("id,timestamp,")) {
+		t.Errorf("expected a header for an empty export, got %q", empty)
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(writeExport(t, export.NDJSON, exportRows)))
+	var lines int
+	for scanner.Scan() {
+		var row models.TimeToDry
+		if err := json.Unmarshal(scanner.Bytes(), &row); err != nil || !sameReading(row, exportRows[lines]) {
+			t.Errorf("line %d: got %+v (%v)", lines, row, err)
+		}
+		lines++
+	}
+	if lines != len(exportRows) {
+		t.Errorf("expected %d NDJSON lines, got %d", len(exportRows), lines)
+	}
+
+	data := writeExport(t, export.Parquet, exportRows)
+	file, err := parquet.OpenFile(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := file.Schema().Lookup("hum_in"); !ok {
+		t.Errorf("expected a hum_in column in %v", file.Schema())
+	}
+	got, err := parquet.Read[models.TimeToDry](bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || !sameReading(got[1], exportRows[1]) {
+		t.Errorf("Parquet rows did not round-trip: %+v", got)
+	}
+
+	if _, err := export.NewWriter[models.TimeToDry](&bytes.Buffer{}, "xlsx"); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+}
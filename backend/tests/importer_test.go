@@ -0,0 +1,220 @@
+package tests
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"backend/database"
+	"backend/importer"
+	"backend/models"
+)
+
+// memoryImportStore keeps imported rows in memory, keyed like the importer's duplicates.
+type memoryImportStore struct {
+	keys map[string]bool
+	rows []any
+}
+
+func newMemoryImportStore(keys ...string) *memoryImportStore {
+	s := &memoryImportStore{keys: map[string]bool{}}
+	for _, k := range keys {
+		s.keys[k] = true
+	}
+	return s
+}
+
+func (s *memoryImportStore) Existing(table string, keys []string) (map[string]bool, error) {
+	existing := map[string]bool{}
+	for _, k := range keys {
+		if s.keys[k] {
+			existing[k] = true
+		}
+	}
+	return existing, nil
+}
+
+func (s *memoryImportStore) Insert(table string, rows []any) error {
+	for _, row := range rows {
+		switch r := row.(type) {
+		case *models.TimeToDry:
+			s.keys[fmt.Sprintf("%s|%d", r.Timestamp.UTC().Format(time.RFC3339), r.TestID)] = true
+		case *models.TMD:
+			s.keys[r.Timestamp.UTC().Format(time.RFC3339)] = true
+		}
+		s.rows = append(s.rows, row)
+	}
+	return nil
+}
+
+// TestImportCSV imports readings with mapped columns, skipping duplicates and reporting invalid rows.
+func TestImportCSV(t *testing.T) {
+	// The 09:01 reading of session 3 is already stored.
+	store := newMemoryImportStore(localTime("2025-05-01 09:01:00").UTC().Format(time.RFC3339) + "|3")
+	var after []string
+	im := &importer.Importer{Store: store, BatchSize: 2, AfterImport: func(report *importer.Report) error {
+		after = append(after, report.Table)
+		return nil
+	}}
+	file := `Time,Humidity In,hum_out,temp_in,temp_out,test_id,battery
+2025-05-01 09:00:00,71,59,28.5,27,3,88
+2025-05-01 09:01:00,70,59,28.5,27,3,88
+2025-05-01 09:02:00,69,59,28.6,27,3,87
+2025-05-01 09:00:00,71,59,28.5,27,3,88
+2025-05-01 09:03:00,140,59,28.6,27,3,87
+2025-05-01 09:04:00,,59,28.6,27,3,87
+2025-05-01 09:05:00,66,59,28.7,27,4,86
+`
+	mapping, err := importer.ParseMapping("Time=timestamp, humidity in=hum_in")
+	if err != nil {
+		t.Fatal(err)
+	}
+	report, err := im.Import(strings.NewReader(file), importer.Options{Table: importer.TableTimeToDry, Filename: "log.csv", Mapping: mapping})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if report.Format != importer.CSV || report.Rows != 7 || report.Imported != 3 || report.Skipped != 2 || report.Invalid != 2 {
+		t.Errorf("unexpected report %+v", report)
+	}
+	if len(report.Errors) != 2 || report.Errors[0].Line != 6 || !strings.Contains(report.Errors[0].Message, "hum_in") ||
+		report.Errors[1].Line != 7 || !strings.Contains(report.Errors[1].Message, "hum_in is missing") {
+		t.Errorf("unexpected row errors %+v", report.Errors)
+	}
+	if len(report.IgnoredColumns) != 1 || report.IgnoredColumns[0] != "battery" {
+		t.Errorf("expected battery to be ignored, got %v", report.IgnoredColumns)
+	}
+	if !report.From.Equal(localTime("2025-05-01 09:00:00")) || !report.To.Equal(localTime("2025-05-01 09:05:00")) {
+		t.Errorf("expected the imported readings from 09:00 to 09:05, got %v to %v", report.From, report.To)
+	}
+	if report.TimestampFormat != "2006-01-02 15:04:05" {
+		t.Errorf("unexpected timestamp format %q", report.TimestampFormat)
+	}
+	if len(store.rows) != 3 {
+		t.Fatalf("expected 3 stored rows, got %d", len(store.rows))
+	}
+	first := store.rows[0].(*models.TimeToDry)
+	if first.HumIn != 71 || first.DiffHum != 12 || first.DiffTemp != 1.5 || first.TestID != 3 {
+		t.Errorf("unexpected first row %+v", first)
+	}
+	if len(after) != 1 || after[0] != importer.TableTimeToDry {
+		t.Errorf("expected AfterImport once for time_to_dry, got %v", after)
+	}
+}
+
+// TestImportRequiresTestID checks readings without a test_id are rejected rather than stored as session 0.
+func TestImportRequiresTestID(t *testing.T) {
+	store := newMemoryImportStore()
+	im := &importer.Importer{Store: store}
+	file := `timestamp,hum_in,hum_out,temp_in,temp_out
+2025-05-01 09:00:00,71,59,28.5,27
+2025-05-01 09:01:00,70,59,28.5,27
+`
+	report, err := im.Import(strings.NewReader(file), importer.Options{Table: importer.TableTimeToDry})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Imported != 0 || report.Invalid != 2 || len(store.rows) != 0 || !strings.Contains(report.Errors[0].Message, "test_id is missing") {
+		t.Errorf("expected both readings rejected, got %+v", report)
+	}
+
+	report, _ = im.Import(strings.NewReader("timestamp,hum_in,hum_out,temp_in,temp_out,test_id\n2025-05-01 09:00:00,71,59,28.5,27,0\n"), importer.Options{Table: importer.TableTimeToDry})
+	if report.Invalid != 1 || len(store.rows) != 0 {
+		t.Errorf("expected test_id 0 rejected, got %+v", report)
+	}
+}
+
+// TestImportDryRun checks a dry run reports what would be imported without storing it.
+func TestImportDryRun(t *testing.T) {
+	store := newMemoryImportStore()
+	im := &importer.Importer{Store: store, AfterImport: func(*importer.Report) error {
+		t.Error("AfterImport called in a dry run")
+		return nil
+	}}
+	file := "timestamp,temperature,humidity\n2025-05-01 09:00:00,31,70\n2025-05-01 10:00:00,32,65\n"
+	report, err := im.Import(strings.NewReader(file), importer.Options{Table: importer.TableTMD, DryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !report.DryRun || report.Imported != 2 || len(store.rows) != 0 {
+		t.Errorf("unexpected dry run: %+v, %d rows stored", report, len(store.rows))
+	}
+}
+
+// TestImportNDJSON imports weather records and detects day-first timestamps.
+func TestImportNDJSON(t *testing.T) {
+	store := newMemoryImportStore()
+	im := &importer.Importer{Store: store}
+	file := `{"time": "13/05/2025 09:00", "temperature": 31.5, "humidity": 70, "rainfall": 0.4}
+
+{"time": "13/05/2025 10:00", "temperature": 32, "humidity": 65}
+{"time": "2025-05-13 11:00:00", "temperature": 33, "humidity": 60}
+{"time": "13/05/2025 12:00", "temperature": 33, "humidity": 60, "rainfall": -1}
+not json
+`
+	report, err := im.Import(strings.NewReader(file), importer.Options{Table: importer.TableTMD, Mapping: map[string]string{"time": "timestamp"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Format != importer.NDJSON || report.TimestampFormat != "02/01/2006 15:04" {
+		t.Errorf("expected NDJSON with day-first timestamps, got %+v", report)
+	}
+	if report.Rows != 5 || report.Imported != 2 || report.Invalid != 3 {
+		t.Errorf("unexpected report %+v", report)
+	}
+	// The stored layout does not match the file's format, even though it is valid on its own.
+	if len(report.Errors) != 3 || report.Errors[0].Line != 4 || report.Errors[1].Line != 5 || report.Errors[2].Line != 6 {
+		t.Errorf("unexpected row errors %+v", report.Errors)
+	}
+	if r := store.rows[0].(*models.TMD); !r.Timestamp.Equal(localTime("2025-05-13 09:00:00")) || r.Rainfall != 0.4 {
+		t.Errorf("unexpected first record %+v", r)
+	}
+}
+
+// TestImportInvalidInput checks bad options and files are rejected as a whole.
+func TestImportInvalidInput(t *testing.T) {
+	im := &importer.Importer{Store: newMemoryImportStore()}
+	cases := []struct {
+		name string
+		file string
+		opts importer.Options
+	}{
+		{"table", "a,b\n", importer.Options{Table: "alerts"}},
+		{"format", "a,b\n", importer.Options{Table: importer.TableTMD, Format: "xlsx"}},
+		{"mapping", "a,b\n", importer.Options{Table: importer.TableTMD, Mapping: map[string]string{"a": "weight"}}},
+		{"empty", "", importer.Options{Table: importer.TableTMD, Format: importer.CSV}},
+	}
+	for _, c := range cases {
+		if _, err := im.Import(strings.NewReader(c.file), c.opts); !errors.Is(err, importer.ErrInvalidInput) {
+			t.Errorf("%s: expected ErrInvalidInput, got %v", c.name, err)
+		}
+	}
+	if _, err := importer.ParseMapping("Time"); err == nil {
+		t.Error("expected an error for a mapping without a field")
+	}
+}
+
+// TestImportGormStore imports a file into the database twice; the second import skips every row.
+func TestImportGormStore(t *testing.T) {
+	useTestDB(t)
+	im := &importer.Importer{Store: importer.GormStore{}}
+	file := "timestamp,temp_in,temp_out,hum_in,hum_out,test_id\n" +
+		"2025-05-01 09:00:00,28,27,70,60,2\n" +
+		"2025-05-01 09:00:00,28,27,70,60,3\n"
+	for i, want := range []int{2, 0} {
+		report, err := im.Import(strings.NewReader(file), importer.Options{Table: importer.TableTimeToDry, Format: importer.CSV})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if report.Imported != want || report.Skipped != 2-want {
+			t.Errorf("import %d: unexpected report %+v", i+1, report)
+		}
+	}
+	var n int64
+	database.DB.Model(&models.TimeToDry{}).Count(&n)
+	if n != 2 {
+		t.Errorf("expected 2 stored readings, got %d", n)
+	}
+}
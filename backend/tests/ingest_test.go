@@ -0,0 +1,132 @@
+package tests
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"backend/ingest"
+	"backend/models"
+	"backend/sessions"
+
+	server "github.com/mochi-mqtt/server/v2"
+	"github.com/mochi-mqtt/server/v2/hooks/auth"
+	"github.com/mochi-mqtt/server/v2/listeners"
+)
+
+const samplePayload = `{"lat": 13.837, "lon": 100.576, "light": 812.5, "temp_in": 31, "temp_out": 33,
+	"hum_in": 78, "hum_out": 65, "diff_hum": 13, "diff_temp": -2}`
+
+// startBroker runs an embedded MQTT broker on a free local port.
+func startBroker(t *testing.T) (*server.Server, string) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to reserve port: %v", err)
+	}
+	addr := l.Addr().String()
+	l.Close()
+
+	broker := server.New(&server.Options{InlineClient: true})
+	if err := broker.AddHook(new(auth.AllowHook), nil); err != nil {
+		t.Fatalf("Failed to add auth hook: %v", err)
+	}
+	if err := broker.AddListener(listeners.NewTCP(listeners.Config{ID: "test", Address: addr})); err != nil {
+		t.Fatalf("Failed to add listener: %v", err)
+	}
+	go broker.Serve()
+	t.Cleanup(func() { broker.Close() })
+
+	return broker, "tcp://" + addr
+}
+
+// newCapturingIngestor returns an Ingestor that records readings and sessions in memory.
+func newCapturingIngestor(now func() time.Time) (*ingest.Ingestor, chan models.TimeToDry) {
+	saved := make(chan models.TimeToDry, 10)
+	in := ingest.NewIngestor()
+	in.Now = now
+	in.Sessions = sessions.NewManager(sessions.NewMemoryStore())
+	in.Sessions.Now = now
+	in.Save = func(r *models.TimeToDry) error {
+		saved <- *r
+		return nil
+	}
+	return in, saved
+}
+
+// TestMQTTIngestion publishes a Data_Collector payload and expects a stored reading.
+func TestMQTTIngestion(t *testing.T) {
+	broker, url := startBroker(t)
+	in, saved := newCapturingIngestor(time.Now)
+
+	sub := ingest.NewMQTTSubscriber(ingest.MQTTConfig{
+		Broker:               url,
+		Topic:                ingest.DefaultTopic,
+		ClientID:             "ingest-test",
+		QoS:                  1,
+		MaxReconnectInterval: time.Second,
+	}, in)
+	if err := sub.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer sub.Stop()
+
+	// Keep publishing until the subscription is live; the broker does not retain messages.
+	deadline := time.After(5 * time.Second)
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		select {
+		case r := <-saved:
+			if r.TestID != 1 {
+				t.Errorf("expected test_id 1, got %d", r.TestID)
+			}
+			if r.Timestamp.IsZero() {
+				t.Error("reading was not stamped")
+			}
+			if r.HumIn != 78 || r.Light != 812.5 {
+				t.Errorf("payload decoded incorrectly: %+v", r)
+			}
+			return
+		case <-ticker.C:
+			if err := broker.Publish(ingest.DefaultTopic, []byte(samplePayload), false, 0); err != nil {
+				t.Fatalf("Publish failed: %v", err)
+			}
+		case <-deadline:
+			t.Fatal("timed out waiting for ingested reading")
+		}
+	}
+}
+
+// TestIngestorAssignsTestIDAfterGap checks a silence longer than the session gap starts a new test.
+func TestIngestorAssignsTestIDAfterGap(t *testing.T) {
+	clock := time.Date(2025, 5, 1, 10, 0, 0, 0, time.Local)
+	in, saved := newCapturingIngestor(func() time.Time { return clock })
+
+	steps := []struct {
+		advance time.Duration
+		want    int
+	}{
+		{0, 1},
+		{5 * time.Second, 1},
+		{3 * time.Minute, 1},
+		{10 * time.Minute, 2},
+		{5 * time.Second, 2},
+	}
+	for i, step := range steps {
+		clock = clock.Add(step.advance)
+		if _, err := in.HandlePayload("test-device", []byte(samplePayload)); err != nil {
+			t.Fatalf("step %d: %v", i, err)
+		}
+		r := <-saved
+		if r.TestID != step.want {
+			t.Errorf("step %d: expected test_id %d, got %d", i, step.want, r.TestID)
+		}
+		if !r.Timestamp.Equal(clock) {
+			t.Errorf("step %d: expected timestamp %s, got %s", i, clock, r.Timestamp)
+		}
+	}
+
+	if _, err := in.HandlePayload("test-device", []byte("not json")); err == nil {
+		t.Error("expected decode error for invalid payload")
+	}
+}
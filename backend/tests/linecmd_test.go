@@ -0,0 +1,166 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"backend/estimator"
+	"backend/linecmd"
+	"backend/models"
+	"backend/subscribers"
+	"backend/weather"
+
+	"github.com/line/line-bot-sdk-go/v7/linebot"
+)
+
+// newTestLineHandler returns a command handler on in-memory sessions, one
+// reading taken a minute ago and the sample forecast fixture.
+func newTestLineHandler() (*linecmd.Handler, *time.Time) {
+	m, clock := newTestManager()
+	reading := &models.TimeToDry{
+		ID: 1, Timestamp: clock.Add(-time.Minute),
+		TempIn: 33, TempOut: 31, HumIn: 60, HumOut: 55, DiffTemp: 2, DiffHum: 5, Light: 20000,
+	}
+	return &linecmd.Handler{
+		Sessions:    m,
+		Subscribers: subscribers.NewRegistry(subscribers.NewMemoryStore()),
+		LastReading: func() (*models.TimeToDry, error) { return reading, nil },
+		Model:       func() (*models.DryTimeModel, error) { return estimator.BuiltinModel(), nil },
+		Outlook: func(ctx context.Context, now time.Time) (*weather.RainOutlook, error) {
+			p := &weather.Fixture{Path: sampleFixture, Now: func() time.Time { return now }}
+			return weather.FetchRainOutlook(ctx, p, bangkok, 8, weather.DefaultThresholds, now)
+		},
+		Now: func() time.Time { return *clock },
+	}, clock
+}
+
+// alice is a LINE user chatting with the bot.
+var alice = linecmd.Source{ID: "U-alice", Type: models.LineSourceUser}
+
+// flexJSON returns the rendered Flex contents of a reply.
+func flexJSON(t *testing.T, msg *linebot.FlexMessage) string {
+	t.Helper()
+	b, err := json.Marshal(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+// TestLineCommands checks each command replies with a Flex card describing the right thing.
+func TestLineCommands(t *testing.T) {
+	h, _ := newTestLineHandler()
+	ctx := context.Background()
+
+	tests := []struct {
+		text     string
+		alt      string
+		contains string
+	}{
+		{"status", "Device online", "Inside"},
+		{"Estimate", "Estimated drying time: 7 h 44 min", "Humidity diff"},
+		{"/rain", "Rain expected around", "Safe until"},
+		{"help", "Commands:", "Start a drying session"},
+		{"wash my socks", "Unknown command", "don't know"},
+	}
+	for _, tt := range tests {
+		reply := h.Handle(ctx, alice, tt.text)
+		if !strings.HasPrefix(reply.AltText, tt.alt) {
+			t.Errorf("%q: expected alt text starting with %q, got %q", tt.text, tt.alt, reply.AltText)
+		}
+		if body := flexJSON(t, reply); !strings.Contains(body, tt.contains) || !strings.Contains(body, `"type":"bubble"`) {
+			t.Errorf("%q: expected a bubble containing %q, got %s", tt.text, tt.contains, body)
+		}
+	}
+}
+
+// TestLineRainWithoutDescription checks no empty text reaches LINE when the provider does not describe the weather.
+func TestLineRainWithoutDescription(t *testing.T) {
+	h, clock := newTestLineHandler()
+	h.Outlook = func(context.Context, time.Time) (*weather.RainOutlook, error) {
+		return &weather.RainOutlook{Current: &weather.Conditions{}, SafeUntil: clock.Add(8 * time.Hour)}, nil
+	}
+	body := flexJSON(t, h.Handle(context.Background(), alice, "rain"))
+	if strings.Contains(body, `"text":""`) || strings.Contains(body, `"Now"`) {
+		t.Errorf("expected no empty text and no current weather, got %s", body)
+	}
+}
+
+// TestLineStartStopCommands drives a drying session from chat.
+func TestLineStartStopCommands(t *testing.T) {
+	h, clock := newTestLineHandler()
+	ctx := context.Background()
+
+	if reply := h.Handle(ctx, alice, "stop"); reply.AltText != "No drying session is running" {
+		t.Errorf("unexpected stop reply without session: %q", reply.AltText)
+	}
+	if reply := h.Handle(ctx, alice, "start"); !strings.Contains(reply.AltText, "started") {
+		t.Fatalf("unexpected start reply: %q", reply.AltText)
+	}
+	if reply := h.Handle(ctx, alice, "start"); !strings.Contains(reply.AltText, "already running") {
+		t.Errorf("expected second start to be refused, got %q", reply.AltText)
+	}
+
+	*clock = clock.Add(30 * time.Minute)
+	if reply := h.Handle(ctx, alice, "estimate"); !strings.Contains(flexJSON(t, reply), "Drying for") {
+		t.Error("expected estimate to include progress of the running session")
+	}
+
+	if reply := h.Handle(ctx, alice, "STOP"); !strings.Contains(reply.AltText, "stopped") {
+		t.Fatalf("unexpected stop reply: %q", reply.AltText)
+	}
+	if active, _ := h.Sessions.Active(); active != nil {
+		t.Errorf("session %d still active after stop", active.TestID)
+	}
+}
+
+// TestLineSubscriptionEvents checks follow, join, unfollow and opt-out keep subscriptions in step.
+func TestLineSubscriptionEvents(t *testing.T) {
+	h, _ := newTestLineHandler()
+	ctx := context.Background()
+	family := &linebot.EventSource{Type: linebot.EventSourceTypeGroup, GroupID: "C-family", UserID: "U-bob"}
+
+	if reply := h.HandleEvent(ctx, &linebot.Event{Type: linebot.EventTypeFollow, Source: &linebot.EventSource{Type: linebot.EventSourceTypeUser, UserID: alice.ID}}); reply == nil {
+		t.Error("expected a welcome reply on follow")
+	}
+	h.HandleEvent(ctx, &linebot.Event{Type: linebot.EventTypeJoin, Source: family})
+	h.Handle(ctx, alice, "unsubscribe rain")
+
+	recipients := func(kind string) []string {
+		subs, _, err := h.Subscribers.Recipients(kind)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var ids []string
+		for _, s := range subs {
+			ids = append(ids, s.LineID)
+		}
+		return ids
+	}
+	if got := recipients(models.AlertRain); len(got) != 1 || got[0] != "C-family" {
+		t.Errorf("expected only the family group to get rain alerts, got %v", got)
+	}
+	if got := recipients(models.AlertDryingComplete); len(got) != 2 {
+		t.Errorf("expected both chats to get dry alerts, got %v", got)
+	}
+
+	// A command sent in the group applies to the group, not the sender.
+	h.HandleEvent(ctx, &linebot.Event{
+		Type:    linebot.EventTypeMessage,
+		Source:  family,
+		Message: &linebot.TextMessage{Text: "unsubscribe dry"},
+	})
+	h.HandleEvent(ctx, &linebot.Event{Type: linebot.EventTypeUnfollow, Source: &linebot.EventSource{Type: linebot.EventSourceTypeUser, UserID: alice.ID}})
+	if got := recipients(models.AlertDryingComplete); len(got) != 0 {
+		t.Errorf("expected nobody to get dry alerts, got %v", got)
+	}
+	if reply := h.Handle(ctx, alice, "alerts"); reply.AltText != "This chat gets no alerts." {
+		t.Errorf("unexpected alerts reply: %q", reply.AltText)
+	}
+	if reply := h.Handle(ctx, alice, "subscribe"); !strings.Contains(reply.AltText, "rain and laundry dry") {
+		t.Errorf("expected resubscribing to switch every alert on, got %q", reply.AltText)
+	}
+}
@@ -0,0 +1,98 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/url"
+	"testing"
+
+	"backend/listing"
+	"backend/models"
+)
+
+var readingList = listing.Options{Fields: listing.FieldsOf(models.TimeToDry{}), TestID: true}
+
+// TestListingParse checks list query parameters are parsed and every invalid one is reported.
+func TestListingParse(t *testing.T) {
+	cursor := listing.Cursor{Timestamp: localTime("2025-05-01 09:10:00"), ID: 42}.Encode()
+	q := url.Values{
+		"from":    {"2025-05-01"},
+		"to":      {"2025-05-01 12:00:00"},
+		"test_id": {"7"},
+		"limit":   {"25"},
+		"order":   {"desc"},
+		"cursor":  {cursor},
+		"fields":  {"timestamp, hum_in,timestamp"},
+	}
+	p, errs := listing.Parse(q, readingList)
+	if errs != nil {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	if !p.From.Equal(localTime("2025-05-01 00:00:00")) || !p.To.Equal(localTime("2025-05-01 12:00:00")) || p.TestID != 7 || p.Limit != 25 || p.Order != listing.Desc {
+		t.Errorf("unexpected params %+v", p)
+	}
+	if p.Cursor == nil || p.Cursor.ID != 42 || !p.Cursor.Timestamp.Equal(localTime("2025-05-01 09:10:00")) {
+		t.Errorf("cursor did not round-trip: %+v", p.Cursor)
+	}
+	if len(p.Fields) != 2 || p.Fields[0] != "timestamp" || p.Fields[1] != "hum_in" {
+		t.Errorf("unexpected fields %v", p.Fields)
+	}
+
+	if p, errs := listing.Parse(url.Values{}, readingList); errs != nil || p.Limit != listing.DefaultLimit || p.Order != listing.Asc {
+		t.Errorf("unexpected defaults %+v (%v)", p, errs)
+	}
+
+	_, errs = listing.Parse(url.Values{
+		"from":   {"yesterday"},
+		"limit":  {"5000"},
+		"order":  {"up"},
+		"cursor": {"???"},
+		"fields": {"hum_in,weight"},
+	}, readingList)
+	for _, name := range []string{"from", "limit", "order", "cursor", "fields"} {
+		if errs[name] == "" {
+			t.Errorf("expected an error for %s, got %v", name, errs)
+		}
+	}
+
+	_, errs = listing.Parse(url.Values{"test_id": {"3"}, "from": {"2025-05-02"}, "to": {"2025-05-01"}}, listing.Options{Fields: listing.FieldsOf(models.TMD{})})
+	if errs["test_id"] == "" || errs["to"] == "" {
+		t.Errorf("expected test_id to be unsupported and to before from, got %v", errs)
+	}
+	if _, errs := listing.ParseFilter(url.Values{"fields": {"hum_in"}}, listing.Options{}); errs["fields"] == "" {
+		t.Errorf("expected fields to be unsupported without selectable fields, got %v", errs)
+	}
+}
+
+// TestListingRespond checks the page metadata, next cursor and field selection of a list response.
+func TestListingRespond(t *testing.T) {
+	rows := []models.TMD{
+		{ID: 1, Timestamp: localTime("2025-05-01 09:00:00"), Temperature: 30},
+		{ID: 2, Timestamp: localTime("2025-05-01 10:00:00"), Temperature: 31},
+		{ID: 3, Timestamp: localTime("2025-05-01 11:00:00"), Temperature: 32},
+	}
+	key := func(r models.TMD) listing.Cursor { return listing.Cursor{Timestamp: r.Timestamp, ID: r.ID} }
+
+	// Page fetches one row more than the limit.
+	p := listing.Params{Limit: 2, Order: listing.Asc, Fields: []string{"temperature"}}
+	resp, err := listing.Respond(p, rows, 10, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	page := resp.Pagination
+	if page.Count != 2 || page.Total != 10 || !page.HasMore {
+		t.Errorf("unexpected pagination %+v", page)
+	}
+	if c, err := listing.DecodeCursor(page.NextCursor); err != nil || c.ID != 2 {
+		t.Errorf("expected the next page to continue after row 2, got %+v (%v)", c, err)
+	}
+
+	body, _ := json.Marshal(resp.Data)
+	if string(body) != `[{"temperature":30},{"temperature":31}]` {
+		t.Errorf("expected only the temperature field, got %s", body)
+	}
+
+	resp, _ = listing.Respond(listing.Params{Limit: 5, Order: listing.Asc}, rows, 3, key)
+	if resp.Pagination.HasMore || resp.Pagination.NextCursor != "" || len(resp.Data.([]models.TMD)) != 3 {
+		t.Errorf("expected a complete last page, got %+v", resp.Pagination)
+	}
+}
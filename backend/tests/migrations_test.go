@@ -0,0 +1,281 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"backend/database"
+	"backend/migrations"
+	"backend/models"
+)
+
+// TestMigrationsDrivers checks every driver has the same migrations.
+func TestMigrationsDrivers(t *testing.T) {
+	mysql, err := migrations.Load(database.MySQL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlite, err := migrations.Load(database.SQLite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(mysql) == 0 || len(mysql) != len(sqlite) {
+		t.Fatalf("expected the same migrations, got %d for MySQL and %d for SQLite", len(mysql), len(sqlite))
+	}
+	for i := range mysql {
+		if mysql[i].Version != i+1 || mysql[i].Version != sqlite[i].Version || mysql[i].Name != sqlite[i].Name {
+			t.Errorf("migration %d differs: %04d_%s and %04d_%s", i, mysql[i].Version, mysql[i].Name, sqlite[i].Version, sqlite[i].Name)
+		}
+	}
+}
+
+// TestMigrationsUpDown applies, reverts and forces migrations on SQLite.
+func TestMigrationsUpDown(t *testing.T) {
+	db, err := database.Open(database.Config{Driver: database.SQLite, DSN: ":memory:"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		sqlDB, _ := db.DB()
+		sqlDB.Close()
+	})
+	m, err := migrations.New(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := len(m.Migrations)
+
+	if pending, err := m.Pending(); err != nil || len(pending) != n {
+		t.Fatalf("expected %d migrations pending, got %d (%v)", n, len(pending), err)
+	}
+	if done, err := m.Up(); err != nil || len(done) != n {
+		t.Fatalf("expected %d migrations applied, got %d (%v)", n, len(done), err)
+	}
+	if pending, err := m.Pending(); err != nil || len(pending) != 0 {
+		t.Errorf("expected no migration pending, got %d (%v)", len(pending), err)
+	}
+	if done, err := m.Up(); err != nil || len(done) != 0 {
+		t.Errorf("expected nothing left to apply, got %d (%v)", len(done), err)
+	}
+	for index, table := range map[string]string{
+		"idx_time_to_dry_timestamp":           "time_to_dry",
+		"idx_time_to_dry_test_id":             "time_to_dry",
+		"idx_tmd_timestamp":                   "tmd",
+		"idx_combined_data_timestamp_test_id": "combined_data",
+	} {
+		if !db.Migrator().HasIndex(table, index) {
+			t.Errorf("missing index %s on %s", index, table)
+		}
+	}
+	if !db.Migrator().HasColumn("combined_data", "weather_gap_seconds") {
+		t.Error("missing combined_data.weather_gap_seconds")
+	}
+	if !db.Migrator().HasTable("rollups_1m") || !db.Migrator().HasTable("rollups_1h") {
+		t.Error("missing rollup tables")
+	}
+
+	// Revert the last four migrations only, the UTC conversions, the rollup tables and 0003.
+	if done, err := m.Down(4); err != nil || len(done) != 4 || done[0].Version != n || done[3].Version != 3 {
+		t.Fatalf("expected migrations %d to 3 reverted, got %+v (%v)", n, done, err)
+	}
+	if db.Migrator().HasTable("rollups_1m") {
+		t.Error("expected rollups_1m to be dropped")
+	}
+	if db.Migrator().HasColumn("combined_data", "weather_gap_seconds") {
+		t.Error("expected combined_data.weather_gap_seconds to be dropped")
+	}
+	statuses, err := m.Status()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if statuses[1].AppliedAt == "" || statuses[2].AppliedAt != "" || statuses[n-1].AppliedAt != "" {
+		t.Errorf("unexpected status %+v", statuses)
+	}
+
+	if _, err := m.Down(n); err != nil {
+		t.Fatal(err)
+	}
+	if db.Migrator().HasTable("time_to_dry") {
+		t.Error("expected time_to_dry to be dropped")
+	}
+
+	// A schema created by hand is recorded without running the scripts.
+	if err := m.Force(1); err != nil {
+		t.Fatal(err)
+	}
+	if db.Migrator().HasTable("time_to_dry") {
+		t.Error("force must not run migrations")
+	}
+	var out bytes.Buffer
+	if err := m.RunCommand([]string{"status"}, &out); err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Split(strings.TrimSpace(out.String()), "\n"); len(lines) != n || !strings.Contains(lines[0], "applied") || !strings.Contains(lines[1], "pending") {
+		t.Errorf("unexpected status output:\n%s", out.String())
+	}
+	if err := m.Force(99); err == nil {
+		t.Error("expected an error for an unknown version")
+	}
+	if err := m.RunCommand([]string{"sideways"}, &out); err == nil {
+		t.Error("expected an error for an unknown command")
+	}
+}
+
+// TestMigrationsUTCTimestamps converts timestamps stored as local text to UTC and back.
+func TestMigrationsUTCTimestamps(t *testing.T) {
+	previous := time.Local
+	time.Local = time.FixedZone("ICT", 7*60*60)
+	t.Cleanup(func() { time.Local = previous })
+
+	useTestDB(t)
+	m, err := migrations.New(database.DB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Back to text timestamps, as they were stored before, reverting the later migrations first.
+	if _, err := m.Down(3); err != nil {
+		t.Fatal(err)
+	}
+	database.DB.Exec("INSERT INTO time_to_dry (timestamp, test_id) VALUES ('2025-05-01 09:00:00', 1), ('2025-05-01T09:30:00+07:00', 1), ('2025-05-01T02:45:00.5Z', 1), ('', 1), ('yesterday', 1)")
+	database.DB.Exec("INSERT INTO combined_data (timestamp, test_id, created_at) VALUES ('2025-05-01 09:00:00', 1, '2025-05-01 09:05:00')")
+	// As left behind by a MySQL run that failed after adding the column.
+	database.DB.Exec("ALTER TABLE time_to_dry ADD COLUMN timestamp_new DATETIME")
+
+	// A value in neither format stops the migration instead of being lost.
+	if _, err := m.Up(); err == nil || !strings.Contains(err.Error(), "yesterday") {
+		t.Fatalf("expected the migration to fail on 'yesterday', got %v", err)
+	}
+	database.DB.Exec("DELETE FROM time_to_dry WHERE timestamp = 'yesterday'")
+	if _, err := m.Up(); err != nil {
+		t.Fatal(err)
+	}
+
+	var readings []models.TimeToDry
+	database.DB.Order("id").Find(&readings)
+	want := time.Date(2025, 5, 1, 2, 0, 0, 0, time.UTC)
+	if len(readings) != 4 || !readings[0].Timestamp.Equal(want) || !readings[1].Timestamp.Equal(want.Add(30*time.Minute)) ||
+		!readings[2].Timestamp.Equal(want.Add(45*time.Minute)) || !readings[3].Timestamp.IsZero() {
+		t.Fatalf("unexpected readings %+v", readings)
+	}
+	if body, _ := json.Marshal(readings[0]); !strings.Contains(string(body), `"timestamp":"2025-05-01T02:00:00Z"`) {
+		t.Errorf("expected an RFC 3339 timestamp in UTC, got %s", body)
+	}
+	var combined models.CombinedData
+	database.DB.First(&combined)
+	if !combined.CreatedAt.Equal(want.Add(5*time.Minute)) || combined.WeatherTimestamp != nil {
+		t.Errorf("unexpected combined row %+v", combined)
+	}
+	var n int64
+	database.DB.Model(&models.TimeToDry{}).Where("timestamp >= ?", want).Count(&n)
+	if n != 3 {
+		t.Errorf("expected 3 readings from %v, got %d", want, n)
+	}
+
+	if _, err := m.Down(3); err != nil {
+		t.Fatal(err)
+	}
+	var stored []string
+	database.DB.Raw("SELECT timestamp FROM time_to_dry ORDER BY id").Scan(&stored)
+	if strings.Join(stored, ",") != "2025-05-01 09:00:00,2025-05-01 09:30:00,2025-05-01 09:45:00," {
+		t.Errorf("expected local text timestamps back, got %q", stored)
+	}
+}
+
+// TestMigrationsUTCTimestampsDST converts timestamps on both sides of a daylight saving time change.
+func TestMigrationsUTCTimestampsDST(t *testing.T) {
+	zone, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip("no time zone database:", err)
+	}
+	previous := time.Local
+	time.Local = zone
+	t.Cleanup(func() { time.Local = previous })
+
+	useTestDB(t)
+	m, err := migrations.New(database.DB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Down(3); err != nil {
+		t.Fatal(err)
+	}
+	database.DB.Exec("INSERT INTO time_to_dry (timestamp, test_id) VALUES ('2025-01-15 09:00:00', 1), ('2025-07-15 09:00:00', 1), ('2025-12-15 09:00:00', 1)")
+	if _, err := m.Up(); err != nil {
+		t.Fatal(err)
+	}
+
+	var readings []models.TimeToDry
+	database.DB.Order("id").Find(&readings)
+	want := []time.Time{
+		time.Date(2025, 1, 15, 14, 0, 0, 0, time.UTC),
+		time.Date(2025, 7, 15, 13, 0, 0, 0, time.UTC),
+		time.Date(2025, 12, 15, 14, 0, 0, 0, time.UTC),
+	}
+	if len(readings) != len(want) {
+		t.Fatalf("expected %d readings, got %+v", len(want), readings)
+	}
+	for i, r := range readings {
+		if !r.Timestamp.Equal(want[i]) {
+			t.Errorf("reading %d: expected %v, got %v", i, want[i], r.Timestamp)
+		}
+	}
+
+	if _, err := m.Down(3); err != nil {
+		t.Fatal(err)
+	}
+	var stored []string
+	database.DB.Raw("SELECT timestamp FROM time_to_dry ORDER BY id").Scan(&stored)
+	if strings.Join(stored, ",") != "2025-01-15 09:00:00,2025-07-15 09:00:00,2025-12-15 09:00:00" {
+		t.Errorf("expected the local text timestamps back, got %q", stored)
+	}
+}
+
+// TestMigrationsUTCAppTimestamps converts the times of sessions and alerts stored as local text to UTC and back.
+func TestMigrationsUTCAppTimestamps(t *testing.T) {
+	previous := time.Local
+	time.Local = time.FixedZone("ICT", 7*60*60)
+	t.Cleanup(func() { time.Local = previous })
+
+	useTestDB(t)
+	m, err := migrations.New(database.DB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Down(1); err != nil {
+		t.Fatal(err)
+	}
+	database.DB.Exec("INSERT INTO drying_sessions (test_id, status, started_at, last_reading_at) VALUES (1, 'active', '2025-05-01 09:00:00', '')")
+	database.DB.Exec("INSERT INTO alerts (kind, message, rain_starts_at, sent_at) VALUES ('rain', 'Rain soon', '2025-05-01T10:00:00+07:00', '2025-05-01 09:30:00')")
+	if _, err := m.Up(); err != nil {
+		t.Fatal(err)
+	}
+
+	var s models.DryingSession
+	database.DB.First(&s)
+	want := time.Date(2025, 5, 1, 2, 0, 0, 0, time.UTC)
+	if !s.StartedAt.Equal(want) || !s.LastReadingAt.IsZero() || s.EndedAt != nil {
+		t.Errorf("unexpected session %+v", s)
+	}
+	var a models.Alert
+	database.DB.First(&a)
+	if !a.SentAt.Equal(want.Add(30*time.Minute)) || a.RainStartsAt == nil || !a.RainStartsAt.Equal(want.Add(time.Hour)) || a.AcknowledgedAt != nil {
+		t.Errorf("unexpected alert %+v", a)
+	}
+	if !database.DB.Migrator().HasIndex("alerts", "idx_alerts_sent_at") {
+		t.Error("missing index idx_alerts_sent_at")
+	}
+
+	if _, err := m.Down(1); err != nil {
+		t.Fatal(err)
+	}
+	var started string
+	var open int64
+	database.DB.Raw("SELECT started_at FROM drying_sessions").Scan(&started)
+	database.DB.Raw("SELECT COUNT(*) FROM drying_sessions WHERE ended_at IS NULL").Scan(&open)
+	if started != "2025-05-01 09:00:00" || open != 1 {
+		t.Errorf("expected the local text time back and no end, got %q and %d open", started, open)
+	}
+}
@@ -5,28 +5,18 @@ import (
 	"backend/models"
 	"testing"
 	"time"
-	"github.com/joho/godotenv"
 )
 
-// setupModelTestDB connects to DB and cleans up afterward
+// setupModelTestDB uses an in-memory database and cleans up afterward
 func setupModelTestDB(t *testing.T) {
-	err := godotenv.Load("../.env")
-	if err != nil {
-		t.Fatalf("Failed to load .env: %v", err)
-	}
-	database.Connect()
-
-	t.Cleanup(func() {
-		sqlDB, _ := database.DB.DB()
-		sqlDB.Close()
-	})
+	useTestDB(t)
 }
 
 // TestTimeToDryCreate verifies a TimeToDry record can be created.
 func TestTimeToDryCreate(t *testing.T) {
 	setupModelTestDB(t)
 	record := models.TimeToDry{
-		Timestamp: time.Now().Format("2006-01-02 15:04:05"),
+		Timestamp: time.Now(),
 		Lat:       13.75,
 		Lon:       100.5,
 		TempIn:    30,
@@ -50,7 +40,7 @@ func TestTimeToDryCreate(t *testing.T) {
 func TestTMDCreate(t *testing.T) {
 	setupModelTestDB(t)
 	record := models.TMD{
-		Timestamp:  time.Now().Format("2006-01-02 15:04:05"),
+		Timestamp:  time.Now(),
 		Temperature: 33.5,
 		Humidity:    70,
 		Rainfall:    1.5,
@@ -67,7 +57,7 @@ func TestTMDCreate(t *testing.T) {
 func TestCombinedDataCreate(t *testing.T) {
 	setupModelTestDB(t)
 	record := models.CombinedData{
-		Timestamp:   time.Now().Format("2006-01-02 15:04:05"),
+		Timestamp:   time.Now(),
 		Lat:         13.75,
 		Lon:         100.5,
 		TempIn:      30,
@@ -80,7 +70,7 @@ func TestCombinedDataCreate(t *testing.T) {
 		APITemp:     33.5,
 		APIHumidity: 70,
 		Rainfall:    1.5,
-		CreatedAt:   time.Now().Format("2006-01-02 15:04:05"),
+		CreatedAt:   time.Now(),
 	}
 	result := database.DB.Create(&record)
 	defer database.DB.Where("test_id = ?", record.TestID).Delete(&models.TimeToDry{})
@@ -0,0 +1,277 @@
+package tests
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"backend/alerts"
+	"backend/models"
+	"backend/notify"
+	"backend/subscribers"
+)
+
+// failingNotifier is a channel that always fails.
+type failingNotifier struct{}
+
+func (failingNotifier) Name() string { return "broken" }
+
+func (failingNotifier) Notify(context.Context, notify.Message) error {
+	return errors.New("unreachable")
+}
+
+// TestWebhookNotifierSignsPayload checks the webhook body is JSON and carries a valid HMAC signature.
+func TestWebhookNotifierSignsPayload(t *testing.T) {
+	var got struct {
+		Kind   string `json:"kind"`
+		Title  string `json:"title"`
+		Body   string `json:"body"`
+		SentAt string `json:"sent_at"`
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if !notify.VerifySignature("s3cret", body, r.Header.Get(notify.SignatureHeader)) {
+			http.Error(w, "bad signature", http.StatusUnauthorized)
+			return
+		}
+		json.Unmarshal(body, &got)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	msg := notify.Message{Kind: models.AlertRain, Title: "Rain alert", Body: "Rain expected around 17:00."}
+	hook := &notify.Webhook{URL: srv.URL, Secret: "s3cret"}
+	if err := hook.Notify(context.Background(), msg); err != nil {
+		t.Fatal(err)
+	}
+	if got.Kind != msg.Kind || got.Body != msg.Body || got.SentAt == "" {
+		t.Errorf("unexpected webhook payload: %+v", got)
+	}
+
+	wrong := &notify.Webhook{URL: srv.URL, Secret: "guess"}
+	if err := wrong.Notify(context.Background(), msg); err == nil {
+		t.Error("expected error when the receiver rejects the signature")
+	}
+}
+
+// fakeSMTP serves one SMTP session on a local port, answering every command
+// with success, and sends the message data it receives on the returned channel.
+// With silent set, it accepts the connection and never answers.
+func fakeSMTP(t *testing.T, silent bool) (host, port string, data <-chan string) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	received := make(chan string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		if silent {
+			io.Copy(io.Discard, conn)
+			return
+		}
+		r := bufio.NewReader(conn)
+		fmt.Fprint(conn, "220 fake ESMTP\r\n")
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			switch cmd := strings.ToUpper(strings.TrimSpace(line)); {
+			case strings.HasPrefix(cmd, "DATA"):
+				fmt.Fprint(conn, "354 go ahead\r\n")
+				var body strings.Builder
+				for line, err = r.ReadString('\n'); err == nil && line != ".\r\n"; line, err = r.ReadString('\n') {
+					body.WriteString(line)
+				}
+				received <- body.String()
+				fmt.Fprint(conn, "250 queued\r\n")
+			case strings.HasPrefix(cmd, "QUIT"):
+				fmt.Fprint(conn, "221 bye\r\n")
+				return
+			default:
+				fmt.Fprint(conn, "250 ok\r\n")
+			}
+		}
+	}()
+	host, port, _ = net.SplitHostPort(ln.Addr().String())
+	return host, port, received
+}
+
+// TestEmailNotifier sends a message over SMTP and gives up on a server that does not answer.
+func TestEmailNotifier(t *testing.T) {
+	host, port, data := fakeSMTP(t, false)
+	n := &notify.Email{Host: host, Port: port, From: "dryer@example.com", To: []string{"home@example.com"}}
+	if err := n.Notify(context.Background(), notify.Message{Kind: models.AlertRain, Title: "Rain", Body: "Bring the laundry in."}); err != nil {
+		t.Fatal(err)
+	}
+	if body := <-data; !strings.Contains(body, "Subject: Rain") || !strings.Contains(body, "Bring the laundry in.") {
+		t.Errorf("unexpected message %q", body)
+	}
+
+	host, port, _ = fakeSMTP(t, true)
+	n = &notify.Email{Host: host, Port: port, From: "dryer@example.com", To: []string{"home@example.com"}}
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	began := time.Now()
+	err := n.Notify(ctx, notify.Message{Kind: models.AlertRain})
+	if !errors.Is(err, context.DeadlineExceeded) || time.Since(began) > 2*time.Second {
+		t.Errorf("expected to give up at the deadline, got %v after %v", err, time.Since(began))
+	}
+}
+
+// TestMultiNotifierReportsPerChannel checks one failing channel does not stop the others.
+func TestMultiNotifierReportsPerChannel(t *testing.T) {
+	logged := &notify.Log{}
+	multi := notify.Multi{logged, failingNotifier{}}
+
+	err := multi.Notify(context.Background(), notify.Message{Kind: models.AlertAllClear, Body: "All clear"})
+	var fanout *notify.FanoutError
+	if !errors.As(err, &fanout) {
+		t.Fatalf("expected a FanoutError, got %v", err)
+	}
+	if fanout.Delivered != 1 || len(fanout.Errors) != 1 || fanout.Errors["broken"] == nil {
+		t.Errorf("unexpected per-channel result: %+v", fanout)
+	}
+	if len(logged.Sent()) != 1 {
+		t.Errorf("healthy channel got %d messages, expected 1", len(logged.Sent()))
+	}
+}
+
+// TestNotifierFromEnv checks channels are selected by NOTIFY_CHANNELS.
+func TestNotifierFromEnv(t *testing.T) {
+	t.Setenv("NOTIFY_CHANNELS", "log, webhook")
+	t.Setenv("NOTIFY_WEBHOOK_URL", "http://example.invalid/hook")
+	n, err := notify.FromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n.Name() != "log,webhook" {
+		t.Errorf("expected log and webhook channels, got %q", n.Name())
+	}
+
+	t.Setenv("NOTIFY_CHANNELS", "email")
+	t.Setenv("SMTP_HOST", "")
+	if _, err := notify.FromEnv(); err == nil {
+		t.Error("expected error when SMTP is not configured")
+	}
+
+	t.Setenv("NOTIFY_CHANNELS", "pigeon")
+	if _, err := notify.FromEnv(); err == nil {
+		t.Error("expected error for unknown channel")
+	}
+}
+
+// TestDryingCompleteAlert checks a session ended as dry notifies once, and other endings do not.
+func TestDryingCompleteAlert(t *testing.T) {
+	m, clock := newTestManager()
+	sent := &notify.Log{}
+	store := &memoryAlertStore{}
+	hook := &alerts.DryingComplete{Notifier: sent, Store: store, Now: func() time.Time { return *clock }}
+	m.OnEnd = hook.SessionEnded
+
+	s, err := m.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	*clock = clock.Add(95 * time.Minute)
+	if _, err := m.Stop(s.TestID, models.EndReasonDryDetected); err != nil {
+		t.Fatal(err)
+	}
+
+	s, _ = m.Start()
+	if _, err := m.Stop(s.TestID, models.EndReasonManual); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(sent.Sent()) != 1 || len(store.alerts) != 1 {
+		t.Fatalf("expected one drying complete alert, got %d sent, %d recorded", len(sent.Sent()), len(store.alerts))
+	}
+	msg := sent.Sent()[0]
+	if msg.Kind != models.AlertDryingComplete || !strings.Contains(msg.Body, "after 95 minutes") {
+		t.Errorf("unexpected message: %+v", msg)
+	}
+}
+
+// TestLINENotifierFansOutToSubscribers checks users are multicast, groups pushed and LINE_USER_ID used only before anyone subscribed.
+func TestLINENotifierFansOutToSubscribers(t *testing.T) {
+	registry := subscribers.NewRegistry(subscribers.NewMemoryStore())
+	pushed := map[string]int{}
+	var multicast [][]string
+	n := &notify.LINE{
+		Subscribers:    registry,
+		FallbackUserID: "U-owner",
+		Push:           func(_, to string) error { pushed[to]++; return nil },
+		Multicast:      func(_ string, to []string) error { multicast = append(multicast, to); return nil },
+	}
+	rain := notify.Message{Kind: models.AlertRain, Body: "Rain expected around 17:00."}
+
+	if err := n.Notify(context.Background(), rain); err != nil {
+		t.Fatal(err)
+	}
+	if pushed["U-owner"] != 1 {
+		t.Fatalf("expected fallback push to U-owner, got %v", pushed)
+	}
+
+	registry.Follow("U-mum", models.LineSourceUser)
+	registry.Follow("U-dad", models.LineSourceUser)
+	registry.Follow("U-kid", models.LineSourceUser)
+	registry.Follow("C-family", models.LineSourceGroup)
+	registry.SetAlerts("U-kid", models.LineSourceUser, subscribers.TopicRain, false)
+
+	if err := n.Notify(context.Background(), rain); err != nil {
+		t.Fatal(err)
+	}
+	if pushed["U-owner"] != 1 || pushed["C-family"] != 1 {
+		t.Errorf("expected one push to the group and no more fallback pushes, got %v", pushed)
+	}
+	if len(multicast) != 1 || strings.Join(multicast[0], ",") != "U-mum,U-dad" {
+		t.Errorf("expected one multicast to U-mum and U-dad, got %v", multicast)
+	}
+}
+
+// TestLINENotifierReportsPartialDelivery checks a failing group push is reported in a FanoutError counting the pushes that went out.
+func TestLINENotifierReportsPartialDelivery(t *testing.T) {
+	registry := subscribers.NewRegistry(subscribers.NewMemoryStore())
+	registry.Follow("U-mum", models.LineSourceUser)
+	registry.Follow("C-family", models.LineSourceGroup)
+	registry.Follow("C-broken", models.LineSourceGroup)
+	n := &notify.LINE{
+		Subscribers: registry,
+		Push: func(_, to string) error {
+			if to == "C-broken" {
+				return errors.New("bot was removed from the group")
+			}
+			return nil
+		},
+		Multicast: func(string, []string) error { return nil },
+	}
+
+	err := n.Notify(context.Background(), notify.Message{Kind: models.AlertRain, Body: "Rain expected around 17:00."})
+	var fanout *notify.FanoutError
+	if !errors.As(err, &fanout) {
+		t.Fatalf("expected a FanoutError, got %v", err)
+	}
+	if fanout.Delivered != 2 || len(fanout.Errors) != 1 || !strings.Contains(err.Error(), "C-broken") {
+		t.Errorf("expected the group push and the multicast delivered and C-broken failed, got %d delivered: %v", fanout.Delivered, err)
+	}
+
+	err = notify.Multi{n, failingNotifier{}}.Notify(context.Background(), notify.Message{Kind: models.AlertRain})
+	if !errors.As(err, &fanout) || fanout.Delivered != 1 {
+		t.Errorf("expected the partly delivered LINE channel to count, got %v", err)
+	}
+}
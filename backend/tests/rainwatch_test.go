@@ -0,0 +1,136 @@
+package tests
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"backend/alerts"
+	"backend/models"
+	"backend/notify"
+	"backend/rainwatch"
+	"backend/sessions"
+	"backend/weather"
+)
+
+// memoryAlertStore is an in-memory alerts.Store.
+type memoryAlertStore struct {
+	alerts []models.Alert
+}
+
+func (s *memoryAlertStore) Last(kinds ...string) (*models.Alert, error) {
+	for i := len(s.alerts) - 1; i >= 0; i-- {
+		for _, k := range kinds {
+			if s.alerts[i].Kind == k {
+				a := s.alerts[i]
+				return &a, nil
+			}
+		}
+	}
+	return nil, nil
+}
+
+func (s *memoryAlertStore) Record(a *models.Alert) error {
+	a.ID = uint(len(s.alerts) + 1)
+	s.alerts = append(s.alerts, *a)
+	return nil
+}
+
+func (s *memoryAlertStore) Acknowledge(id uint, at time.Time) (*models.Alert, error) {
+	for i := range s.alerts {
+		if s.alerts[i].ID == id {
+			if s.alerts[i].AcknowledgedAt == nil {
+				s.alerts[i].AcknowledgedAt = &at
+			}
+			a := s.alerts[i]
+			return &a, nil
+		}
+	}
+	return nil, alerts.ErrNotFound
+}
+
+const dryFixture = `{"current": {"condition": "Clear", "description": "clear sky"},
+	"hourly": [{"offset_hours": 0, "precip_probability": 0.1}, {"offset_hours": 1, "precip_probability": 0.1}]}`
+
+// TestRainWatchDeduplicatesAlerts walks a watcher through rain, repeated rain, cooldown and all clear.
+func TestRainWatchDeduplicatesAlerts(t *testing.T) {
+	dir := t.TempDir()
+	fixture := filepath.Join(dir, "forecast.json")
+	useFixture := func(content string) {
+		if err := os.WriteFile(fixture, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	rainy, err := os.ReadFile(sampleFixture)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	clock := time.Date(2025, 5, 1, 14, 25, 0, 0, time.Local)
+	now := func() time.Time { return clock }
+	sent := &notify.Log{}
+	store := &memoryAlertStore{}
+	manager := sessions.NewManager(sessions.NewMemoryStore())
+	manager.Now = now
+	drying, err := manager.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := &rainwatch.Watcher{
+		Provider:   &weather.Fixture{Path: fixture, Now: now},
+		Thresholds: weather.DefaultThresholds,
+		Hours:      8,
+		Lead:       3 * time.Hour,
+		Cooldown:   time.Hour,
+		Notifier:   sent,
+		Store:      store,
+		Sessions:   manager,
+		Now:        now,
+	}
+
+	steps := []struct {
+		name     string
+		forecast []byte
+		advance  time.Duration
+		want     string
+	}{
+		{"rain ahead", rainy, 0, models.AlertRain},
+		{"still rainy", rainy, 15 * time.Minute, ""},
+		{"dry within cooldown", []byte(dryFixture), 15 * time.Minute, ""},
+		{"dry after cooldown", []byte(dryFixture), time.Hour, models.AlertAllClear},
+		{"still dry", []byte(dryFixture), 15 * time.Minute, ""},
+	}
+	for _, step := range steps {
+		clock = clock.Add(step.advance)
+		useFixture(string(step.forecast))
+		alert, err := w.Check(context.Background())
+		if err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+		got := ""
+		if alert != nil {
+			got = alert.Kind
+		}
+		if got != step.want {
+			t.Errorf("%s: expected alert %q, got %q", step.name, step.want, got)
+		}
+	}
+
+	if len(sent.Sent()) != 2 || len(store.alerts) != 2 {
+		t.Fatalf("expected 2 sent and recorded alerts, got %d sent, %d recorded", len(sent.Sent()), len(store.alerts))
+	}
+	if store.alerts[0].RainStartsAt == nil {
+		t.Error("rain alert did not record when rain starts")
+	}
+	if s, _ := manager.Get(drying.TestID); s.Status != models.SessionCompleted || s.EndReason != models.EndReasonRain {
+		t.Errorf("expected the rain alert to end the session for rain, got %+v", s)
+	}
+
+	// A restarted watcher reads the last alert back and stays quiet.
+	w.Store = &memoryAlertStore{alerts: store.alerts}
+	if alert, _ := w.Check(context.Background()); alert != nil {
+		t.Errorf("restarted watcher re-sent %q", alert.Kind)
+	}
+}
@@ -0,0 +1,86 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"backend/controllers"
+	"backend/ingest"
+	"backend/models"
+	"backend/sessions"
+)
+
+// stubDefaultIngestor swaps the shared ingestor for one that keeps readings in memory.
+func stubDefaultIngestor(t *testing.T) *[]models.TimeToDry {
+	var saved []models.TimeToDry
+	original := ingest.Default
+	ingest.Default = ingest.NewIngestor()
+	ingest.Default.Sessions = sessions.NewManager(sessions.NewMemoryStore())
+	ingest.Default.Save = func(r *models.TimeToDry) error {
+		saved = append(saved, *r)
+		return nil
+	}
+	t.Cleanup(func() { ingest.Default = original })
+	return &saved
+}
+
+// TestIngestReadingsBatch posts a mixed batch and expects per-item results.
+func TestIngestReadingsBatch(t *testing.T) {
+	saved := stubDefaultIngestor(t)
+
+	body := `[
+		{"timestamp": "2025-05-01 10:00:00", "temp_in": 30, "temp_out": 32, "hum_in": 80, "hum_out": 70, "light": 900, "diff_temp": 99, "diff_hum": 99},
+		{"temp_in": 30, "temp_out": 32, "hum_in": 140, "hum_out": 70, "light": -1}
+	]`
+	req := httptest.NewRequest("POST", "/api/ttd/readings", strings.NewReader(body))
+	w := httptest.NewRecorder()
+	controllers.IngestReadings(w, req)
+
+	if w.Code != http.StatusMultiStatus {
+		t.Fatalf("expected status 207, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var res controllers.IngestReadingsResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+		t.Fatal("response not JSON")
+	}
+	if res.Accepted != 1 || res.Rejected != 1 {
+		t.Errorf("expected 1 accepted and 1 rejected, got %+v", res)
+	}
+	if len(res.Results) != 2 || len(res.Results[1].Errors) != 2 {
+		t.Fatalf("expected two errors for the second reading, got %+v", res.Results)
+	}
+
+	if len(*saved) != 1 {
+		t.Fatalf("expected 1 saved reading, got %d", len(*saved))
+	}
+	got := (*saved)[0]
+	if got.DiffTemp != -2 || got.DiffHum != 10 {
+		t.Errorf("diffs not recomputed server-side: diff_temp=%v diff_hum=%v", got.DiffTemp, got.DiffHum)
+	}
+	if !got.Timestamp.Equal(localTime("2025-05-01 10:00:00")) || got.TestID == 0 {
+		t.Errorf("unexpected timestamp or test_id: %+v", got)
+	}
+}
+
+// TestIngestReadingsSingle checks a single object is accepted and malformed JSON rejected.
+func TestIngestReadingsSingle(t *testing.T) {
+	stubDefaultIngestor(t)
+
+	req := httptest.NewRequest("POST", "/api/ttd/readings", strings.NewReader(`{"temp_in": 29, "temp_out": 31, "hum_in": 60, "hum_out": 55, "test_id": 7}`))
+	w := httptest.NewRecorder()
+	controllers.IngestReadings(w, req)
+	if w.Code != http.StatusCreated {
+		t.Errorf("expected status 201, got %d", w.Code)
+	}
+
+	req = httptest.NewRequest("POST", "/api/ttd/readings", strings.NewReader(`{"temp_in":`))
+	w = httptest.NewRecorder()
+	controllers.IngestReadings(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400, got %d", w.Code)
+	}
+}
@@ -0,0 +1,199 @@
+package tests
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"backend/controllers"
+	"backend/database"
+	"backend/listing"
+	"backend/models"
+	"backend/repository"
+	"backend/sessions"
+	"backend/weather"
+)
+
+// sampleReadings are two sessions of readings, two of them at the same time.
+func sampleReadings() []models.TimeToDry {
+	start := localTime("2025-05-01 09:00:00")
+	var rows []models.TimeToDry
+	for i := range 6 {
+		rows = append(rows, models.TimeToDry{Timestamp: start.Add(time.Duration(i/2) * time.Minute), HumIn: float64(60 + i), TestID: 1 + i%2})
+	}
+	return rows
+}
+
+func readingIDs(rows []models.TimeToDry) []uint {
+	ids := []uint{}
+	for _, r := range rows {
+		ids = append(ids, r.ID)
+	}
+	return ids
+}
+
+// TestRepositoriesAgree checks the in-memory repositories answer like the GORM ones.
+func TestRepositoriesAgree(t *testing.T) {
+	useTestDB(t)
+	rows := sampleReadings()
+	for i := range rows {
+		database.DB.Create(&rows[i])
+	}
+	memory := repository.NewMemoryReadings(sampleReadings()...)
+	gorm := repository.GormReadings{DB: database.DB}
+
+	second := listing.Cursor{Timestamp: rows[1].Timestamp, ID: rows[1].ID}
+	cases := []listing.Params{
+		{Limit: 10, Order: listing.Asc},
+		{Limit: 2, Order: listing.Desc},
+		{Limit: 2, Order: listing.Asc, Cursor: &second},
+		{Limit: 2, Order: listing.Desc, Cursor: &second},
+		{Limit: 10, Order: listing.Asc, TestID: 2, From: rows[2].Timestamp, To: rows[4].Timestamp},
+	}
+	for i, p := range cases {
+		want, wantTotal, err := gorm.Page(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, total, _ := memory.Page(p)
+		if total != wantTotal || !equalIDs(readingIDs(got), readingIDs(want)) {
+			t.Errorf("case %d: expected %v of %d, got %v of %d", i, readingIDs(want), wantTotal, readingIDs(got), total)
+		}
+	}
+
+	for _, testID := range []int{0, 2} {
+		want, _ := gorm.Last(testID)
+		if got, _ := memory.Last(testID); got == nil || got.ID != want.ID {
+			t.Errorf("last of %d: expected %d, got %+v", testID, want.ID, got)
+		}
+	}
+	want, _ := gorm.ForTest(1)
+	if got, _ := memory.ForTest(1); !equalIDs(readingIDs(got), readingIDs(want)) {
+		t.Errorf("expected session 1 readings %v, got %v", readingIDs(want), readingIDs(got))
+	}
+	if _, err := memory.Last(9); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+	if _, err := gorm.Last(9); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("expected ErrNotFound from GORM, got %v", err)
+	}
+
+	start := localTime("2025-05-01 09:00:00")
+	var weather []models.TMD
+	for i := range 4 {
+		r := models.TMD{Timestamp: start.Add(time.Duration(i) * time.Hour), Temperature: float64(30 + i)}
+		database.DB.Create(&r)
+		weather = append(weather, r)
+	}
+	memoryWeather := repository.NewMemoryWeather(weather...)
+	between, _ := memoryWeather.Between(start.Add(time.Hour), start.Add(2*time.Hour))
+	gormBetween, _ := repository.GormWeather{DB: database.DB}.Between(start.Add(time.Hour), start.Add(2*time.Hour))
+	if len(between) != 2 || len(gormBetween) != 2 || between[0].ID != gormBetween[0].ID {
+		t.Errorf("expected the same 2 records, got %+v and %+v", between, gormBetween)
+	}
+	recent, _ := memoryWeather.Recent(start.Add(time.Hour), 2)
+	gormRecent, _ := repository.GormWeather{DB: database.DB}.Recent(start.Add(time.Hour), 2)
+	if len(recent) != 2 || recent[0].ID != weather[3].ID || recent[1].ID != gormRecent[1].ID {
+		t.Errorf("expected the newest 2 records first, got %+v and %+v", recent, gormRecent)
+	}
+}
+
+func equalIDs(a, b []uint) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// activeModel is a repository.Models whose active model is fixed.
+type activeModel struct{ model models.DryTimeModel }
+
+func (m activeModel) Active() (*models.DryTimeModel, error) {
+	return &m.model, nil
+}
+
+// TestDataHandlerInMemory serves the data endpoints from in-memory repositories, without a database.
+func TestDataHandlerInMemory(t *testing.T) {
+	now := time.Now()
+	store := sessions.NewMemoryStore()
+	store.Create(&models.DryingSession{TestID: 7, Status: models.SessionActive, StartedAt: now.Add(-time.Hour)})
+	h := &controllers.DataHandler{
+		Readings: repository.NewMemoryReadings(
+			models.TimeToDry{Timestamp: now.Add(-2 * time.Minute), HumIn: 70, TestID: 7},
+			models.TimeToDry{Timestamp: now.Add(-time.Minute), HumIn: 65, TestID: 7},
+			models.TimeToDry{Timestamp: now.Add(-48 * time.Hour), HumIn: 80, TestID: 6},
+		),
+		Weather:    repository.NewMemoryWeather(models.TMD{Timestamp: now.Add(-30 * time.Hour)}, models.TMD{Timestamp: now.Add(-time.Hour), Temperature: 32}),
+		Combined:   repository.NewMemoryCombined(),
+		Sessions:   store,
+		Models:     activeModel{models.DryTimeModel{Version: 3, Intercept: 90}},
+		Forecast:   &weather.Fixture{Path: sampleFixture},
+		Thresholds: weather.DefaultThresholds,
+	}
+
+	w := httptest.NewRecorder()
+	h.GetLastRowOfLatestTestID(w, httptest.NewRequest("GET", "/api/ttd/latest/last", nil))
+	var last models.TimeToDry
+	if err := json.Unmarshal(w.Body.Bytes(), &last); err != nil || last.HumIn != 65 {
+		t.Errorf("expected the 65%% reading of session 7, got %d %s", w.Code, w.Body)
+	}
+
+	w = httptest.NewRecorder()
+	h.CheckDeviceStatus(w, httptest.NewRequest("GET", "/api/ttd/status", nil))
+	var status map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &status); err != nil || status["is_working"] != true || status["latest_test_id"] != float64(7) {
+		t.Errorf("unexpected status %d %s", w.Code, w.Body)
+	}
+
+	w = httptest.NewRecorder()
+	h.TMDLast24Hours(w, httptest.NewRequest("GET", "/api/tmd/recent", nil))
+	var recent []models.TMD
+	if err := json.Unmarshal(w.Body.Bytes(), &recent); err != nil || len(recent) != 1 || recent[0].Temperature != 32 {
+		t.Errorf("expected the record of the last 24 hours, got %s", w.Body)
+	}
+
+	w = httptest.NewRecorder()
+	h.GetTimeToDry(w, httptest.NewRequest("GET", "/api/timetodry?order=desc&limit=1", nil))
+	var page struct {
+		Data       []models.TimeToDry `json:"data"`
+		Pagination listing.Pagination `json:"pagination"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &page); err != nil || len(page.Data) != 1 || page.Data[0].HumIn != 65 || page.Pagination.Total != 3 || !page.Pagination.HasMore {
+		t.Errorf("unexpected page %s", w.Body)
+	}
+
+	w = httptest.NewRecorder()
+	h.GetCombinedData(w, httptest.NewRequest("GET", "/api/combined", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("expected an empty combined page, got %d %s", w.Code, w.Body)
+	}
+
+	w = httptest.NewRecorder()
+	h.EstimateDryTime(w, httptest.NewRequest("GET", "/api/drytime/estimate?temp_in=30&temp_out=30&hum_in=60&hum_out=60&light=0", nil))
+	var estimate map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &estimate); err != nil || estimate["model_version"] != float64(3) || estimate["estimated_drying_time_minutes"] != float64(90) {
+		t.Errorf("expected the estimate of model v3, got %d %s", w.Code, w.Body)
+	}
+
+	w = httptest.NewRecorder()
+	h.RainForecast(w, httptest.NewRequest("GET", "/api/forecast/rain?hours=6", nil))
+	var forecast map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &forecast); err != nil || forecast["provider"] != h.Forecast.Name() || forecast["hours"] != float64(6) {
+		t.Errorf("expected the forecast of the fixture, got %d %s", w.Code, w.Body)
+	}
+
+	h.Forecast = nil
+	w = httptest.NewRecorder()
+	h.RainForecast(w, httptest.NewRequest("GET", "/api/forecast/rain", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503 without a weather provider, got %d", w.Code)
+	}
+}
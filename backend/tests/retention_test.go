@@ -0,0 +1,123 @@
+package tests
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"backend/database"
+	"backend/models"
+	"backend/retention"
+)
+
+// retentionJob keeps 30 days of readings and weather, and 60 days of per-minute rollups.
+func retentionJob() *retention.Job {
+	job := retention.New()
+	for i := range job.Config.Sources {
+		job.Config.Sources[i].KeepDays = 30
+	}
+	job.Config.MinuteRollupDays = 60
+	now := localTime("2025-06-10 12:30:00")
+	job.Now = func() time.Time { return now }
+	return job
+}
+
+func countRows(t *testing.T, table string) int64 {
+	t.Helper()
+	var n int64
+	if err := database.DB.Table(table).Count(&n).Error; err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+// TestRetentionPrune rolls old readings up per minute and per hour, deletes them and keeps recent ones.
+func TestRetentionPrune(t *testing.T) {
+	useTestDB(t)
+	old := localTime("2025-05-01 09:00:00")
+	// Sessions 1 and 2 report every 20 seconds for two minutes, 40 days ago.
+	for i := range 6 {
+		for _, testID := range []int{1, 2} {
+			database.DB.Create(&models.TimeToDry{Timestamp: old.Add(time.Duration(i*20) * time.Second), HumIn: float64(60 + i + testID*10), TestID: testID})
+		}
+	}
+	database.DB.Create(&models.TimeToDry{Timestamp: localTime("2025-06-05 09:00:00"), HumIn: 50, TestID: 3})
+	database.DB.Create(&models.TMD{Timestamp: old, Temperature: 30})
+	database.DB.Create(&models.TMD{Timestamp: old.Add(30 * time.Minute), Temperature: 32})
+	// A minute rollup past its own retention.
+	database.DB.Table(models.RollupMinuteTable).Create(&models.Rollup{Source: "tmd", BucketStart: localTime("2025-03-01 09:00:00"), Field: "temperature", Count: 1})
+
+	job := retentionJob()
+	report, err := job.Prune(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	readings := report.Tables[0]
+	if !report.DryRun || readings.Table != "time_to_dry" || readings.Removed != 12 || readings.HourRollups != 2*9 || readings.MinuteRollups != 4*9 ||
+		!readings.Cutoff.Equal(localTime("2025-05-11 12:00:00")) || report.Tables[1].Removed != 2 || report.PrunedRollups != 1 {
+		t.Errorf("unexpected dry run report %+v", report)
+	}
+	if countRows(t, "time_to_dry") != 13 || countRows(t, models.RollupHourTable) != 0 || job.Metrics().Runs != 0 {
+		t.Fatal("a dry run must not change anything")
+	}
+
+	if _, err := job.Prune(false); err != nil {
+		t.Fatal(err)
+	}
+	if countRows(t, "time_to_dry") != 1 || countRows(t, "tmd") != 0 {
+		t.Errorf("expected only the recent reading kept, got %d readings and %d weather records", countRows(t, "time_to_dry"), countRows(t, "tmd"))
+	}
+	var hour models.Rollup
+	database.DB.Table(models.RollupHourTable).Where("source = ? AND test_id = ? AND field = ?", "time_to_dry", 2, "hum_in").First(&hour)
+	if !hour.BucketStart.Equal(old) || hour.Count != 6 || hour.Min != 80 || hour.Max != 85 || hour.Avg != 82.5 {
+		t.Errorf("unexpected hour rollup %+v", hour)
+	}
+	var weather []models.Rollup
+	database.DB.Table(models.RollupMinuteTable).Where("source = ? AND field = ?", "tmd", "temperature").Order("bucket_start").Find(&weather)
+	if len(weather) != 2 || !weather[1].BucketStart.Equal(old.Add(30*time.Minute)) || weather[1].Avg != 32 {
+		t.Errorf("expected the old minute rollup pruned and two new ones, got %+v", weather)
+	}
+
+	// A reading of the same hour imported later is merged into its rollups.
+	database.DB.Create(&models.TimeToDry{Timestamp: old.Add(10 * time.Minute), HumIn: 90, TestID: 2})
+	if _, err := job.Prune(false); err != nil {
+		t.Fatal(err)
+	}
+	var merged models.Rollup
+	database.DB.Table(models.RollupHourTable).Where("source = ? AND test_id = ? AND field = ?", "time_to_dry", 2, "hum_in").First(&merged)
+	if merged.Count != 7 || merged.Max != 90 || merged.Avg != 83.571 {
+		t.Errorf("unexpected merged hour rollup %+v", merged)
+	}
+
+	m := job.Metrics()
+	if m.Runs != 2 || m.RowsRemoved["time_to_dry"] != 13 || m.RowsRemoved["tmd"] != 2 || m.RollupsPruned != 1 || m.LastRun == nil || m.Failures != 0 {
+		t.Errorf("unexpected metrics %+v", m)
+	}
+}
+
+// TestRetentionCommand prints the report of a dry run.
+func TestRetentionCommand(t *testing.T) {
+	useTestDB(t)
+	database.DB.Create(&models.TMD{Timestamp: localTime("2025-05-01 09:00:00"), Temperature: 30})
+	var out strings.Builder
+	if err := retentionJob().RunCommand([]string{"-dry-run"}, &out); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), `"dry_run": true`) || !strings.Contains(out.String(), `"removed": 1`) || countRows(t, "tmd") != 1 {
+		t.Errorf("unexpected dry run output:\n%s", out.String())
+	}
+}
+
+// TestRetentionConfigFromEnv reads the retention from the environment and rejects a zero interval.
+func TestRetentionConfigFromEnv(t *testing.T) {
+	t.Setenv("RETENTION_TMD_DAYS", "7")
+	t.Setenv("RETENTION_MINUTE_ROLLUP_DAYS", "-1")
+	t.Setenv("RETENTION_INTERVAL", "0s")
+	cfg := retention.NewFromEnv().Config
+	if cfg.Sources[1].Table != "tmd" || cfg.Sources[1].KeepDays != 7 || cfg.Sources[0].KeepDays != 90 {
+		t.Errorf("unexpected sources %+v", cfg.Sources)
+	}
+	if cfg.MinuteRollupDays != 365 || cfg.Interval != retention.DefaultInterval {
+		t.Errorf("expected the defaults for invalid values, got %+v", cfg)
+	}
+}
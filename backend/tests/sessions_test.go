@@ -0,0 +1,147 @@
+package tests
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"backend/models"
+	"backend/sessions"
+)
+
+// newTestManager returns a session manager on an in-memory store with a controllable clock.
+func newTestManager() (*sessions.Manager, *time.Time) {
+	clock := time.Date(2025, 5, 1, 9, 0, 0, 0, time.Local)
+	m := sessions.NewManager(sessions.NewMemoryStore())
+	m.Now = func() time.Time { return clock }
+	return m, &clock
+}
+
+// TestSessionAutoStartAfterGap checks readings after a silence time out the old session and start a new one.
+func TestSessionAutoStartAfterGap(t *testing.T) {
+	m, clock := newTestManager()
+
+	first, err := m.Record(*clock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.Origin != models.SessionOriginAuto || first.Status != models.SessionActive {
+		t.Fatalf("expected active auto session, got %+v", first)
+	}
+
+	*clock = clock.Add(2 * time.Minute)
+	same, _ := m.Record(*clock)
+	if same.TestID != first.TestID {
+		t.Errorf("reading within gap started session %d, expected %d", same.TestID, first.TestID)
+	}
+
+	lastReading := *clock
+	*clock = clock.Add(20 * time.Minute)
+	next, _ := m.Record(*clock)
+	if next.TestID == first.TestID {
+		t.Fatal("reading after gap did not start a new session")
+	}
+
+	ended, err := m.Get(first.TestID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ended.EndReason != models.EndReasonTimeout || ended.EndedAt == nil {
+		t.Fatalf("expected first session to time out, got %+v", ended)
+	}
+	if !ended.EndedAt.Equal(lastReading) {
+		t.Errorf("expected ended_at %s (last reading), got %s", lastReading, *ended.EndedAt)
+	}
+}
+
+// TestSessionManualStartStop checks the manual lifecycle and its conflict errors.
+func TestSessionManualStartStop(t *testing.T) {
+	m, clock := newTestManager()
+
+	s, err := m.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Start(); !errors.Is(err, sessions.ErrSessionActive) {
+		t.Errorf("expected ErrSessionActive, got %v", err)
+	}
+
+	// Readings go to the manually started session.
+	*clock = clock.Add(time.Minute)
+	r, _ := m.Record(*clock)
+	if r.TestID != s.TestID {
+		t.Errorf("reading attached to %d, expected %d", r.TestID, s.TestID)
+	}
+
+	if _, err := m.Stop(s.TestID, "bogus"); err == nil {
+		t.Error("expected error for unknown end reason")
+	}
+	stopped, err := m.Stop(s.TestID, models.EndReasonRain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stopped.Status != models.SessionCompleted || stopped.EndReason != models.EndReasonRain {
+		t.Errorf("unexpected stopped session: %+v", stopped)
+	}
+	if _, err := m.Stop(s.TestID, models.EndReasonManual); !errors.Is(err, sessions.ErrSessionEnded) {
+		t.Errorf("expected ErrSessionEnded, got %v", err)
+	}
+	if _, err := m.Stop(999, models.EndReasonManual); !errors.Is(err, sessions.ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
+
+// TestSessionExpireIdle checks silent sessions are ended by the idle sweep.
+func TestSessionExpireIdle(t *testing.T) {
+	m, clock := newTestManager()
+	s, _ := m.Record(*clock)
+
+	*clock = clock.Add(3 * time.Minute)
+	if expired, _ := m.ExpireIdle(); expired != nil {
+		t.Fatal("session expired before the gap elapsed")
+	}
+
+	*clock = clock.Add(10 * time.Minute)
+	expired, err := m.ExpireIdle()
+	if err != nil || expired == nil || expired.TestID != s.TestID {
+		t.Fatalf("expected session %d to expire, got %+v (%v)", s.TestID, expired, err)
+	}
+	if active, _ := m.Active(); active != nil {
+		t.Errorf("expected no active session, got %+v", active)
+	}
+}
+
+// TestSessionTimesStoredInUTC checks session times are stored in UTC, so a
+// change of the display zone does not move them, and written as RFC 3339.
+func TestSessionTimesStoredInUTC(t *testing.T) {
+	previous := time.Local
+	time.Local = time.FixedZone("ICT", 7*60*60)
+	t.Cleanup(func() { time.Local = previous })
+	useTestDB(t)
+
+	started := time.Date(2025, 5, 1, 9, 0, 0, 0, time.Local)
+	ended := started.Add(2 * time.Hour)
+	store := sessions.GormStore{}
+	if err := store.Create(&models.DryingSession{TestID: 3, Status: models.SessionCompleted, StartedAt: started, EndedAt: &ended}); err != nil {
+		t.Fatal(err)
+	}
+
+	time.Local = time.FixedZone("EST", -5*60*60)
+	s, err := store.Get(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !s.StartedAt.Equal(started) || s.EndedAt == nil || !s.EndedAt.Equal(ended) {
+		t.Errorf("expected the stored times back, got %+v", s)
+	}
+	body, err := json.Marshal(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fields map[string]any
+	json.Unmarshal(body, &fields)
+	if fields["started_at"] != "2025-05-01T02:00:00Z" || fields["ended_at"] != "2025-05-01T04:00:00Z" {
+		t.Errorf("expected RFC 3339 times in UTC, got %s", body)
+	}
+}
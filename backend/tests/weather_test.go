@@ -0,0 +1,207 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"backend/weather"
+)
+
+const sampleFixture = "../weather/testdata/sample.json"
+
+var bangkok = weather.Location{Lat: 13.84, Lon: 100.58}
+
+// TestFixtureProvider checks offsets in the fixture are resolved against the current hour.
+func TestFixtureProvider(t *testing.T) {
+	now := time.Date(2025, 5, 1, 14, 25, 0, 0, time.UTC)
+	p := &weather.Fixture{Path: sampleFixture, Now: func() time.Time { return now }}
+
+	current, err := p.Current(context.Background(), bangkok)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if current.Condition != "Clouds" || current.IsRainy() {
+		t.Errorf("unexpected current conditions: %+v", current)
+	}
+
+	hourly, err := p.Hourly(context.Background(), bangkok, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hourly) != 5 {
+		t.Fatalf("expected 5 hours, got %d", len(hourly))
+	}
+	if want := time.Date(2025, 5, 1, 17, 0, 0, 0, time.UTC); !hourly[3].Time.Equal(want) {
+		t.Errorf("expected hour 3 at %v, got %v", want, hourly[3].Time)
+	}
+	if hourly[4].PrecipProbability != 0.85 {
+		t.Errorf("expected precip probability 0.85, got %v", hourly[4].PrecipProbability)
+	}
+}
+
+// TestOpenWeatherMapProvider decodes canned OpenWeatherMap responses.
+func TestOpenWeatherMapProvider(t *testing.T) {
+	var noOneCall atomic.Bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("appid") != "secret" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/data/2.5/weather":
+			w.Write([]byte(`{"dt": 1746100000, "weather": [{"main": "Rain", "description": "light rain"}],
+				"main": {"temp": 29.5, "humidity": 83}, "rain": {"1h": 0.6}}`))
+		case "/data/3.0/onecall":
+			if noOneCall.Load() {
+				http.Error(w, "no One Call 3.0 subscription", http.StatusUnauthorized)
+				return
+			}
+			w.Write([]byte(`{"hourly": [
+				{"dt": 1746100800, "temp": 29, "humidity": 85, "pop": 0.9, "rain": {"1h": 2.1}, "weather": [{"main": "Rain", "description": "moderate rain"}]},
+				{"dt": 1746104400, "temp": 30, "humidity": 80, "pop": 0.2, "weather": [{"main": "Clouds", "description": "few clouds"}]}]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	p := &weather.OpenWeatherMap{APIKey: "secret", BaseURL: srv.URL}
+	current, err := p.Current(context.Background(), bangkok)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !current.IsRainy() || current.Temperature != 29.5 || current.Precipitation != 0.6 {
+		t.Errorf("unexpected current conditions: %+v", current)
+	}
+
+	hourly, err := p.Hourly(context.Background(), bangkok, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hourly) != 1 || hourly[0].PrecipProbability != 0.9 || hourly[0].Precipitation != 2.1 {
+		t.Errorf("unexpected hourly forecast: %+v", hourly)
+	}
+
+	bad := &weather.OpenWeatherMap{APIKey: "wrong", BaseURL: srv.URL}
+	if _, err := bad.Current(context.Background(), bangkok); err == nil {
+		t.Error("expected error for rejected API key")
+	}
+
+	// Without the hourly forecast the outlook is marked partial.
+	noOneCall.Store(true)
+	o, err := weather.FetchRainOutlook(context.Background(), p, bangkok, 3, weather.DefaultThresholds, time.Unix(1746100000, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !o.Partial || !o.RainingNow || len(o.Hours) != 0 {
+		t.Errorf("expected a partial outlook of the current weather, got %+v", o)
+	}
+}
+
+// TestOpenMeteoProvider decodes a canned Open-Meteo response.
+func TestOpenMeteoProvider(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{
+			"current": {"time": 1746100000, "temperature_2m": 31.2, "relative_humidity_2m": 70, "precipitation": 0, "weather_code": 2},
+			"hourly": {"time": [1746100800, 1746104400], "temperature_2m": [31, 30], "relative_humidity_2m": [70, 78],
+				"precipitation_probability": [10, 75], "precipitation": [0, 1.4], "weather_code": [2, 81]}}`))
+	}))
+	defer srv.Close()
+
+	p := &weather.OpenMeteo{BaseURL: srv.URL}
+	current, err := p.Current(context.Background(), bangkok)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if current.Condition != "Clouds" || current.IsRainy() {
+		t.Errorf("unexpected current conditions: %+v", current)
+	}
+
+	hourly, err := p.Hourly(context.Background(), bangkok, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hourly) != 2 || hourly[1].Condition != "Rain" || hourly[1].PrecipProbability != 0.75 {
+		t.Errorf("unexpected hourly forecast: %+v", hourly)
+	}
+}
+
+// TestWeatherProviderFromEnv checks the provider is selected by configuration.
+func TestWeatherProviderFromEnv(t *testing.T) {
+	t.Setenv("WEATHER_PROVIDER", "fixture")
+	t.Setenv("WEATHER_FIXTURE", sampleFixture)
+	p, err := weather.FromEnv()
+	if err != nil || p.Name() != "fixture" {
+		t.Fatalf("expected fixture provider, got %v (%v)", p, err)
+	}
+
+	t.Setenv("WEATHER_PROVIDER", "openmeteo")
+	if p, _ := weather.FromEnv(); p == nil || p.Name() != "openmeteo" {
+		t.Errorf("expected openmeteo provider, got %v", p)
+	}
+
+	t.Setenv("WEATHER_PROVIDER", "openweathermap")
+	t.Setenv("OWM_API_KEY", "")
+	if _, err := weather.FromEnv(); err == nil {
+		t.Error("expected error when OWM_API_KEY is missing")
+	}
+
+	t.Setenv("WEATHER_PROVIDER", "bogus")
+	if _, err := weather.FromEnv(); err == nil {
+		t.Error("expected error for unknown provider")
+	}
+}
+
+// TestRainOutlookFromFixture checks when rain starts and until when laundry is safe.
+func TestRainOutlookFromFixture(t *testing.T) {
+	now := time.Date(2025, 5, 1, 14, 25, 0, 0, time.UTC)
+	p := &weather.Fixture{Path: sampleFixture, Now: func() time.Time { return now }}
+	current, _ := p.Current(context.Background(), bangkok)
+	hourly, err := p.Hourly(context.Background(), bangkok, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	o := weather.NewRainOutlook(current, hourly, now, weather.DefaultThresholds)
+	if o.RainingNow || !o.RainExpected {
+		t.Fatalf("expected rain later but not now, got %+v", o)
+	}
+	rainAt := time.Date(2025, 5, 1, 17, 0, 0, 0, time.UTC)
+	if o.RainStartsAt == nil || !o.RainStartsAt.Equal(rainAt) || !o.SafeUntil.Equal(rainAt) {
+		t.Errorf("expected rain and safe-until at %v, got %v / %v", rainAt, o.RainStartsAt, o.SafeUntil)
+	}
+	if o.RainSoon(now, 2*time.Hour) || !o.RainSoon(now, 3*time.Hour) {
+		t.Error("expected rain within 3 hours but not within 2")
+	}
+	if len(o.Hours) != 8 || !o.Hours[4].Rain || o.Hours[6].Rain {
+		t.Errorf("unexpected hourly flags: %+v", o.Hours)
+	}
+}
+
+// TestRainOutlookDryAndRainingNow covers a dry window and rain at the current hour.
+func TestRainOutlookDryAndRainingNow(t *testing.T) {
+	now := time.Date(2025, 5, 1, 9, 30, 0, 0, time.UTC)
+	hour := now.Truncate(time.Hour)
+	dry := []weather.HourlyForecast{
+		{Time: hour.Add(-time.Hour), PrecipProbability: 0.9, Precipitation: 4},
+		{Time: hour, PrecipProbability: 0.1},
+		{Time: hour.Add(time.Hour), PrecipProbability: 0.2},
+	}
+
+	o := weather.NewRainOutlook(&weather.Conditions{Condition: "Clear"}, dry, now, weather.DefaultThresholds)
+	if o.RainExpected || len(o.Hours) != 2 {
+		t.Fatalf("expected a dry two-hour window, got %+v", o)
+	}
+	if want := hour.Add(2 * time.Hour); !o.SafeUntil.Equal(want) {
+		t.Errorf("expected safe until end of window %v, got %v", want, o.SafeUntil)
+	}
+
+	o = weather.NewRainOutlook(&weather.Conditions{Condition: "Rain", Description: "light rain"}, dry, now, weather.DefaultThresholds)
+	if !o.RainingNow || !o.SafeUntil.Equal(now) || !o.RainSoon(now, 0) {
+		t.Errorf("expected rain now, got %+v", o)
+	}
+}
@@ -0,0 +1,161 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"backend/alerts"
+	"backend/events"
+	"backend/models"
+	"backend/ws"
+
+	"github.com/gorilla/websocket"
+)
+
+// newTestWSServer serves a ws.Server on its own bus, an in-memory session manager and alert store.
+func newTestWSServer(t *testing.T) (*ws.Server, *httptest.Server) {
+	t.Helper()
+	m, _ := newTestManager()
+	bus := events.NewBus(64)
+	m.Events = bus
+
+	s := ws.NewServer()
+	s.Bus = bus
+	s.Sessions = m
+	s.Alerts = alerts.Publishing{Store: &memoryAlertStore{}, Bus: bus}
+	s.Now = func() time.Time { return time.Date(2025, 5, 1, 9, 30, 0, 0, time.Local) }
+	srv := httptest.NewServer(s)
+	t.Cleanup(srv.Close)
+	return s, srv
+}
+
+func dialWS(t *testing.T, srv *httptest.Server) *websocket.Conn {
+	t.Helper()
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// wsReply is a ws.ServerMessage with its data left undecoded.
+type wsReply struct {
+	Type  string          `json:"type"`
+	ID    string          `json:"id"`
+	Error string          `json:"error"`
+	Data  json.RawMessage `json:"data"`
+	Event *events.Event   `json:"event"`
+}
+
+func readWS(t *testing.T, conn *websocket.Conn) wsReply {
+	t.Helper()
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	var msg wsReply
+	if err := conn.ReadJSON(&msg); err != nil {
+		t.Fatal(err)
+	}
+	return msg
+}
+
+// sendWS sends a command and returns the answer to it.
+func sendWS(t *testing.T, conn *websocket.Conn, cmd ws.ClientMessage) wsReply {
+	t.Helper()
+	if err := conn.WriteJSON(cmd); err != nil {
+		t.Fatal(err)
+	}
+	reply := readWS(t, conn)
+	if reply.ID != cmd.ID || (reply.Type != ws.MessageResult && reply.Type != ws.MessageError) {
+		t.Fatalf("expected the answer to %q, got %+v", cmd.ID, reply)
+	}
+	return reply
+}
+
+// TestWebSocketCommandsShareTheBus drives sessions and alerts over one connection and checks a second client sees the events.
+func TestWebSocketCommandsShareTheBus(t *testing.T) {
+	s, srv := newTestWSServer(t)
+	dashboard, remote := dialWS(t, srv), dialWS(t, srv)
+
+	if r := sendWS(t, dashboard, ws.ClientMessage{ID: "1", Type: ws.CommandSubscribe, Topics: []string{ws.TopicSession, ws.TopicAlerts}}); r.Type != ws.MessageResult {
+		t.Fatalf("subscribe failed: %s", r.Error)
+	}
+
+	r := sendWS(t, remote, ws.ClientMessage{ID: "start", Type: ws.CommandStartSession})
+	var started models.DryingSession
+	if err := json.Unmarshal(r.Data, &started); err != nil || started.Status != models.SessionActive {
+		t.Fatalf("expected the started session, got %+v (%v)", r, err)
+	}
+	if r := sendWS(t, remote, ws.ClientMessage{ID: "again", Type: ws.CommandStartSession}); r.Type != ws.MessageError {
+		t.Errorf("expected starting a second session to fail, got %+v", r)
+	}
+	sendWS(t, remote, ws.ClientMessage{ID: "stop", Type: ws.CommandStopSession})
+
+	for _, want := range []string{models.SessionActive, models.SessionCompleted} {
+		e := readWS(t, dashboard)
+		if e.Type != ws.MessageEvent || e.Event.Type != events.TypeSession || e.Event.TestID != started.TestID {
+			t.Fatalf("expected a session event, got %+v", e)
+		}
+		if status := e.Event.Data.(map[string]any)["status"]; status != want {
+			t.Errorf("expected %s session, got %v", want, status)
+		}
+	}
+
+	alert := &models.Alert{Kind: models.AlertRain, Message: "Rain soon"}
+	s.Alerts.Record(alert)
+	if e := readWS(t, dashboard); e.Event == nil || e.Event.Type != events.TypeAlert {
+		t.Fatalf("expected the rain alert, got %+v", e)
+	}
+	r = sendWS(t, remote, ws.ClientMessage{ID: "ack", Type: ws.CommandAckAlert, AlertID: alert.ID})
+	var acked models.Alert
+	if err := json.Unmarshal(r.Data, &acked); err != nil || acked.AcknowledgedAt == nil || !acked.AcknowledgedAt.Equal(localTime("2025-05-01 09:30:00")) {
+		t.Fatalf("expected the acknowledged alert, got %+v (%v)", r, err)
+	}
+	if e := readWS(t, dashboard); e.Event == nil || e.Event.Data.(map[string]any)["acknowledged_at"] == nil {
+		t.Errorf("expected the acknowledged alert event, got %+v", e)
+	}
+	if r := sendWS(t, remote, ws.ClientMessage{ID: "missing", Type: ws.CommandAckAlert, AlertID: 99}); r.Type != ws.MessageError {
+		t.Errorf("expected acknowledging an unknown alert to fail, got %+v", r)
+	}
+}
+
+// TestWebSocketSubscriptions checks topic filtering, resuming with last_event_id and rejected messages.
+func TestWebSocketSubscriptions(t *testing.T) {
+	s, srv := newTestWSServer(t)
+	conn := dialWS(t, srv)
+
+	seen := s.Bus.Publish(events.TypeReading, "dev-a", 1, nil)
+	missed := s.Bus.Publish(events.TypeReading, "dev-a", 1, nil)
+	s.Bus.Publish(events.TypeReading, "dev-b", 1, nil)
+	s.Bus.Publish(events.TypeAlert, "", 0, nil)
+
+	sendWS(t, conn, ws.ClientMessage{ID: "sub", Type: ws.CommandSubscribe, Topics: []string{ws.TopicDevice}, Device: "dev-a", LastEventID: seen.ID})
+	if e := readWS(t, conn); e.Event == nil || e.Event.ID != missed.ID {
+		t.Fatalf("expected to resume with event %d, got %+v", missed.ID, e)
+	}
+
+	// Only the dev-a reading passes; unsubscribing stops the readings.
+	s.Bus.Publish(events.TypeReading, "dev-b", 1, nil)
+	live := s.Bus.Publish(events.TypeReading, "dev-a", 1, nil)
+	if e := readWS(t, conn); e.Event == nil || e.Event.ID != live.ID {
+		t.Fatalf("expected live event %d, got %+v", live.ID, e)
+	}
+	sendWS(t, conn, ws.ClientMessage{ID: "unsub", Type: ws.CommandUnsubscribe, Topics: []string{ws.TopicDevice}})
+	s.Bus.Publish(events.TypeReading, "dev-a", 1, nil)
+
+	for _, bad := range []ws.ClientMessage{
+		{ID: "topic", Type: ws.CommandSubscribe, Topics: []string{"weather"}},
+		{ID: "type", Type: "reboot"},
+		{ID: "ack", Type: ws.CommandAckAlert},
+	} {
+		if r := sendWS(t, conn, bad); r.Type != ws.MessageError {
+			t.Errorf("expected %q to be rejected, got %+v", bad.ID, r)
+		}
+	}
+	conn.WriteMessage(websocket.TextMessage, []byte("not json"))
+	if r := readWS(t, conn); r.Type != ws.MessageError {
+		t.Errorf("expected invalid JSON to be rejected, got %+v", r)
+	}
+}
@@ -2,11 +2,33 @@ package utils
 
 import "time"
 
+// TimestampLayout is the format times are shown in, in local time. The
+// sensor tables stored their timestamps in it before they moved to UTC.
+const TimestampLayout = "2006-01-02 15:04:05"
+
 func ParseTimestamp(ts string) (time.Time, error) {
 	// Try ISO 8601 format first
 	if t, err := time.Parse(time.RFC3339, ts); err == nil {
 		return t, nil
 	}
 	// Fallback to custom format if needed
-	return time.Parse("2006-01-02 15:04:05", ts)
+	return time.Parse(TimestampLayout, ts)
+}
+
+// ParseLocalTimestamp reads a timestamp in TimestampLayout as local time.
+// RFC 3339 values are converted to local time.
+func ParseLocalTimestamp(ts string) (time.Time, error) {
+	if t, err := time.ParseInLocation(TimestampLayout, ts, time.Local); err == nil {
+		return t, nil
+	}
+	t, err := ParseTimestamp(ts)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return t.In(time.Local), nil
+}
+
+// FormatTimestamp formats t in local time using TimestampLayout.
+func FormatTimestamp(t time.Time) string {
+	return t.In(time.Local).Format(TimestampLayout)
 }
@@ -7,13 +7,23 @@ import (
 	"github.com/line/line-bot-sdk-go/v7/linebot"
 )
 
-func PushLineMessage(message string, userID string) error {
+// MaxMulticastRecipients is the LINE limit of user IDs per multicast.
+const MaxMulticastRecipients = 500
+
+func newLineBot() (*linebot.Client, error) {
 	bot, err := linebot.New(
 		os.Getenv("LINE_CHANNEL_SECRET"),
 		os.Getenv("LINE_CHANNEL_TOKEN"),
 	)
 	if err != nil {
 		log.Println("Failed to create LINE bot client:", err)
+	}
+	return bot, err
+}
+
+func PushLineMessage(message string, userID string) error {
+	bot, err := newLineBot()
+	if err != nil {
 		return err
 	}
 
@@ -26,3 +36,24 @@ func PushLineMessage(message string, userID string) error {
 	log.Println("✅ LINE message sent successfully")
 	return nil
 }
+
+// MulticastLineMessage sends message to several LINE users at once, in
+// batches of MaxMulticastRecipients. Groups and rooms cannot be multicast
+// to; use PushLineMessage for them.
+func MulticastLineMessage(message string, userIDs []string) error {
+	bot, err := newLineBot()
+	if err != nil {
+		return err
+	}
+
+	for start := 0; start < len(userIDs); start += MaxMulticastRecipients {
+		end := min(start+MaxMulticastRecipients, len(userIDs))
+		if _, err := bot.Multicast(userIDs[start:end], linebot.NewTextMessage(message)).Do(); err != nil {
+			log.Println("Failed to multicast LINE message:", err)
+			return err
+		}
+	}
+
+	log.Printf("✅ LINE message sent to %d users", len(userIDs))
+	return nil
+}
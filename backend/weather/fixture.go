@@ -0,0 +1,88 @@
+package weather
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// Fixture serves weather from a JSON file, for tests and offline development.
+// Hourly entries may give an absolute "time" or an "offset_hours" from the
+// current hour, so a fixture keeps describing the near future.
+//
+//	{
+//	  "current": {"temperature": 31, "humidity": 70, "condition": "Clouds"},
+//	  "hourly": [{"offset_hours": 1, "precip_probability": 0.8, "precipitation": 2.5}]
+//	}
+type Fixture struct {
+	Path string
+	// Now returns the current time. Defaults to time.Now.
+	Now func() time.Time
+}
+
+type fixtureFile struct {
+	Current *Conditions `json:"current"`
+	Hourly  []struct {
+		HourlyForecast
+		OffsetHours *int `json:"offset_hours"`
+	} `json:"hourly"`
+}
+
+func (p *Fixture) Name() string { return "fixture" }
+
+func (p *Fixture) now() time.Time {
+	if p.Now != nil {
+		return p.Now()
+	}
+	return time.Now()
+}
+
+func (p *Fixture) load() (*fixtureFile, error) {
+	raw, err := os.ReadFile(p.Path)
+	if err != nil {
+		return nil, fmt.Errorf("weather fixture: %w", err)
+	}
+	var f fixtureFile
+	if err := json.Unmarshal(raw, &f); err != nil {
+		return nil, fmt.Errorf("weather fixture %s: %w", p.Path, err)
+	}
+	return &f, nil
+}
+
+func (p *Fixture) Current(_ context.Context, _ Location) (*Conditions, error) {
+	f, err := p.load()
+	if err != nil {
+		return nil, err
+	}
+	if f.Current == nil {
+		return nil, fmt.Errorf("weather fixture %s has no current conditions", p.Path)
+	}
+	c := *f.Current
+	if c.Time.IsZero() {
+		c.Time = p.now()
+	}
+	return &c, nil
+}
+
+func (p *Fixture) Hourly(_ context.Context, _ Location, hours int) ([]HourlyForecast, error) {
+	f, err := p.load()
+	if err != nil {
+		return nil, err
+	}
+
+	hour := p.now().Truncate(time.Hour)
+	forecast := make([]HourlyForecast, 0, len(f.Hourly))
+	for _, h := range f.Hourly {
+		entry := h.HourlyForecast
+		if h.OffsetHours != nil {
+			entry.Time = hour.Add(time.Duration(*h.OffsetHours) * time.Hour)
+		}
+		forecast = append(forecast, entry)
+	}
+	if len(forecast) > hours {
+		forecast = forecast[:hours]
+	}
+	return forecast, nil
+}
@@ -0,0 +1,132 @@
+package weather
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// OpenMeteo reads current weather and hourly forecasts from open-meteo.com,
+// which needs no API key.
+type OpenMeteo struct {
+	// BaseURL defaults to https://api.open-meteo.com.
+	BaseURL string
+}
+
+type openMeteoResponse struct {
+	Current struct {
+		Time          int64   `json:"time"`
+		Temperature   float64 `json:"temperature_2m"`
+		Humidity      float64 `json:"relative_humidity_2m"`
+		Precipitation float64 `json:"precipitation"`
+		WeatherCode   int     `json:"weather_code"`
+	} `json:"current"`
+	Hourly struct {
+		Time                     []int64   `json:"time"`
+		Temperature              []float64 `json:"temperature_2m"`
+		Humidity                 []float64 `json:"relative_humidity_2m"`
+		PrecipitationProbability []float64 `json:"precipitation_probability"`
+		Precipitation            []float64 `json:"precipitation"`
+		WeatherCode              []int     `json:"weather_code"`
+	} `json:"hourly"`
+}
+
+func (p *OpenMeteo) Name() string { return "openmeteo" }
+
+func (p *OpenMeteo) fetch(ctx context.Context, loc Location, extra url.Values) (*openMeteoResponse, error) {
+	base := p.BaseURL
+	if base == "" {
+		base = "https://api.open-meteo.com"
+	}
+	q := url.Values{
+		"latitude":   {fmt.Sprint(loc.Lat)},
+		"longitude":  {fmt.Sprint(loc.Lon)},
+		"timeformat": {"unixtime"},
+		"timezone":   {"GMT"},
+	}
+	for k, v := range extra {
+		q[k] = v
+	}
+
+	var data openMeteoResponse
+	if err := getJSON(ctx, base+"/v1/forecast?"+q.Encode(), &data); err != nil {
+		return nil, fmt.Errorf("openmeteo: %w", err)
+	}
+	return &data, nil
+}
+
+func (p *OpenMeteo) Current(ctx context.Context, loc Location) (*Conditions, error) {
+	data, err := p.fetch(ctx, loc, url.Values{
+		"current": {"temperature_2m,relative_humidity_2m,precipitation,weather_code"},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	cur := data.Current
+	condition, description := describeWMOCode(cur.WeatherCode)
+	return &Conditions{
+		Time:          time.Unix(cur.Time, 0),
+		Temperature:   cur.Temperature,
+		Humidity:      cur.Humidity,
+		Precipitation: cur.Precipitation,
+		Condition:     condition,
+		Description:   description,
+	}, nil
+}
+
+func (p *OpenMeteo) Hourly(ctx context.Context, loc Location, hours int) ([]HourlyForecast, error) {
+	data, err := p.fetch(ctx, loc, url.Values{
+		"hourly":         {"temperature_2m,relative_humidity_2m,precipitation_probability,precipitation,weather_code"},
+		"forecast_hours": {fmt.Sprint(hours)},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	h := data.Hourly
+	n := min(hours, len(h.Time), len(h.Temperature), len(h.Humidity),
+		len(h.PrecipitationProbability), len(h.Precipitation), len(h.WeatherCode))
+	forecast := make([]HourlyForecast, n)
+	for i := range forecast {
+		condition, description := describeWMOCode(h.WeatherCode[i])
+		forecast[i] = HourlyForecast{
+			Time:              time.Unix(h.Time[i], 0),
+			Temperature:       h.Temperature[i],
+			Humidity:          h.Humidity[i],
+			PrecipProbability: h.PrecipitationProbability[i] / 100,
+			Precipitation:     h.Precipitation[i],
+			Condition:         condition,
+			Description:       description,
+		}
+	}
+	return forecast, nil
+}
+
+// describeWMOCode maps a WMO weather interpretation code to an
+// OpenWeatherMap-style main condition and description.
+func describeWMOCode(code int) (string, string) {
+	switch {
+	case code == 0:
+		return "Clear", "clear sky"
+	case code <= 3:
+		return "Clouds", "partly cloudy"
+	case code == 45 || code == 48:
+		return "Fog", "fog"
+	case code >= 51 && code <= 57:
+		return "Drizzle", "drizzle"
+	case code >= 61 && code <= 67:
+		return "Rain", "rain"
+	case code >= 71 && code <= 77:
+		return "Snow", "snow"
+	case code >= 80 && code <= 82:
+		return "Rain", "rain showers"
+	case code == 85 || code == 86:
+		return "Snow", "snow showers"
+	case code >= 95:
+		return "Thunderstorm", "thunderstorm"
+	default:
+		return "Unknown", fmt.Sprintf("weather code %d", code)
+	}
+}
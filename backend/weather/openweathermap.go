@@ -0,0 +1,113 @@
+package weather
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// OpenWeatherMap reads current weather from the 2.5 weather endpoint and the
+// hourly forecast from One Call 3.0.
+type OpenWeatherMap struct {
+	APIKey string
+	// BaseURL defaults to https://api.openweathermap.org.
+	BaseURL string
+}
+
+type owmCondition struct {
+	Main        string `json:"main"`
+	Description string `json:"description"`
+}
+
+type owmCurrent struct {
+	Dt      int64          `json:"dt"`
+	Weather []owmCondition `json:"weather"`
+	Main    struct {
+		Temp     float64 `json:"temp"`
+		Humidity float64 `json:"humidity"`
+	} `json:"main"`
+	Rain struct {
+		OneHour float64 `json:"1h"`
+	} `json:"rain"`
+}
+
+type owmOneCall struct {
+	Hourly []struct {
+		Dt       int64          `json:"dt"`
+		Temp     float64        `json:"temp"`
+		Humidity float64        `json:"humidity"`
+		Pop      float64        `json:"pop"`
+		Weather  []owmCondition `json:"weather"`
+		Rain     struct {
+			OneHour float64 `json:"1h"`
+		} `json:"rain"`
+	} `json:"hourly"`
+}
+
+func (p *OpenWeatherMap) Name() string { return "openweathermap" }
+
+func (p *OpenWeatherMap) baseURL() string {
+	if p.BaseURL != "" {
+		return p.BaseURL
+	}
+	return "https://api.openweathermap.org"
+}
+
+func (p *OpenWeatherMap) query(loc Location) url.Values {
+	return url.Values{
+		"lat":   {fmt.Sprint(loc.Lat)},
+		"lon":   {fmt.Sprint(loc.Lon)},
+		"units": {"metric"},
+		"appid": {p.APIKey},
+	}
+}
+
+func (p *OpenWeatherMap) Current(ctx context.Context, loc Location) (*Conditions, error) {
+	var data owmCurrent
+	if err := getJSON(ctx, p.baseURL()+"/data/2.5/weather?"+p.query(loc).Encode(), &data); err != nil {
+		return nil, fmt.Errorf("openweathermap current: %w", err)
+	}
+
+	c := &Conditions{
+		Time:          time.Unix(data.Dt, 0),
+		Temperature:   data.Main.Temp,
+		Humidity:      data.Main.Humidity,
+		Precipitation: data.Rain.OneHour,
+	}
+	if len(data.Weather) > 0 {
+		c.Condition = data.Weather[0].Main
+		c.Description = data.Weather[0].Description
+	}
+	return c, nil
+}
+
+func (p *OpenWeatherMap) Hourly(ctx context.Context, loc Location, hours int) ([]HourlyForecast, error) {
+	q := p.query(loc)
+	q.Set("exclude", "current,minutely,daily,alerts")
+
+	var data owmOneCall
+	if err := getJSON(ctx, p.baseURL()+"/data/3.0/onecall?"+q.Encode(), &data); err != nil {
+		return nil, fmt.Errorf("openweathermap hourly: %w", err)
+	}
+
+	forecast := make([]HourlyForecast, 0, hours)
+	for _, h := range data.Hourly {
+		if len(forecast) == hours {
+			break
+		}
+		f := HourlyForecast{
+			Time:              time.Unix(h.Dt, 0),
+			Temperature:       h.Temp,
+			Humidity:          h.Humidity,
+			PrecipProbability: h.Pop,
+			Precipitation:     h.Rain.OneHour,
+		}
+		if len(h.Weather) > 0 {
+			f.Condition = h.Weather[0].Main
+			f.Description = h.Weather[0].Description
+		}
+		forecast = append(forecast, f)
+	}
+	return forecast, nil
+}
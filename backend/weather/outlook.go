@@ -0,0 +1,121 @@
+package weather
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"time"
+
+	"backend/config"
+)
+
+// Thresholds decide when a forecast hour counts as rainy.
+type Thresholds struct {
+	// Probability is the minimum chance of precipitation, from 0 to 1.
+	Probability float64 `json:"probability"`
+	// Precipitation is the minimum expected rainfall in mm.
+	Precipitation float64 `json:"precipitation"`
+}
+
+// DefaultThresholds treat an hour as rainy at a 50% chance or 0.1 mm of rain.
+var DefaultThresholds = Thresholds{Probability: 0.5, Precipitation: 0.1}
+
+// ThresholdsFromEnv reads RAIN_PROBABILITY_THRESHOLD and RAIN_MIN_MM.
+func ThresholdsFromEnv() Thresholds {
+	t := DefaultThresholds
+	if v, err := strconv.ParseFloat(config.GetEnv("RAIN_PROBABILITY_THRESHOLD", ""), 64); err == nil {
+		t.Probability = v
+	}
+	if v, err := strconv.ParseFloat(config.GetEnv("RAIN_MIN_MM", ""), 64); err == nil {
+		t.Precipitation = v
+	}
+	return t
+}
+
+// IsRainy reports whether the forecast hour crosses the thresholds.
+func (h HourlyForecast) IsRainy(t Thresholds) bool {
+	return h.PrecipProbability >= t.Probability || h.Precipitation >= t.Precipitation
+}
+
+// HourOutlook is one hour of a RainOutlook.
+type HourOutlook struct {
+	HourlyForecast
+	Rain bool `json:"rain"`
+}
+
+// RainOutlook summarises the rain risk over the next hours.
+type RainOutlook struct {
+	Current      *Conditions   `json:"current,omitempty"`
+	RainingNow   bool          `json:"raining_now"`
+	Hours        []HourOutlook `json:"hourly"`
+	RainExpected bool          `json:"rain_expected"`
+	// RainStartsAt is when the first rainy hour begins, nil if no rain is expected.
+	RainStartsAt *time.Time `json:"rain_starts_at"`
+	// SafeUntil is how long laundry can stay out: the start of the rain, or
+	// the end of the forecast window when no rain is expected.
+	SafeUntil time.Time `json:"safe_to_hang_until"`
+	// Partial is set when the hourly forecast was unavailable, so the
+	// outlook says nothing about rain to come.
+	Partial bool `json:"partial"`
+}
+
+// RainSoon reports whether it is raining now or rain starts within d.
+func (o *RainOutlook) RainSoon(now time.Time, d time.Duration) bool {
+	if o.RainingNow {
+		return true
+	}
+	return o.RainStartsAt != nil && o.RainStartsAt.Sub(now) <= d
+}
+
+// NewRainOutlook builds the outlook from current conditions (optional) and
+// the hourly forecast. Hours that are already over are ignored.
+func NewRainOutlook(current *Conditions, hourly []HourlyForecast, now time.Time, t Thresholds) RainOutlook {
+	o := RainOutlook{Current: current, SafeUntil: now}
+	if current != nil && current.IsRainy() {
+		o.RainingNow = true
+		o.RainExpected = true
+		o.RainStartsAt = &now
+	}
+
+	for _, h := range hourly {
+		end := h.Time.Add(time.Hour)
+		if !end.After(now) {
+			continue
+		}
+		rainy := h.IsRainy(t)
+		o.Hours = append(o.Hours, HourOutlook{HourlyForecast: h, Rain: rainy})
+
+		if rainy && o.RainStartsAt == nil {
+			start := h.Time
+			if start.Before(now) {
+				start = now
+			}
+			o.RainExpected = true
+			o.RainStartsAt = &start
+		}
+		if o.RainStartsAt == nil {
+			o.SafeUntil = end
+		}
+	}
+	if o.RainStartsAt != nil {
+		o.SafeUntil = *o.RainStartsAt
+	}
+	return o
+}
+
+// FetchRainOutlook asks the provider for current conditions and the next
+// hours of forecast. If the forecast is unavailable the outlook falls back
+// to the current conditions alone and is marked Partial.
+func FetchRainOutlook(ctx context.Context, p Provider, loc Location, hours int, t Thresholds, now time.Time) (*RainOutlook, error) {
+	current, err := p.Current(ctx, loc)
+	if err != nil {
+		return nil, err
+	}
+	hourly, err := p.Hourly(ctx, loc, hours)
+	if err != nil {
+		log.Printf("Hourly forecast from %s unavailable, the rain outlook covers current weather only: %v", p.Name(), err)
+	}
+	o := NewRainOutlook(current, hourly, now, t)
+	o.Partial = err != nil
+	return &o, nil
+}
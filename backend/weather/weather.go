@@ -0,0 +1,119 @@
+package weather
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"backend/config"
+)
+
+// Location is a point on the map, in decimal degrees.
+type Location struct {
+	Lat float64 `json:"lat"`
+	Lon float64 `json:"lon"`
+}
+
+// Conditions describes the weather right now.
+type Conditions struct {
+	Time        time.Time `json:"time"`
+	Temperature float64   `json:"temperature"`
+	Humidity    float64   `json:"humidity"`
+	// Precipitation is the rainfall of the last hour, in mm.
+	Precipitation float64 `json:"precipitation"`
+	Condition     string  `json:"condition"`
+	Description   string  `json:"description"`
+}
+
+// IsRainy reports whether the conditions mention rain, showers or thunder.
+func (c *Conditions) IsRainy() bool {
+	return c.Precipitation > 0 || mentionsRain(c.Condition) || mentionsRain(c.Description)
+}
+
+// HourlyForecast is the forecast for the hour starting at Time.
+type HourlyForecast struct {
+	Time        time.Time `json:"time"`
+	Temperature float64   `json:"temperature"`
+	Humidity    float64   `json:"humidity"`
+	// PrecipProbability is the chance of precipitation, from 0 to 1.
+	PrecipProbability float64 `json:"precip_probability"`
+	// Precipitation is the expected rainfall during the hour, in mm.
+	Precipitation float64 `json:"precipitation"`
+	Condition     string  `json:"condition"`
+	Description   string  `json:"description"`
+}
+
+// Provider is a source of current weather and hourly forecasts.
+type Provider interface {
+	Name() string
+	Current(ctx context.Context, loc Location) (*Conditions, error)
+	// Hourly returns the forecast for the next hours hours, in chronological order.
+	Hourly(ctx context.Context, loc Location, hours int) ([]HourlyForecast, error)
+}
+
+func mentionsRain(s string) bool {
+	s = strings.ToLower(s)
+	return strings.Contains(s, "rain") || strings.Contains(s, "shower") ||
+		strings.Contains(s, "thunder") || strings.Contains(s, "drizzle")
+}
+
+var httpClient = &http.Client{Timeout: 10 * time.Second}
+
+// FromEnv builds the provider named by WEATHER_PROVIDER: "openweathermap"
+// (the default, needs OWM_API_KEY), "openmeteo" or "fixture" (reads
+// WEATHER_FIXTURE, for tests and offline development).
+func FromEnv() (Provider, error) {
+	switch name := strings.ToLower(config.GetEnv("WEATHER_PROVIDER", "openweathermap")); name {
+	case "openweathermap", "owm":
+		key := config.GetEnv("OWM_API_KEY", "")
+		if key == "" {
+			return nil, fmt.Errorf("OWM_API_KEY is required for the openweathermap provider")
+		}
+		return &OpenWeatherMap{APIKey: key}, nil
+	case "openmeteo", "open-meteo":
+		return &OpenMeteo{}, nil
+	case "fixture":
+		path := config.GetEnv("WEATHER_FIXTURE", "")
+		if path == "" {
+			return nil, fmt.Errorf("WEATHER_FIXTURE is required for the fixture provider")
+		}
+		return &Fixture{Path: path}, nil
+	default:
+		return nil, fmt.Errorf("unknown WEATHER_PROVIDER %q", name)
+	}
+}
+
+// LocationFromEnv reads the clothesline position from LAT and LON.
+func LocationFromEnv() (Location, error) {
+	lat, err := strconv.ParseFloat(config.GetEnv("LAT", ""), 64)
+	if err != nil {
+		return Location{}, fmt.Errorf("invalid LAT: %w", err)
+	}
+	lon, err := strconv.ParseFloat(config.GetEnv("LON", ""), 64)
+	if err != nil {
+		return Location{}, fmt.Errorf("invalid LON: %w", err)
+	}
+	return Location{Lat: lat, Lon: lon}, nil
+}
+
+// getJSON performs a GET request and decodes a JSON response into v.
+func getJSON(ctx context.Context, url string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("weather API returned %s", resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
@@ -0,0 +1,64 @@
+package ws
+
+import "backend/events"
+
+// Message types sent by clients.
+const (
+	// CommandSubscribe adds Topics to the subscription and sets its Device
+	// and TestID filters. With LastEventID the events after it are sent first.
+	CommandSubscribe = "subscribe"
+	// CommandUnsubscribe removes Topics from the subscription, or all topics if none are given.
+	CommandUnsubscribe = "unsubscribe"
+	// CommandStartSession starts a manual drying session.
+	CommandStartSession = "start_session"
+	// CommandStopSession stops session TestID, or the active session, with Reason.
+	CommandStopSession = "stop_session"
+	// CommandAckAlert acknowledges alert AlertID.
+	CommandAckAlert = "ack_alert"
+
+	// invalidMessage is the type of a message without one, or that could not be decoded.
+	invalidMessage = ""
+)
+
+// Message types sent by the server.
+const (
+	// MessageResult answers a command; Data holds its result.
+	MessageResult = "result"
+	// MessageError answers a command that failed.
+	MessageError = "error"
+	// MessageEvent carries an Event of a subscribed topic.
+	MessageEvent = "event"
+	// MessageReset tells the client that events were lost and it should reload its state.
+	MessageReset = "reset"
+)
+
+// ClientMessage is a command sent by a client. ID is echoed in the answer so
+// the client can match it to the command.
+type ClientMessage struct {
+	Type        string   `json:"type"`
+	ID          string   `json:"id,omitempty"`
+	Topics      []string `json:"topics,omitempty"`
+	Device      string   `json:"device,omitempty"`
+	TestID      int      `json:"test_id,omitempty"`
+	LastEventID uint64   `json:"last_event_id,omitempty"`
+	Reason      string   `json:"reason,omitempty"`
+	AlertID     uint     `json:"alert_id,omitempty"`
+
+	err error
+}
+
+// ServerMessage is a message sent to a client.
+type ServerMessage struct {
+	Type  string        `json:"type"`
+	ID    string        `json:"id,omitempty"`
+	Error string        `json:"error,omitempty"`
+	Data  any           `json:"data,omitempty"`
+	Event *events.Event `json:"event,omitempty"`
+}
+
+// Subscription is the answer to subscribe and unsubscribe commands.
+type Subscription struct {
+	Topics []string `json:"topics"`
+	Device string   `json:"device,omitempty"`
+	TestID int      `json:"test_id,omitempty"`
+}
@@ -0,0 +1,324 @@
+package ws
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"time"
+
+	"backend/alerts"
+	"backend/events"
+	"backend/models"
+	"backend/sessions"
+
+	"github.com/gorilla/websocket"
+)
+
+// Topics clients can subscribe to, and the event types each one carries.
+const (
+	// TopicDevice carries sensor readings.
+	TopicDevice = "device"
+	// TopicSession carries session starts and ends and ETA updates.
+	TopicSession = "session"
+	// TopicAlerts carries sent and acknowledged alerts.
+	TopicAlerts = "alerts"
+)
+
+var topicTypes = map[string][]string{
+	TopicDevice:  {events.TypeReading},
+	TopicSession: {events.TypeSession, events.TypeETA},
+	TopicAlerts:  {events.TypeAlert},
+}
+
+const (
+	// pingInterval is how often the server pings an idle client.
+	pingInterval = 30 * time.Second
+	// pongWait is how long a client may stay silent, pongs included, before it is dropped.
+	pongWait  = 2 * pingInterval
+	writeWait = 10 * time.Second
+	// maxMessageSize bounds client messages, which are small commands.
+	maxMessageSize = 4096
+)
+
+// Server is the WebSocket endpoint. Clients subscribe to topics and receive
+// the matching events from Bus, the same bus that feeds the SSE stream, and
+// send commands that act on Sessions and Alerts.
+type Server struct {
+	Bus      *events.Bus
+	Sessions *sessions.Manager
+	Alerts   alerts.Store
+	Now      func() time.Time
+	Upgrader websocket.Upgrader
+}
+
+// NewServer returns a Server on the shared bus, session manager and alert store.
+func NewServer() *Server {
+	return &Server{
+		Bus:      events.Default,
+		Sessions: sessions.Default,
+		Alerts:   alerts.Default,
+		Now:      time.Now,
+		Upgrader: websocket.Upgrader{
+			// The API is open to any origin, as with CORS.
+			CheckOrigin: func(*http.Request) bool { return true },
+		},
+	}
+}
+
+// Default is the Server used by the /api/ws route.
+var Default = NewServer()
+
+// ServeHTTP upgrades the request and serves the connection until the client leaves.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// The server's write timeout would cut the connection; it lives as long as the client.
+	http.NewResponseController(w).SetWriteDeadline(time.Time{})
+
+	conn, err := s.Upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// The upgrader has already replied with an error.
+		return
+	}
+	c := &client{server: s, conn: conn, in: make(chan ClientMessage), done: make(chan struct{})}
+	go c.read()
+	c.run()
+}
+
+// client is one WebSocket connection. All writes happen on the run goroutine.
+type client struct {
+	server *Server
+	conn   *websocket.Conn
+	in     chan ClientMessage
+	// readErr is why reading stopped, set before in is closed.
+	readErr error
+	// done is closed when run returns, so read does not block on in.
+	done chan struct{}
+
+	topics []string
+	filter events.Filter
+	sub    *events.Subscription
+	// lastID is the last event the client has seen; events up to it are not sent again.
+	lastID uint64
+}
+
+// read decodes client messages into c.in until the connection fails.
+func (c *client) read() {
+	defer close(c.in)
+	c.conn.SetReadLimit(maxMessageSize)
+	c.conn.SetReadDeadline(time.Now().Add(pongWait))
+	c.conn.SetPongHandler(func(string) error {
+		return c.conn.SetReadDeadline(time.Now().Add(pongWait))
+	})
+	for {
+		_, data, err := c.conn.ReadMessage()
+		if err != nil {
+			c.readErr = err
+			return
+		}
+		var msg ClientMessage
+		if err := json.Unmarshal(data, &msg); err != nil {
+			msg = ClientMessage{Type: invalidMessage, err: err}
+		}
+		c.conn.SetReadDeadline(time.Now().Add(pongWait))
+		select {
+		case c.in <- msg:
+		case <-c.done:
+			return
+		}
+	}
+}
+
+func (c *client) run() {
+	defer close(c.done)
+	defer c.conn.Close()
+	defer func() {
+		if c.sub != nil {
+			c.server.Bus.Unsubscribe(c.sub)
+		}
+	}()
+
+	ping := time.NewTicker(pingInterval)
+	defer ping.Stop()
+	for {
+		var incoming <-chan events.Event
+		if c.sub != nil {
+			incoming = c.sub.C
+		}
+
+		var err error
+		select {
+		case msg, ok := <-c.in:
+			if !ok {
+				if c.readErr != nil && !websocket.IsCloseError(c.readErr, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+					log.Printf("WebSocket client %s: %v", c.conn.RemoteAddr(), c.readErr)
+				}
+				return
+			}
+			err = c.handle(msg)
+		case e, ok := <-incoming:
+			if !ok {
+				// Dropped for falling behind; pick up from the last event sent.
+				c.sub = nil
+				err = c.resubscribe()
+				break
+			}
+			err = c.sendEvent(e)
+		case <-ping.C:
+			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			err = c.conn.WriteMessage(websocket.PingMessage, nil)
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// handle answers one client message. The returned error is a write failure
+// that ends the connection; command failures are sent to the client.
+func (c *client) handle(msg ClientMessage) error {
+	reply := ServerMessage{Type: MessageResult, ID: msg.ID}
+	data, err := c.command(msg)
+	if err != nil {
+		reply.Type, reply.Error = MessageError, err.Error()
+	} else {
+		reply.Data = data
+	}
+	if err := c.write(reply); err != nil {
+		return err
+	}
+
+	// Send the backlog after confirming the subscription, so the client
+	// knows its topics are in place before events arrive.
+	if err == nil && (msg.Type == CommandSubscribe || msg.Type == CommandUnsubscribe) {
+		return c.resubscribe()
+	}
+	return nil
+}
+
+func (c *client) command(msg ClientMessage) (any, error) {
+	s := c.server
+	switch msg.Type {
+	case CommandSubscribe:
+		for _, t := range msg.Topics {
+			if _, ok := topicTypes[t]; !ok {
+				return nil, fmt.Errorf("unknown topic %q, expected device, session or alerts", t)
+			}
+		}
+		if msg.TestID < 0 {
+			return nil, errors.New("test_id must be a positive integer")
+		}
+		for _, t := range msg.Topics {
+			if !slices.Contains(c.topics, t) {
+				c.topics = append(c.topics, t)
+			}
+		}
+		c.filter.Device, c.filter.TestID = msg.Device, msg.TestID
+		switch {
+		case msg.LastEventID > 0:
+			c.lastID = msg.LastEventID
+		case c.sub == nil:
+			// Start from now rather than replaying what happened while unsubscribed.
+			c.lastID = s.Bus.LastID()
+		}
+		return c.subscription(), nil
+
+	case CommandUnsubscribe:
+		if len(msg.Topics) == 0 {
+			c.topics = nil
+		}
+		c.topics = slices.DeleteFunc(c.topics, func(t string) bool { return slices.Contains(msg.Topics, t) })
+		return c.subscription(), nil
+
+	case CommandStartSession:
+		return s.Sessions.Start()
+
+	case CommandStopSession:
+		reason := msg.Reason
+		if reason == "" {
+			reason = models.EndReasonManual
+		}
+		if !models.IsValidEndReason(reason) {
+			return nil, errors.New("reason must be one of manual, dry_detected, timeout, rain")
+		}
+		testID := msg.TestID
+		if testID == 0 {
+			active, err := s.Sessions.Active()
+			if err != nil {
+				return nil, err
+			}
+			if active == nil {
+				return nil, errors.New("no active session")
+			}
+			testID = active.TestID
+		}
+		return s.Sessions.Stop(testID, reason)
+
+	case CommandAckAlert:
+		if msg.AlertID == 0 {
+			return nil, errors.New("alert_id is required")
+		}
+		return s.Alerts.Acknowledge(msg.AlertID, s.Now())
+
+	case invalidMessage:
+		if msg.err != nil {
+			return nil, fmt.Errorf("invalid message: %v", msg.err)
+		}
+		return nil, errors.New("type is required")
+
+	default:
+		return nil, fmt.Errorf("unknown message type %q", msg.Type)
+	}
+}
+
+// subscription describes the client's current topics and filters.
+func (c *client) subscription() Subscription {
+	return Subscription{Topics: slices.Clone(c.topics), Device: c.filter.Device, TestID: c.filter.TestID}
+}
+
+// resubscribe replaces the bus subscription with one for the current topics,
+// first sending the stored events after the last one the client has seen, so
+// nothing is lost while switching. The client is told to reset when some of
+// those are no longer available.
+func (c *client) resubscribe() error {
+	if c.sub != nil {
+		c.server.Bus.Unsubscribe(c.sub)
+		c.sub = nil
+	}
+	if len(c.topics) == 0 {
+		return nil
+	}
+
+	c.filter.Types = nil
+	for _, t := range c.topics {
+		c.filter.Types = append(c.filter.Types, topicTypes[t]...)
+	}
+	sub, backlog, complete := c.server.Bus.Subscribe(c.filter, c.lastID)
+	c.sub = sub
+	if !complete {
+		if err := c.write(ServerMessage{Type: MessageReset, Data: map[string]uint64{"last_event_id": c.server.Bus.LastID()}}); err != nil {
+			return err
+		}
+	}
+	for _, e := range backlog {
+		if err := c.sendEvent(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *client) sendEvent(e events.Event) error {
+	if e.ID <= c.lastID {
+		// Already sent before a resubscription.
+		return nil
+	}
+	c.lastID = e.ID
+	return c.write(ServerMessage{Type: MessageEvent, Event: &e})
+}
+
+func (c *client) write(msg ServerMessage) error {
+	c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+	return c.conn.WriteJSON(msg)
+}
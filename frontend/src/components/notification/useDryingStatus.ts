@@ -1,18 +1,20 @@
 // src/components/notification/useDryingStatus.ts
 import useSWR from 'swr';
-
-const fetcher = (url: string) => fetch(url).then((res) => res.json());
+import { API_URL, fetcher } from '@/lib/api';
 
 export function useDryingStatus() {
-  const { data: generalStatus } = useSWR('http://localhost:8080/api/ttd/status', fetcher);
+  // latest_test_id is the active drying session, or the latest one when none is running.
+  const { data: generalStatus } = useSWR(`${API_URL}/api/ttd/status`, fetcher, { refreshInterval: 10000 });
+  const testID = generalStatus?.latest_test_id;
   const { data: testStatus } = useSWR(
-    'http://localhost:8080/api/ttd/status/check?test_id=0',
+    testID ? `${API_URL}/api/ttd/status/check?test_id=${testID}` : null,
     fetcher,
     { refreshInterval: 10000 }
   );
 
   return {
     isWorking: generalStatus?.is_working,
+    testID,
     testStatus: testStatus?.status,
     lastUpdated: testStatus?.last_timestamp,
   };
@@ -0,0 +1,35 @@
+export const API_URL = 'http://localhost:8080';
+
+export interface Pagination {
+  limit: number;
+  order: 'asc' | 'desc';
+  count: number;
+  total: number;
+  has_more: boolean;
+  next_cursor?: string;
+}
+
+// Page is the body of the paginated list endpoints (/api/timetodry, /api/tmd, /api/combined).
+export interface Page<T> {
+  data: T[];
+  pagination: Pagination;
+}
+
+export const fetcher = (url: string) =>
+  fetch(url).then((res) => {
+    if (!res.ok) throw new Error(`Request failed: ${res.status}`);
+    return res.json();
+  });
+
+export interface Stats {
+  min: number;
+  max: number;
+  avg: number;
+}
+
+// Aggregate is the body of the /aggregate endpoints: readings downsampled into time buckets.
+export interface Aggregate {
+  interval: string;
+  fields: string[];
+  buckets: { start: string; count: number; fields: Record<string, Stats> }[];
+}
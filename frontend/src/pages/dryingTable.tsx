@@ -1,10 +1,9 @@
 // src/pages/dryingTable.tsx
 import Head from 'next/head';
-import { useRouter } from 'next/router';
-import React, { useEffect, useState } from 'react';
+import React, { useState } from 'react';
 import useSWR from 'swr';
-import { generatePagination } from '@/lib/generatePagination';
 import { DotLottieReact } from '@lottiefiles/dotlottie-react';
+import { API_URL, Page, fetcher } from '@/lib/api';
 
 interface DryingTest {
   id: number;
@@ -21,36 +20,33 @@ interface DryingTest {
   test_id: number;
 }
 
-const fetcher = (url: string) => fetch(url).then(res => res.json());
-
 export default function DryingTable() {
-  const router = useRouter();
-  const { query } = router;
-  const { data: allTests, error, isLoading } = useSWR<DryingTest[]>(
-    'http://localhost:8080/api/timetodry',
-    fetcher,
-    { refreshInterval: 60000 }
-  );
-
-  const [currentPage, setCurrentPage] = useState(Number(query.page) || 1);
   const [selectedTestId, setSelectedTestId] = useState<number | 'all'>('all');
   const [itemsPerPage, setItemsPerPage] = useState<number>(10);
+  // Cursors of the pages before the current one; the API pages forward with next_cursor.
+  const [cursors, setCursors] = useState<string[]>([]);
+  const cursor = cursors[cursors.length - 1];
 
-  useEffect(() => {
-    if (query.page) {
-      setCurrentPage(Number(query.page));
-    }
-  }, [query.page]);
+  const params = new URLSearchParams({ limit: String(itemsPerPage), order: 'desc' });
+  if (selectedTestId !== 'all') params.set('test_id', String(selectedTestId));
+  if (cursor) params.set('cursor', cursor);
 
-  const handlePageChange = (page: number) => {
-    router.push({ pathname: '/dryingTable', query: { ...query, page } });
-  };
+  const { data: page, error, isLoading } = useSWR<Page<DryingTest>>(
+    `${API_URL}/api/timetodry?${params}`,
+    fetcher,
+    { refreshInterval: 60000, keepPreviousData: true }
+  );
+  const { data: sessions } = useSWR<{ test_id: number }[]>(`${API_URL}/api/sessions?limit=200`, fetcher);
 
-  const filteredData = allTests?.filter(test => selectedTestId === 'all' || test.test_id === selectedTestId) || [];
+  const paginatedData = page?.data ?? [];
+  const currentPage = cursors.length + 1;
+  const totalPages = page ? Math.max(1, Math.ceil(page.pagination.total / itemsPerPage)) : 1;
 
-  const totalPages = itemsPerPage === -1 ? 1 : Math.ceil(filteredData.length / itemsPerPage);
-  const paginatedData = itemsPerPage === -1 ? filteredData : filteredData.slice((currentPage - 1) * itemsPerPage, currentPage * itemsPerPage);
-  const pageNumbers = generatePagination(currentPage, totalPages);
+  const resetPages = () => setCursors([]);
+  const nextPage = () => {
+    if (page?.pagination.next_cursor) setCursors([...cursors, page.pagination.next_cursor]);
+  };
+  const previousPage = () => setCursors(cursors.slice(0, -1));
 
   return (
     <>
@@ -83,12 +79,12 @@ export default function DryingTable() {
                   value={selectedTestId}
                   onChange={(e) => {
                     setSelectedTestId(e.target.value === 'all' ? 'all' : Number(e.target.value));
-                    setCurrentPage(1);
+                    resetPages();
                   }}
                   className="border px-2 py-1 rounded-md"
                 >
                   <option value="all">All</option>
-                  {[...new Set((allTests ?? []).map(test => test.test_id))].map(id => (
+                  {(sessions ?? []).map(({ test_id: id }) => (
                     <option key={id} value={id}>Test-{id}</option>
                   ))}
                 </select>
@@ -99,16 +95,14 @@ export default function DryingTable() {
                 <select
                   value={itemsPerPage}
                   onChange={(e) => {
-                    const value = e.target.value === 'all' ? -1 : Number(e.target.value);
-                    setItemsPerPage(value);
-                    setCurrentPage(1);
+                    setItemsPerPage(Number(e.target.value));
+                    resetPages();
                   }}
                   className="border px-2 py-1 rounded-md"
                 >
-                  {[10, 25, 50, 100].map(n => (
+                  {[10, 25, 50, 100, 500].map(n => (
                     <option key={n} value={n}>{n}</option>
                   ))}
-                  <option value="all">All</option>
                 </select>
               </div>
             </div>
@@ -152,30 +146,18 @@ export default function DryingTable() {
             {totalPages > 1 && (
               <div className="flex justify-center mt-6 space-x-1">
                 <button
-                  onClick={() => handlePageChange(Math.max(1, currentPage - 1))}
+                  onClick={previousPage}
                   disabled={currentPage === 1}
                   className="px-3 py-1 rounded bg-gray-200 hover:bg-gray-300 disabled:opacity-50"
                 >
                   Previous
                 </button>
-                {pageNumbers.map((page, i) =>
-                  page === '...' ? (
-                    <span key={i} className="px-3 py-1 text-gray-500">...</span>
-                  ) : (
-                    <button
-                      key={i}
-                      onClick={() => handlePageChange(page)}
-                      className={`px-3 py-1 rounded ${
-                        currentPage === page ? 'bg-blue-600 text-white' : 'bg-gray-200 text-gray-700 hover:bg-gray-300'
-                      }`}
-                    >
-                      {page}
-                    </button>
-                  )
-                )}
+                <span className="px-3 py-1 text-gray-700">
+                  Page {currentPage} of {totalPages}
+                </span>
                 <button
-                  onClick={() => handlePageChange(Math.min(totalPages, currentPage + 1))}
-                  disabled={currentPage === totalPages}
+                  onClick={nextPage}
+                  disabled={!page?.pagination.has_more}
                   className="px-3 py-1 rounded bg-gray-200 hover:bg-gray-300 disabled:opacity-50"
                 >
                   Next
@@ -6,71 +6,61 @@ import { ProgressBar } from '@/components/statistics/ProgressBar';
 import useSWR from 'swr';
 import { DotLottieReact } from '@lottiefiles/dotlottie-react';
 import DryingChart from '@/components/charts/DryingChart';
+import { API_URL, Aggregate, Page, fetcher } from '@/lib/api';
 
-const fetcher = (url: string) => fetch(url).then((res) => res.json());
+const chartFields = ['hum_in', 'hum_out', 'temp_in', 'temp_out'];
 
 export default function Statistics() {
-  const { data: allTests } = useSWR<any[]>('http://localhost:8080/api/timetodry', fetcher);
+  const { data: sessions } = useSWR<{ test_id: number }[]>(`${API_URL}/api/sessions?limit=200`, fetcher);
   const [selectedTest, setSelectedTest] = useState<number | null>(null);
+  const testUrl = (path: string, params: Record<string, string>) =>
+    selectedTest !== null ? `${API_URL}${path}?${new URLSearchParams({ test_id: String(selectedTest), ...params })}` : null;
+  const { data: firstPage } = useSWR<Page<any>>(testUrl('/api/timetodry', { limit: '1', fields: 'timestamp' }), fetcher);
+  const { data: lastPage } = useSWR<Page<any>>(testUrl('/api/timetodry', { limit: '1', order: 'desc' }), fetcher);
+  // Charts only need per-minute averages, not every reading.
+  const { data: chartBuckets } = useSWR<Aggregate>(
+    testUrl('/api/timetodry/aggregate', { interval: '1m', fields: chartFields.join(',') }),
+    fetcher
+  );
   const { data: deviceStatusByTest } = useSWR<{ status: string; test_id: number; last_timestamp: string }>(
-    selectedTest !== null ? `http://localhost:8080/api/ttd/status/check?test_id=${selectedTest}` : null,
+    testUrl('/api/ttd/status/check', {}),
     fetcher,
     { refreshInterval: 10000 }
   );
 
-  const [durationMinutes, setDurationMinutes] = useState<number>(0);
   const [estimatedMinutes, setEstimatedMinutes] = useState<number | null>(null);
-  const [data, setData] = useState<any | null>(null);
-  const [selectedTestData, setSelectedTestData] = useState<any[]>([]);
+  const data = lastPage?.data[0] ?? null;
+  const durationMinutes =
+    data && firstPage?.data[0]
+      ? Math.round((new Date(data.timestamp).getTime() - new Date(firstPage.data[0].timestamp).getTime()) / (1000 * 60))
+      : 0;
+  const selectedTestData = (chartBuckets?.buckets ?? []).map((b) => ({
+    timestamp: b.start,
+    ...Object.fromEntries(chartFields.map((f) => [f, b.fields[f]?.avg])),
+  }));
 
   useEffect(() => {
-    if (allTests && allTests.length > 0) {
-      const groupedByTestId: { [key: number]: any[] } = {};
-      allTests.forEach((entry) => {
-        if (!groupedByTestId[entry.test_id]) {
-          groupedByTestId[entry.test_id] = [];
-        }
-        groupedByTestId[entry.test_id].push(entry);
-      });
-
-      const testIds = Object.keys(groupedByTestId).map(Number);
-      const initialTestId = testIds[0];
-      setSelectedTest(initialTestId);
+    if (sessions && sessions.length > 0 && selectedTest === null) {
+      setSelectedTest(sessions[0].test_id);
     }
-  }, [allTests]);
+  }, [sessions, selectedTest]);
 
   useEffect(() => {
-    if (allTests && selectedTest !== null) {
-      const filtered = allTests.filter((entry) => entry.test_id === selectedTest);
-      setSelectedTestData(filtered);
-  
-      if (filtered.length > 0) {
-        const first = new Date(filtered[0].timestamp);
-        const last = new Date(filtered[filtered.length - 1].timestamp);
-        const diff = last.getTime() - first.getTime();
-        const minutes = Math.round(diff / (1000 * 60));
-        setDurationMinutes(minutes);
-        setData(filtered[filtered.length - 1]);
-  
-        const lastEntry = filtered[filtered.length - 1];
-        const query = new URLSearchParams({
-          temp_in: String(lastEntry.temp_in),
-          temp_out: String(lastEntry.temp_out),
-          hum_in: String(lastEntry.hum_in),
-          hum_out: String(lastEntry.hum_out),
-          light: String(lastEntry.light),
-        }).toString();
-  
-        fetch(`http://localhost:8080/api/drytime/estimate?${query}`)
-          .then((res) => res.json())
-          .then((res) => setEstimatedMinutes(res.estimated_drying_time_minutes))
-          .catch((err) => console.error('Failed to fetch estimated drying time:', err));
-      } else {
-        console.warn('No test entries found for selected test');
-      }
-    }
-  }, [allTests, selectedTest]);
-  
+    if (!data) return;
+    const query = new URLSearchParams({
+      temp_in: String(data.temp_in),
+      temp_out: String(data.temp_out),
+      hum_in: String(data.hum_in),
+      hum_out: String(data.hum_out),
+      light: String(data.light),
+    }).toString();
+
+    fetch(`${API_URL}/api/drytime/estimate?${query}`)
+      .then((res) => res.json())
+      .then((res) => setEstimatedMinutes(res.estimated_drying_time_minutes))
+      .catch((err) => console.error('Failed to fetch estimated drying time:', err));
+  }, [data?.id]);
+
   if (!data) return (
     <div className="flex items-center justify-center min-h-screen">
       <DotLottieReact
@@ -111,7 +101,7 @@ export default function Statistics() {
           <div className="mb-6">
             <label className="block text-sm font-medium text-gray-700 mb-1">Select Test</label>
             <select className="w-full border border-gray-300 rounded-md py-2 px-3" value={selectedTest ?? ''} onChange={(e) => setSelectedTest(Number(e.target.value))}>
-              {allTests && [...new Set(allTests.map(test => test.test_id))].map((id) => (
+              {sessions && sessions.map(({ test_id: id }) => (
                 <option key={id} value={id}>{`TEST-${id}`}</option>
               ))}
             </select>